@@ -1,17 +1,58 @@
 package clicmd
 
 import (
+	"bufio"
 	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
 	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
 
 	"github.com/joshilesanmi/open-search-dev/search"
+	"github.com/joshilesanmi/open-search-dev/search/memory"
 	"github.com/joshilesanmi/open-search-dev/search/opensearch"
 	"github.com/rs/zerolog"
 	"github.com/urfave/cli/v2"
 )
 
-func makeOpenSearchClient(endpoint string, logger zerolog.Logger, opts ...opensearch.OpenSearchOption) (search.SearchEngine, error) {
-	return opensearch.NewOpenSearch(endpoint, logger, opts...)
+// makeOpenSearchClient creates an engine for the endpoint given on the command line. Authentication, TLS and
+// timeouts are read from the environment, see opensearch.ConfigFromEnv; the secondary cluster is only configured
+// by commands with a --secondary-endpoint flag.
+func makeOpenSearchClient(endpoint string, logger zerolog.Logger, opts ...opensearch.OpenSearchOption) (opensearch.Engine, error) {
+	config, err := opensearch.ConfigFromEnv()
+	if err != nil {
+		return nil, err
+	}
+	config.Endpoint = endpoint
+	config.SecondaryEndpoint, config.SecondaryName = "", ""
+	if err := config.Validate(); err != nil {
+		return nil, err
+	}
+	return config.NewEngine(logger, opts...)
+}
+
+// makeRedactor returns the Redactor of the --redact-field and --hash-field flags, or nil if neither is set.
+func makeRedactor(c *cli.Context) (*search.Redactor, error) {
+	var rules []search.RedactionRule
+	for _, field := range c.StringSlice("redact-field") {
+		rules = append(rules, search.RedactionRule{Field: field, Action: search.RedactDrop})
+	}
+	for _, field := range c.StringSlice("hash-field") {
+		rules = append(rules, search.RedactionRule{Field: field, Action: search.RedactHash})
+	}
+	if len(rules) == 0 {
+		return nil, nil
+	}
+
+	key := c.String("redaction-key")
+	if key == "" && len(c.StringSlice("hash-field")) > 0 {
+		return nil, cli.Exit("--hash-field requires --redaction-key", 1)
+	}
+	return search.NewRedactor([]byte(key), rules...), nil
 }
 
 var indexConfig = map[string]interface{}{
@@ -69,8 +110,25 @@ var indexConfig = map[string]interface{}{
 	},
 }
 
+// indexTemplates are the built-in index configurations of create-index, by name.
+var indexTemplates = map[string]map[string]interface{}{
+	"neodxp-default": indexConfig,
+}
+
+// indexTemplateNames returns the sorted names of the built-in index configurations.
+func indexTemplateNames() []string {
+	names := make([]string, 0, len(indexTemplates))
+	for name := range indexTemplates {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
 func OpenSearch() *cli.Command {
-	logger := zerolog.New(os.Stdout).
+	// Logs go to stderr, and only from info level, so the output of the commands can be piped into other tools.
+	logger := zerolog.New(os.Stderr).
+		Level(zerolog.InfoLevel).
 		With().
 		Timestamp().
 		Caller().
@@ -88,30 +146,1471 @@ func OpenSearch() *cli.Command {
 			&cli.StringFlag{
 				Name:     "endpoint",
 				Usage:    "cluster endpoint (url)",
+				EnvVars:  []string{"OPENSEARCH_ENDPOINT"},
 				Required: true,
 			},
+			&cli.StringFlag{
+				Name:  "config",
+				Usage: "JSON or YAML file with the settings, mappings and aliases of the index",
+			},
+			&cli.StringFlag{
+				Name:  "template",
+				Usage: "built-in index configuration: " + strings.Join(indexTemplateNames(), ", "),
+				Value: "neodxp-default",
+			},
+			&cli.StringFlag{
+				Name:  "language",
+				Usage: "language of the text fields, analyzed with its stop words and stemming: english, german or french",
+			},
+			&cli.BoolFlag{
+				Name:  "dry-run",
+				Usage: "validate and print the configuration without creating the index",
+			},
 		},
 		Action: createIndex(logger),
 	}
 
+	doctor := &cli.Command{
+		Name:  "doctor",
+		Usage: "check connectivity, version, indices and disk usage of the configured clusters",
+		Flags: []cli.Flag{
+			&cli.StringFlag{
+				Name:     "endpoint",
+				Usage:    "cluster endpoint (url)",
+				EnvVars:  []string{"OPENSEARCH_ENDPOINT"},
+				Required: true,
+			},
+			&cli.StringFlag{
+				Name:    "secondary-endpoint",
+				Usage:   "secondary cluster endpoint (url)",
+				EnvVars: []string{"OPENSEARCH_SECONDARY_ENDPOINT"},
+			},
+			&cli.StringSliceFlag{
+				Name:  "index-name",
+				Usage: "index expected to exist with the default index config (repeatable)",
+			},
+		},
+		Action: doctor(logger),
+	}
+
+	searchCmd := &cli.Command{
+		Name:  "search",
+		Usage: "search the documents of an instance",
+		Flags: []cli.Flag{
+			&cli.StringFlag{
+				Name:     "endpoint",
+				Usage:    "cluster endpoint (url)",
+				EnvVars:  []string{"OPENSEARCH_ENDPOINT"},
+				Required: true,
+			},
+			&cli.StringFlag{
+				Name:     "instance-id",
+				Usage:    "instance to search",
+				Required: true,
+			},
+			&cli.StringFlag{
+				Name:     "query",
+				Usage:    "query string",
+				Required: true,
+			},
+			&cli.StringFlag{
+				Name:    "index",
+				Aliases: []string{"index-name"},
+				Usage:   "restrict the search to an index",
+			},
+			&cli.IntFlag{
+				Name:  "size",
+				Usage: "maximum number of documents returned",
+				Value: 10,
+			},
+			&cli.StringFlag{
+				Name:  "sort",
+				Usage: "comma-separated sort fields, e.g. created_at:desc,name.raw",
+			},
+			&cli.StringSliceFlag{
+				Name:  "fields",
+				Usage: "document fields printed, comma-separated or repeatable, e.g. name,address.city; all fields by default",
+			},
+			&cli.StringFlag{
+				Name:  "output",
+				Usage: "output format: ndjson, json or table",
+				Value: "ndjson",
+			},
+			&cli.BoolFlag{
+				Name:  "dump-query",
+				Usage: "print the query DSL that would be sent instead of searching",
+			},
+			&cli.StringSliceFlag{
+				Name:  "redact-field",
+				Usage: "dotted path of a field removed from the printed documents and logged queries (repeatable)",
+			},
+			&cli.StringSliceFlag{
+				Name:  "hash-field",
+				Usage: "dotted path of a field replaced by a keyed hash in the printed documents and logged queries (repeatable)",
+			},
+			&cli.StringFlag{
+				Name:    "redaction-key",
+				Usage:   "key of the hashes of --hash-field",
+				EnvVars: []string{"OPENSEARCH_REDACTION_KEY"},
+			},
+		},
+		Action: searchDocuments(logger),
+	}
+
+	schema := &cli.Command{
+		Name:  "schema",
+		Usage: "manage the per-entity mapping fragments of an index",
+		Subcommands: []*cli.Command{
+			{
+				Name:  "validate",
+				Usage: "compose the schema fragments of a directory and validate them against an index mapping",
+				Flags: []cli.Flag{
+					&cli.StringFlag{
+						Name:     "dir",
+						Usage:    "directory of schema fragment files (*.json)",
+						Required: true,
+					},
+					&cli.StringFlag{
+						Name:     "endpoint",
+						Usage:    "cluster endpoint (url)",
+						EnvVars:  []string{"OPENSEARCH_ENDPOINT"},
+						Required: true,
+					},
+					&cli.StringFlag{
+						Name:     "index-name",
+						Usage:    "index whose mapping is validated",
+						Required: true,
+					},
+				},
+				Action: validateSchema(logger),
+			},
+		},
+	}
+
+	sandbox := &cli.Command{
+		Name:  "sandbox",
+		Usage: "load an NDJSON fixture into the in-memory engine and run queries against it, without a cluster",
+		Flags: []cli.Flag{
+			&cli.StringFlag{
+				Name:     "fixture",
+				Usage:    "NDJSON file of documents carrying instance_id, entity_name and id",
+				Required: true,
+			},
+			&cli.StringFlag{
+				Name:     "instance-id",
+				Usage:    "instance to search",
+				Required: true,
+			},
+			&cli.StringFlag{
+				Name:  "query",
+				Usage: "query string; if omitted, queries are read from stdin, one per line",
+			},
+			&cli.StringFlag{
+				Name:  "mode",
+				Usage: "query mode: query_string, simple or multi_match",
+				Value: "query_string",
+			},
+			&cli.StringFlag{
+				Name:  "operator",
+				Usage: "default operator: or, and",
+				Value: "or",
+			},
+			&cli.StringSliceFlag{
+				Name:  "field",
+				Usage: "field the query is matched against (repeatable)",
+			},
+		},
+		Action: sandbox(),
+	}
+
+	dashboards := &cli.Command{
+		Name:  "dashboards",
+		Usage: "manage OpenSearch Dashboards saved objects",
+		Subcommands: []*cli.Command{
+			{
+				Name:  "provision",
+				Usage: "provision the index pattern and basic visualizations of an instance",
+				Flags: []cli.Flag{
+					&cli.StringFlag{
+						Name:     "dashboards-endpoint",
+						Usage:    "OpenSearch Dashboards endpoint (url)",
+						Required: true,
+					},
+					&cli.StringFlag{
+						Name:     "index-name",
+						Usage:    "index whose instance alias the index pattern targets",
+						Required: true,
+					},
+					&cli.StringFlag{
+						Name:     "instance-id",
+						Usage:    "instance to provision",
+						Required: true,
+					},
+					&cli.StringFlag{
+						Name:  "time-field",
+						Usage: "date field of the index pattern and the timeline visualization",
+					},
+				},
+				Action: provisionDashboards(),
+			},
+		},
+	}
+
+	suggestMappingCmd := &cli.Command{
+		Name:  "suggest-mapping",
+		Usage: "sample documents of an index or NDJSON file and propose an explicit mapping",
+		Flags: []cli.Flag{
+			&cli.StringFlag{
+				Name:  "file",
+				Usage: "NDJSON file of documents to sample",
+			},
+			&cli.StringFlag{
+				Name:    "endpoint",
+				Usage:   "cluster endpoint (url)",
+				EnvVars: []string{"OPENSEARCH_ENDPOINT"},
+			},
+			&cli.StringFlag{
+				Name:  "index-name",
+				Usage: "index to sample",
+			},
+			&cli.IntFlag{
+				Name:  "sample-size",
+				Usage: "number of documents to sample",
+				Value: 1000,
+			},
+		},
+		Action: suggestMapping(logger),
+	}
+
+	promoteSecondaryCmd := &cli.Command{
+		Name:  "promote-secondary",
+		Usage: "check the secondary cluster is consistent with the primary and promote it to primary in the configuration file of the services",
+		Flags: []cli.Flag{
+			&cli.StringFlag{
+				Name:     "config",
+				Usage:    "JSON or YAML engine configuration file, with a secondary endpoint, that the services are created from",
+				Required: true,
+			},
+			&cli.StringSliceFlag{
+				Name:  "index-name",
+				Usage: "index compared between the clusters before promoting (repeatable)",
+			},
+			&cli.BoolFlag{
+				Name:  "force",
+				Usage: "promote even if the clusters diverge",
+			},
+		},
+		Action: promoteSecondary(logger),
+	}
+
+	verifyClustersCmd := &cli.Command{
+		Name:  "verify-clusters",
+		Usage: "compares the documents of indices between the primary and the secondary cluster",
+		Flags: []cli.Flag{
+			&cli.StringFlag{
+				Name:     "endpoint",
+				Usage:    "primary cluster endpoint (url)",
+				EnvVars:  []string{"OPENSEARCH_ENDPOINT"},
+				Required: true,
+			},
+			&cli.StringFlag{
+				Name:     "secondary-endpoint",
+				Usage:    "secondary cluster endpoint (url)",
+				EnvVars:  []string{"OPENSEARCH_SECONDARY_ENDPOINT"},
+				Required: true,
+			},
+			&cli.StringSliceFlag{
+				Name:     "index-name",
+				Aliases:  []string{"index"},
+				Usage:    "index compared between the clusters (repeatable)",
+				Required: true,
+			},
+			&cli.StringSliceFlag{
+				Name:  "ignore-field",
+				Usage: "dotted path of a field ignored in the comparison (repeatable)",
+			},
+			&cli.StringFlag{
+				Name:  "output",
+				Usage: "file the diff report is exported to as JSON",
+			},
+		},
+		Action: verifyClusters(logger),
+	}
+
+	getDocumentCmd := &cli.Command{
+		Name:  "get-document",
+		Usage: "prints a document by its instance and entity",
+		Flags: []cli.Flag{
+			&cli.StringFlag{
+				Name:     "endpoint",
+				Usage:    "primary cluster endpoint (url)",
+				EnvVars:  []string{"OPENSEARCH_ENDPOINT"},
+				Required: true,
+			},
+			&cli.StringFlag{
+				Name:    "secondary-endpoint",
+				Usage:   "secondary cluster endpoint (url), required by --cluster secondary and both",
+				EnvVars: []string{"OPENSEARCH_SECONDARY_ENDPOINT"},
+			},
+			&cli.StringFlag{
+				Name:     "index-name",
+				Usage:    "index name",
+				Required: true,
+			},
+			&cli.StringFlag{
+				Name:     "instance-id",
+				Usage:    "instance id",
+				Required: true,
+			},
+			&cli.StringFlag{
+				Name:     "entity-name",
+				Usage:    "entity name, e.g. person",
+				Required: true,
+			},
+			&cli.StringFlag{
+				Name:     "entity-id",
+				Usage:    "entity id",
+				Required: true,
+			},
+			&cli.StringFlag{
+				Name:  "cluster",
+				Usage: "cluster the document is read from: primary, secondary or both",
+				Value: "primary",
+			},
+			&cli.StringSliceFlag{
+				Name:  "ignore-field",
+				Usage: "dotted path of a field ignored when comparing the clusters with --cluster both (repeatable)",
+			},
+			&cli.StringSliceFlag{
+				Name:  "redact-field",
+				Usage: "dotted path of a field removed from the printed documents and logged queries (repeatable)",
+			},
+			&cli.StringSliceFlag{
+				Name:  "hash-field",
+				Usage: "dotted path of a field replaced by a keyed hash in the printed documents and logged queries (repeatable)",
+			},
+			&cli.StringFlag{
+				Name:    "redaction-key",
+				Usage:   "key of the hashes of --hash-field",
+				EnvVars: []string{"OPENSEARCH_REDACTION_KEY"},
+			},
+		},
+		Action: getDocument(logger),
+	}
+
+	deleteDocumentCmd := &cli.Command{
+		Name:  "delete-document",
+		Usage: "deletes a document by its instance and entity",
+		Flags: []cli.Flag{
+			&cli.StringFlag{
+				Name:     "endpoint",
+				Usage:    "primary cluster endpoint (url)",
+				EnvVars:  []string{"OPENSEARCH_ENDPOINT"},
+				Required: true,
+			},
+			&cli.StringFlag{
+				Name:    "secondary-endpoint",
+				Usage:   "secondary cluster endpoint (url)",
+				EnvVars: []string{"OPENSEARCH_SECONDARY_ENDPOINT"},
+			},
+			&cli.StringFlag{
+				Name:     "index-name",
+				Usage:    "index name",
+				Required: true,
+			},
+			&cli.StringFlag{
+				Name:     "instance-id",
+				Usage:    "instance id",
+				Required: true,
+			},
+			&cli.StringFlag{
+				Name:     "entity-name",
+				Usage:    "entity name, e.g. person",
+				Required: true,
+			},
+			&cli.StringFlag{
+				Name:     "entity-id",
+				Usage:    "entity id",
+				Required: true,
+			},
+		},
+		Action: deleteDocument(logger),
+	}
+
+	deleteIndexCmd := &cli.Command{
+		Name:  "delete-index",
+		Usage: "deletes an index and its documents",
+		Flags: []cli.Flag{
+			&cli.StringFlag{
+				Name:     "endpoint",
+				Usage:    "primary cluster endpoint (url)",
+				EnvVars:  []string{"OPENSEARCH_ENDPOINT"},
+				Required: true,
+			},
+			&cli.StringFlag{
+				Name:    "secondary-endpoint",
+				Usage:   "secondary cluster endpoint (url)",
+				EnvVars: []string{"OPENSEARCH_SECONDARY_ENDPOINT"},
+			},
+			&cli.StringFlag{
+				Name:     "index-name",
+				Usage:    "index name",
+				Required: true,
+			},
+			&cli.BoolFlag{
+				Name:  "yes",
+				Usage: "delete without asking for confirmation",
+			},
+			&cli.BoolFlag{
+				Name:  "force",
+				Usage: "delete even if the index is protected",
+			},
+		},
+		Action: deleteIndex(logger),
+	}
+
+	listIndicesCmd := &cli.Command{
+		Name:  "list-indices",
+		Usage: "lists the indices matching a pattern",
+		Flags: []cli.Flag{
+			&cli.StringFlag{
+				Name:     "endpoint",
+				Usage:    "cluster endpoint (url)",
+				EnvVars:  []string{"OPENSEARCH_ENDPOINT"},
+				Required: true,
+			},
+			&cli.StringFlag{
+				Name:  "pattern",
+				Usage: "index name pattern, e.g. people-*",
+				Value: "*",
+			},
+			&cli.StringFlag{
+				Name:  "output",
+				Usage: "output format: table or json",
+				Value: "table",
+			},
+		},
+		Action: listIndices(logger),
+	}
+
+	describeIndexCmd := &cli.Command{
+		Name:  "describe-index",
+		Usage: "prints the mappings, settings, aliases and size of an index",
+		Flags: []cli.Flag{
+			&cli.StringFlag{
+				Name:     "endpoint",
+				Usage:    "cluster endpoint (url)",
+				EnvVars:  []string{"OPENSEARCH_ENDPOINT"},
+				Required: true,
+			},
+			&cli.StringFlag{
+				Name:     "index-name",
+				Usage:    "index or alias name",
+				Required: true,
+			},
+		},
+		Action: describeIndex(logger),
+	}
+
+	healthCmd := &cli.Command{
+		Name:  "health",
+		Usage: "prints the status, shards, pending tasks and disk usage of the clusters; exits with 1 unless they are green or yellow",
+		Flags: []cli.Flag{
+			&cli.StringFlag{
+				Name:     "endpoint",
+				Usage:    "cluster endpoint (url)",
+				EnvVars:  []string{"OPENSEARCH_ENDPOINT"},
+				Required: true,
+			},
+			&cli.StringFlag{
+				Name:    "secondary-endpoint",
+				Usage:   "secondary cluster endpoint (url), checked as well if set",
+				EnvVars: []string{"OPENSEARCH_SECONDARY_ENDPOINT"},
+			},
+			&cli.StringFlag{
+				Name:  "output",
+				Usage: "output format: table or json",
+				Value: "table",
+			},
+		},
+		Action: health(logger),
+	}
+
 	return &cli.Command{
 		Name:  "opensearch",
 		Usage: "provides open commands",
 		Subcommands: []*cli.Command{
 			createIndex,
+			doctor,
+			searchCmd,
+			schema,
+			sandbox,
+			dashboards,
+			suggestMappingCmd,
+			promoteSecondaryCmd,
+			verifyClustersCmd,
+			getDocumentCmd,
+			deleteDocumentCmd,
+			deleteIndexCmd,
+			listIndicesCmd,
+			describeIndexCmd,
+			healthCmd,
 		},
 	}
 }
 
+// createIndex creates an index with the configuration of a file given with --config, or else of a built-in
+// template. The configuration is validated before the cluster is contacted.
 func createIndex(logger zerolog.Logger) func(c *cli.Context) error {
 	return func(c *cli.Context) error {
 		indexName := c.String("index-name")
 		endpoint := c.String("endpoint")
 
+		var config map[string]interface{}
+		switch {
+		case c.IsSet("config") && c.IsSet("template"):
+			return cli.Exit("--config and --template are mutually exclusive", 1)
+		case c.IsSet("config"):
+			var err error
+			if config, err = readIndexConfig(c.String("config")); err != nil {
+				return err
+			}
+		default:
+			var ok bool
+			if config, ok = indexTemplates[c.String("template")]; !ok {
+				return cli.Exit(fmt.Sprintf("unknown template %q, expected one of %s", c.String("template"),
+					strings.Join(indexTemplateNames(), ", ")), 1)
+			}
+		}
+
+		if lang := c.String("language"); lang != "" {
+			var err error
+			if config, err = opensearch.IndexConfigWithLanguage(config, opensearch.Language(lang)); err != nil {
+				return cli.Exit("invalid --language: "+err.Error(), 1)
+			}
+		}
+
+		if err := opensearch.ValidateIndexConfig(config); err != nil {
+			return cli.Exit("invalid index configuration: "+err.Error(), 1)
+		}
+		if c.Bool("dry-run") {
+			enc := json.NewEncoder(c.App.Writer)
+			enc.SetIndent("", "  ")
+			return enc.Encode(config)
+		}
+
 		client, err := makeOpenSearchClient(endpoint, logger)
 		if err != nil {
 			return err
 		}
-		return client.CreateIndex(context.Background(), indexName, indexConfig)
+		return client.CreateIndex(context.Background(), indexName, config)
+	}
+}
+
+// readIndexConfig reads an index configuration from a JSON or YAML file.
+func readIndexConfig(path string) (map[string]interface{}, error) {
+	var config map[string]interface{}
+	if err := opensearch.DecodeConfigFile(path, &config); err != nil {
+		return nil, fmt.Errorf("invalid index configuration: %w", err)
+	}
+	return config, nil
+}
+
+func doctor(logger zerolog.Logger) func(c *cli.Context) error {
+	return func(c *cli.Context) error {
+		var opts []opensearch.OpenSearchOption
+		if endpoint := c.String("secondary-endpoint"); endpoint != "" {
+			opts = append(opts, opensearch.WithSecondaryEndpoint(endpoint))
+		}
+
+		client, err := makeOpenSearchClient(c.String("endpoint"), logger, opts...)
+		if err != nil {
+			return err
+		}
+
+		var checkOpts []opensearch.SelfCheckOption
+		for _, indexName := range c.StringSlice("index-name") {
+			checkOpts = append(checkOpts, opensearch.WithExpectedIndex(indexName, indexConfig))
+		}
+
+		findings, err := client.SelfCheck(context.Background(), checkOpts...)
+		if err != nil {
+			return err
+		}
+
+		failed := false
+		for _, f := range findings {
+			fmt.Fprintf(c.App.Writer, "[%s] %s %s: %s\n", f.Severity, f.Cluster, f.Check, f.Message)
+			if f.Severity == opensearch.SeverityError {
+				failed = true
+			}
+		}
+
+		if failed {
+			return cli.Exit("self-check found errors", 1)
+		}
+		return nil
+	}
+}
+
+func searchDocuments(logger zerolog.Logger) func(c *cli.Context) error {
+	return func(c *cli.Context) error {
+		instanceID := c.String("instance-id")
+		query := search.Query{Value: c.String("query"), Size: c.Int("size")}
+
+		// The search is sent to the index, rather than to every index with a filter on it.
+		ctx := context.Background()
+		if index := c.String("index"); index != "" {
+			ctx = search.WithSearchIndices(ctx, index)
+		}
+		if spec := c.String("sort"); spec != "" {
+			sortFields, err := search.ParseSort(spec)
+			if err != nil {
+				return cli.Exit(err.Error(), 1)
+			}
+			query.Sort = sortFields
+		}
+
+		var fields []string
+		for _, f := range c.StringSlice("fields") {
+			fields = append(fields, strings.Split(f, ",")...)
+		}
+
+		output := c.String("output")
+		switch output {
+		case "ndjson", "json", "table":
+		default:
+			return cli.Exit("--output must be ndjson, json or table", 1)
+		}
+
+		redactor, err := makeRedactor(c)
+		if err != nil {
+			return err
+		}
+
+		var opts []opensearch.OpenSearchOption
+		if redactor != nil {
+			opts = append(opts, opensearch.WithLogging(opensearch.WithLogRedactor(redactor)))
+		}
+
+		client, err := makeOpenSearchClient(c.String("endpoint"), logger, opts...)
+		if err != nil {
+			return err
+		}
+
+		if c.Bool("dump-query") {
+			dsl, err := client.DebugQuery(instanceID, query)
+			if err != nil {
+				return err
+			}
+			fmt.Fprintln(c.App.Writer, string(dsl))
+			return nil
+		}
+
+		result, err := client.Search(ctx, instanceID, query)
+		if err != nil {
+			return err
+		}
+
+		if redactor != nil {
+			for i, h := range result.Hits {
+				if result.Hits[i].Document, err = redactor.Redact(h.Document); err != nil {
+					return err
+				}
+			}
+		}
+
+		return printSearchResult(c.App.Writer, result, output, fields)
+	}
+}
+
+// printSearchResult writes the hits of a search in an output format, restricted to the given document fields
+// if any. Dotted fields, such as address.city, select fields of objects and are printed under their path.
+func printSearchResult(w io.Writer, result search.SearchResult, output string, fields []string) error {
+	project := func(d search.Document) search.Document {
+		if len(fields) == 0 {
+			return d
+		}
+		p := make(search.Document, len(fields))
+		for _, f := range fields {
+			if v, ok := lookupField(d, f); ok {
+				p[f] = v
+			}
+		}
+		return p
+	}
+
+	switch output {
+	case "json":
+		type hit struct {
+			ID       string          `json:"id"`
+			Index    string          `json:"index,omitempty"`
+			Score    float64         `json:"score"`
+			Document search.Document `json:"document"`
+		}
+		hits := make([]hit, 0, len(result.Hits))
+		for _, h := range result.Hits {
+			hits = append(hits, hit{ID: h.ID, Index: h.Index, Score: h.Score, Document: project(h.Document)})
+		}
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(map[string]interface{}{
+			"total":          result.Total,
+			"total_relation": result.TotalRelation,
+			"took_ms":        result.Took.Milliseconds(),
+			"hits":           hits,
+		})
+
+	case "table":
+		tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+		header := []string{"ID", "INDEX", "SCORE"}
+		if len(fields) == 0 {
+			header = append(header, "DOCUMENT")
+		}
+		for _, f := range fields {
+			header = append(header, strings.ToUpper(f))
+		}
+		fmt.Fprintln(tw, strings.Join(header, "\t"))
+
+		for _, h := range result.Hits {
+			row := []string{h.ID, h.Index, fmt.Sprintf("%.3f", h.Score)}
+			if len(fields) == 0 {
+				b, err := json.Marshal(h.Document)
+				if err != nil {
+					return err
+				}
+				row = append(row, truncate(string(b), 80))
+			}
+			for _, f := range fields {
+				v, _ := lookupField(h.Document, f)
+				row = append(row, cell(v))
+			}
+			fmt.Fprintln(tw, strings.Join(row, "\t"))
+		}
+		if err := tw.Flush(); err != nil {
+			return err
+		}
+		_, err := fmt.Fprintf(w, "%d of %d documents\n", len(result.Hits), result.Total)
+		return err
+
+	default:
+		enc := json.NewEncoder(w)
+		for _, h := range result.Hits {
+			if err := enc.Encode(project(h.Document)); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
+
+// lookupField returns the value of a field of a document, descending into objects along a dotted path. A key
+// containing the dots itself takes precedence. The values of a field of objects in an array are collected.
+func lookupField(d map[string]interface{}, path string) (interface{}, bool) {
+	if v, ok := d[path]; ok {
+		return v, true
+	}
+	for i := strings.IndexByte(path, '.'); i >= 0; i = nextDot(path, i) {
+		v, ok := d[path[:i]]
+		if !ok {
+			continue
+		}
+		switch v := v.(type) {
+		case map[string]interface{}:
+			if found, ok := lookupField(v, path[i+1:]); ok {
+				return found, true
+			}
+		case []interface{}:
+			var values []interface{}
+			for _, e := range v {
+				if m, ok := e.(map[string]interface{}); ok {
+					if found, ok := lookupField(m, path[i+1:]); ok {
+						values = append(values, found)
+					}
+				}
+			}
+			if len(values) > 0 {
+				return values, true
+			}
+		}
+	}
+	return nil, false
+}
+
+// nextDot returns the index of the first dot of path after index i, or -1.
+func nextDot(path string, i int) int {
+	if j := strings.IndexByte(path[i+1:], '.'); j >= 0 {
+		return i + 1 + j
+	}
+	return -1
+}
+
+// cell formats a document value for a table, rendering objects and arrays as JSON.
+func cell(v interface{}) string {
+	switch v := v.(type) {
+	case nil:
+		return ""
+	case string:
+		// Tabs and line breaks would break the columns.
+		return truncate(strings.Join(strings.Fields(v), " "), 40)
+	case map[string]interface{}, []interface{}:
+		b, err := json.Marshal(v)
+		if err != nil {
+			return fmt.Sprint(v)
+		}
+		return truncate(string(b), 40)
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// truncate shortens s to at most n runes, marking the truncation with an ellipsis.
+func truncate(s string, n int) string {
+	r := []rune(s)
+	if len(r) <= n {
+		return s
+	}
+	return string(r[:n-1]) + "…"
+}
+
+func validateSchema(logger zerolog.Logger) func(c *cli.Context) error {
+	return func(c *cli.Context) error {
+		fragments, err := opensearch.LoadSchemaFragments(c.String("dir"))
+		if err != nil {
+			return err
+		}
+
+		config, err := opensearch.ComposeSchema(fragments)
+		if err != nil {
+			return err
+		}
+
+		client, err := makeOpenSearchClient(c.String("endpoint"), logger)
+		if err != nil {
+			return err
+		}
+
+		indexName := c.String("index-name")
+		findings, err := client.SelfCheck(context.Background(), opensearch.WithExpectedIndex(indexName, config))
+		if err != nil {
+			return err
+		}
+
+		// Only the mapping matters here; disk and version findings are left to the doctor command, except for
+		// errors that prevented the mapping from being checked at all.
+		failed := false
+		for _, f := range findings {
+			switch f.Check {
+			case "index " + indexName, "connectivity", "auth":
+			default:
+				continue
+			}
+			fmt.Fprintf(c.App.Writer, "[%s] %s %s: %s\n", f.Severity, f.Cluster, f.Check, f.Message)
+			if f.Severity == opensearch.SeverityError {
+				failed = true
+			}
+		}
+
+		if failed {
+			return cli.Exit("schema validation failed", 1)
+		}
+		return nil
+	}
+}
+
+func sandbox() func(c *cli.Context) error {
+	return func(c *cli.Context) error {
+		ctx := context.Background()
+
+		query := search.Query{Fields: c.StringSlice("field")}
+		switch c.String("mode") {
+		case "query_string":
+			query.Mode = search.QueryModeQueryString
+		case "simple":
+			query.Mode = search.QueryModeSimple
+		case "multi_match":
+			query.Mode = search.QueryModeMultiMatch
+		default:
+			return fmt.Errorf("unknown query mode %q", c.String("mode"))
+		}
+		switch c.String("operator") {
+		case "or":
+			query.Operator = search.OperatorOr
+		case "and":
+			query.Operator = search.OperatorAnd
+		default:
+			return fmt.Errorf("unknown operator %q", c.String("operator"))
+		}
+
+		engine := memory.New()
+		n, err := loadFixture(ctx, engine, c.String("fixture"))
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(c.App.ErrWriter, "loaded %d documents\n", n)
+
+		run := func(value string) error {
+			query.Value = value
+			hits, err := engine.Explain(ctx, c.String("instance-id"), query)
+			if err != nil {
+				return err
+			}
+
+			fmt.Fprintf(c.App.Writer, "%d hits for %q\n", len(hits), value)
+			for _, h := range hits {
+				doc, err := json.Marshal(h.Document)
+				if err != nil {
+					return err
+				}
+				fmt.Fprintf(c.App.Writer, "%6.2f %s %s\n", h.Score, h.ID, doc)
+				for _, m := range h.Matches {
+					fmt.Fprintf(c.App.Writer, "       %q matched %s\n", m.Term, m.Field)
+				}
+			}
+			return nil
+		}
+
+		if c.IsSet("query") {
+			return run(c.String("query"))
+		}
+
+		scanner := bufio.NewScanner(os.Stdin)
+		for scanner.Scan() {
+			if line := strings.TrimSpace(scanner.Text()); line != "" {
+				if err := run(line); err != nil {
+					fmt.Fprintln(c.App.ErrWriter, err)
+				}
+			}
+		}
+		return scanner.Err()
+	}
+}
+
+// loadFixture indexes every document of an NDJSON file into the engine, returning the number of documents.
+func loadFixture(ctx context.Context, engine search.SearchEngine, path string) (int, error) {
+	n := 0
+	err := readDocuments(path, func(d search.Document) error {
+		instanceID, _ := d["instance_id"].(string)
+		entityName, _ := d["entity_name"].(string)
+		entityID, _ := d["id"].(string)
+		if err := engine.PutDocument(ctx, instanceID, "sandbox", entityName, entityID, d); err != nil {
+			return err
+		}
+		n++
+		return nil
+	})
+
+	return n, err
+}
+
+// readDocuments calls fn with every document of an NDJSON file, skipping blank lines.
+func readDocuments(path string, fn func(d search.Document) error) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	line := 0
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		line++
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" {
+			continue
+		}
+
+		var d search.Document
+		if err := json.Unmarshal([]byte(text), &d); err != nil {
+			return fmt.Errorf("%s line %d: %v", path, line, err)
+		}
+		if err := fn(d); err != nil {
+			return fmt.Errorf("%s line %d: %v", path, line, err)
+		}
+	}
+
+	return scanner.Err()
+}
+
+func suggestMapping(logger zerolog.Logger) func(c *cli.Context) error {
+	return func(c *cli.Context) error {
+		size := c.Int("sample-size")
+
+		var documents []search.Document
+		switch {
+		case c.IsSet("file"):
+			err := readDocuments(c.String("file"), func(d search.Document) error {
+				if len(documents) < size {
+					documents = append(documents, d)
+				}
+				return nil
+			})
+			if err != nil {
+				return err
+			}
+		case c.IsSet("endpoint") && c.IsSet("index-name"):
+			client, err := makeOpenSearchClient(c.String("endpoint"), logger)
+			if err != nil {
+				return err
+			}
+			documents, err = client.SampleDocuments(context.Background(), c.String("index-name"), size)
+			if err != nil {
+				return err
+			}
+		default:
+			return cli.Exit("either --file or --endpoint and --index-name are required", 1)
+		}
+
+		suggestion := opensearch.SuggestMapping(documents)
+		for _, note := range suggestion.Notes {
+			fmt.Fprintln(c.App.ErrWriter, "note:", note)
+		}
+
+		enc := json.NewEncoder(c.App.Writer)
+		enc.SetIndent("", "  ")
+		return enc.Encode(map[string]interface{}{"mappings": suggestion.Mappings})
+	}
+}
+
+func provisionDashboards() func(c *cli.Context) error {
+	return func(c *cli.Context) error {
+		timeField := c.String("time-field")
+
+		var opts []opensearch.DashboardsOption
+		var visualizations []opensearch.Visualization
+		for _, v := range opensearch.DefaultVisualizations(timeField) {
+			if v.Kind == opensearch.VisualizationTimeline && timeField == "" {
+				continue
+			}
+			visualizations = append(visualizations, v)
+		}
+		if timeField != "" {
+			opts = append(opts, opensearch.WithDashboardsTimeField(timeField))
+		}
+
+		d := opensearch.NewDashboards(c.String("dashboards-endpoint"), opts...)
+		return d.Provision(context.Background(), c.String("index-name"), c.String("instance-id"), visualizations...)
+	}
+}
+
+// promoteSecondary promotes the secondary cluster of an engine configuration file after checking it is reachable
+// and consistent with the primary. The promotion is written to the file, so services creating their engines from
+// it with NewFromConfig use the promoted cluster once they are restarted; running services can also promote their
+// own engines with PromoteSecondary.
+func promoteSecondary(logger zerolog.Logger) func(c *cli.Context) error {
+	return func(c *cli.Context) error {
+		ctx := context.Background()
+		path := c.String("config")
+
+		config, err := opensearch.LoadConfig(path)
+		if err != nil {
+			return err
+		}
+		if config.SecondaryEndpoint == "" {
+			return cli.Exit(fmt.Sprintf("%s does not configure a secondary endpoint", path), 1)
+		}
+
+		client, err := config.NewEngine(logger)
+		if err != nil {
+			return err
+		}
+		if err := client.Ping(ctx); err != nil {
+			return err
+		}
+
+		diverged := false
+		for _, indexName := range c.StringSlice("index-name") {
+			report, err := client.Reconcile(ctx, indexName)
+			if err != nil {
+				return err
+			}
+			fmt.Fprintf(c.App.Writer, "%s: %d scanned, %d missing, %d divergent, %d extra\n",
+				indexName, report.Scanned, report.Missing, report.Divergent, report.Extra)
+			if !report.Consistent() {
+				diverged = true
+			}
+		}
+		if diverged && !c.Bool("force") {
+			return cli.Exit("the clusters diverge, reconcile them or promote with --force", 1)
+		}
+
+		if err := opensearch.PromoteSecondaryConfig(path); err != nil {
+			return err
+		}
+
+		promoted, err := opensearch.LoadConfig(path)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(c.App.Writer, "promoted in %s: endpoint=%s secondary_endpoint=%s\n",
+			path, promoted.Endpoint, promoted.SecondaryEndpoint)
+		return nil
+	}
+}
+
+// verifyClusters reconciles indices between the primary and the secondary cluster without repairing them. It
+// prints every missing, divergent and extra document with a summary per index, optionally exports the report as
+// JSON, and fails if the clusters diverge.
+func verifyClusters(logger zerolog.Logger) func(c *cli.Context) error {
+	return func(c *cli.Context) error {
+		ctx := context.Background()
+
+		client, err := makeOpenSearchClient(c.String("endpoint"), logger,
+			opensearch.WithSecondaryEndpoint(c.String("secondary-endpoint")),
+			opensearch.WithIgnoredFields(c.StringSlice("ignore-field")...))
+		if err != nil {
+			return err
+		}
+
+		type indexReport struct {
+			opensearch.ReconcileReport
+			Diffs []opensearch.ReconcileDiff
+		}
+
+		var reports []indexReport
+		diverged := false
+		for _, indexName := range c.StringSlice("index-name") {
+			var diffs []opensearch.ReconcileDiff
+			report, err := client.Reconcile(ctx, indexName, opensearch.WithDiffs(func(d opensearch.ReconcileDiff) {
+				if len(d.Fields) > 0 {
+					fmt.Fprintf(c.App.Writer, "%s: %s %s: %s\n", indexName, d.Kind, d.ID, strings.Join(d.Fields, ", "))
+				} else {
+					fmt.Fprintf(c.App.Writer, "%s: %s %s\n", indexName, d.Kind, d.ID)
+				}
+				diffs = append(diffs, d)
+			}))
+			if err != nil {
+				return err
+			}
+			fmt.Fprintf(c.App.Writer, "%s: %d scanned, %d missing, %d divergent, %d extra\n",
+				indexName, report.Scanned, report.Missing, report.Divergent, report.Extra)
+			if !report.Consistent() {
+				diverged = true
+			}
+			reports = append(reports, indexReport{ReconcileReport: report, Diffs: diffs})
+		}
+
+		if path := c.String("output"); path != "" {
+			b, err := json.MarshalIndent(reports, "", "  ")
+			if err != nil {
+				return err
+			}
+			if err := os.WriteFile(path, append(b, '\n'), 0o644); err != nil {
+				return err
+			}
+		}
+
+		if diverged {
+			return cli.Exit("the clusters diverge", 1)
+		}
+		return nil
+	}
+}
+
+// getDocument prints a document as indented JSON. With --cluster both, the document is read from each cluster
+// and both versions are printed, labelled by cluster, followed by whether they match.
+func getDocument(logger zerolog.Logger) func(c *cli.Context) error {
+	return func(c *cli.Context) error {
+		ctx := context.Background()
+
+		var targets []search.ClusterTarget
+		switch c.String("cluster") {
+		case "primary":
+			targets = []search.ClusterTarget{search.Primary}
+		case "secondary":
+			targets = []search.ClusterTarget{search.Secondary}
+		case "both":
+			targets = []search.ClusterTarget{search.Primary, search.Secondary}
+		default:
+			return cli.Exit("--cluster must be primary, secondary or both", 1)
+		}
+
+		var opts []opensearch.OpenSearchOption
+		if endpoint := c.String("secondary-endpoint"); endpoint != "" {
+			opts = append(opts, opensearch.WithSecondaryEndpoint(endpoint))
+		} else if c.String("cluster") != "primary" {
+			return cli.Exit("--cluster "+c.String("cluster")+" requires --secondary-endpoint", 1)
+		}
+
+		redactor, err := makeRedactor(c)
+		if err != nil {
+			return err
+		}
+
+		client, err := makeOpenSearchClient(c.String("endpoint"), logger, opts...)
+		if err != nil {
+			return err
+		}
+
+		enc := json.NewEncoder(c.App.Writer)
+		enc.SetIndent("", "  ")
+
+		var documents []search.Document
+		for _, target := range targets {
+			d, err := client.FindDocument(search.WithCluster(ctx, target), c.String("instance-id"),
+				c.String("index-name"), c.String("entity-name"), c.String("entity-id"))
+			if err != nil && !(len(targets) > 1 && errors.Is(err, search.ErrDocumentNotFound)) {
+				return err
+			}
+
+			if len(targets) > 1 {
+				label := "primary"
+				if target == search.Secondary {
+					label = "secondary"
+				}
+				if d == nil {
+					fmt.Fprintf(c.App.Writer, "%s: not found\n", label)
+				} else {
+					fmt.Fprintf(c.App.Writer, "%s:\n", label)
+				}
+			}
+			if d != nil {
+				printed := d
+				if redactor != nil {
+					if printed, err = redactor.Redact(d); err != nil {
+						return err
+					}
+				}
+				if err := enc.Encode(printed); err != nil {
+					return err
+				}
+			}
+			// The clusters are compared on the unredacted documents.
+			documents = append(documents, d)
+		}
+
+		if len(documents) > 1 {
+			primary, secondary := documents[0], documents[1]
+			switch {
+			case primary == nil && secondary == nil:
+				return cli.Exit("the document exists on neither cluster", 1)
+			case secondary == nil:
+				return cli.Exit("the document only exists on the primary cluster", 1)
+			case primary == nil:
+				return cli.Exit("the document only exists on the secondary cluster", 1)
+			}
+			if fields := opensearch.DiffDocuments(primary, secondary, c.StringSlice("ignore-field")...); len(fields) > 0 {
+				return cli.Exit("the clusters hold different versions of the document, differing in "+strings.Join(fields, ", "), 1)
+			}
+			fmt.Fprintln(c.App.Writer, "the clusters hold the same document")
+		}
+		return nil
+	}
+}
+
+func deleteDocument(logger zerolog.Logger) func(c *cli.Context) error {
+	return func(c *cli.Context) error {
+		var opts []opensearch.OpenSearchOption
+		if endpoint := c.String("secondary-endpoint"); endpoint != "" {
+			opts = append(opts, opensearch.WithSecondaryEndpoint(endpoint))
+		}
+
+		client, err := makeOpenSearchClient(c.String("endpoint"), logger, opts...)
+		if err != nil {
+			return err
+		}
+		return client.DeleteDocument(context.Background(), c.String("instance-id"), c.String("index-name"),
+			c.String("entity-name"), c.String("entity-id"))
+	}
+}
+
+// deleteIndex deletes an index after the user confirmed it by typing its name, unless --yes is given. The
+// confirmation also satisfies destructive policies requiring elevated confirmation.
+func deleteIndex(logger zerolog.Logger) func(c *cli.Context) error {
+	return func(c *cli.Context) error {
+		indexName := c.String("index-name")
+
+		if !c.Bool("yes") {
+			fmt.Fprintf(c.App.ErrWriter, "This deletes index %q and all its documents. Type the index name to confirm: ", indexName)
+			// Only a complete line confirms the deletion; input ending without a newline, e.g. piped with
+			// printf, or any other read error does not.
+			answer, err := bufio.NewReader(c.App.Reader).ReadString('\n')
+			if err != nil || strings.TrimSpace(answer) != indexName {
+				return cli.Exit("deletion not confirmed", 1)
+			}
+		}
+
+		var opts []opensearch.OpenSearchOption
+		if endpoint := c.String("secondary-endpoint"); endpoint != "" {
+			opts = append(opts, opensearch.WithSecondaryEndpoint(endpoint))
+		}
+
+		client, err := makeOpenSearchClient(c.String("endpoint"), logger, opts...)
+		if err != nil {
+			return err
+		}
+
+		deleteOpts := []search.DeleteIndexOption{search.WithConfirmation()}
+		if c.Bool("force") {
+			deleteOpts = append(deleteOpts, search.WithForceDelete())
+		}
+		if err := client.DeleteIndex(context.Background(), indexName, deleteOpts...); err != nil {
+			return err
+		}
+		fmt.Fprintf(c.App.Writer, "deleted index %s\n", indexName)
+		return nil
+	}
+}
+
+func listIndices(logger zerolog.Logger) func(c *cli.Context) error {
+	return func(c *cli.Context) error {
+		output := c.String("output")
+		if output != "table" && output != "json" {
+			return cli.Exit("--output must be table or json", 1)
+		}
+
+		client, err := makeOpenSearchClient(c.String("endpoint"), logger)
+		if err != nil {
+			return err
+		}
+
+		indices, err := client.ListIndices(context.Background(), c.String("pattern"))
+		if err != nil {
+			return err
+		}
+
+		if output == "json" {
+			enc := json.NewEncoder(c.App.Writer)
+			enc.SetIndent("", "  ")
+			return enc.Encode(indices)
+		}
+
+		tw := tabwriter.NewWriter(c.App.Writer, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(tw, "INDEX\tHEALTH\tSTATUS\tPRI\tREP\tDOCS\tSIZE")
+		for _, i := range indices {
+			fmt.Fprintf(tw, "%s\t%s\t%s\t%d\t%d\t%d\t%s\n",
+				i.Name, i.Health, i.Status, i.Primaries, i.Replicas, i.DocsCount, formatBytes(i.StoreSizeBytes))
+		}
+		return tw.Flush()
+	}
+}
+
+func describeIndex(logger zerolog.Logger) func(c *cli.Context) error {
+	return func(c *cli.Context) error {
+		client, err := makeOpenSearchClient(c.String("endpoint"), logger)
+		if err != nil {
+			return err
+		}
+
+		d, err := client.DescribeIndex(context.Background(), c.String("index-name"))
+		if err != nil {
+			return err
+		}
+
+		enc := json.NewEncoder(c.App.Writer)
+		enc.SetIndent("", "  ")
+		return enc.Encode(map[string]interface{}{
+			"index":    d.Name,
+			"aliases":  d.Aliases,
+			"mappings": d.Mappings,
+			"settings": d.Settings,
+			"stats": map[string]interface{}{
+				"docs_count":       d.DocsCount,
+				"deleted_docs":     d.DeletedDocs,
+				"store_size_bytes": d.StoreSizeBytes,
+			},
+		})
+	}
+}
+
+// health prints the health and disk usage of every cluster, for deploy pipelines to gate on. It fails if a
+// cluster is red, or reports a status other than green or yellow.
+func health(logger zerolog.Logger) func(c *cli.Context) error {
+	return func(c *cli.Context) error {
+		output := c.String("output")
+		if output != "table" && output != "json" {
+			return cli.Exit("--output must be table or json", 1)
+		}
+
+		var opts []opensearch.OpenSearchOption
+		if endpoint := c.String("secondary-endpoint"); endpoint != "" {
+			opts = append(opts, opensearch.WithSecondaryEndpoint(endpoint))
+		}
+
+		client, err := makeOpenSearchClient(c.String("endpoint"), logger, opts...)
+		if err != nil {
+			return err
+		}
+
+		ctx := context.Background()
+		clusters, err := client.ClusterHealth(ctx)
+		if err != nil {
+			return cli.Exit(fmt.Sprintf("cannot read cluster health: %v", err), 1)
+		}
+		disks, err := client.DiskUsage(ctx)
+		if err != nil {
+			return cli.Exit(fmt.Sprintf("cannot read disk usage: %v", err), 1)
+		}
+
+		var unhealthy []string
+		for _, h := range clusters {
+			if h.Status != opensearch.HealthGreen && h.Status != opensearch.HealthYellow {
+				unhealthy = append(unhealthy, fmt.Sprintf("%s is %s", clusterLabel(h.Cluster, h.Name), h.Status))
+			}
+		}
+
+		if output == "json" {
+			type report struct {
+				Cluster string                     `json:"cluster"`
+				Name    string                     `json:"name,omitempty"`
+				Health  opensearch.ClusterHealth   `json:"health"`
+				Disk    []opensearch.NodeDiskUsage `json:"disk"`
+			}
+			reports := make([]report, 0, len(clusters))
+			for i, h := range clusters {
+				reports = append(reports, report{Cluster: h.Cluster, Name: h.Name, Health: h, Disk: disks[i].Nodes})
+			}
+			enc := json.NewEncoder(c.App.Writer)
+			enc.SetIndent("", "  ")
+			if err := enc.Encode(reports); err != nil {
+				return err
+			}
+		} else {
+			for i, h := range clusters {
+				if i > 0 {
+					fmt.Fprintln(c.App.Writer)
+				}
+				fmt.Fprintf(c.App.Writer, "%s: %s, %d nodes, %d unassigned shards, %d pending tasks\n",
+					clusterLabel(h.Cluster, h.Name), h.Status, h.NumberOfNodes, h.UnassignedShards, h.NumberOfPendingTasks)
+
+				tw := tabwriter.NewWriter(c.App.Writer, 0, 0, 2, ' ', 0)
+				fmt.Fprintln(tw, "NODE\tHOST\tSHARDS\tUSED\tAVAILABLE\tTOTAL\tDISK%")
+				for _, n := range disks[i].Nodes {
+					fmt.Fprintf(tw, "%s\t%s\t%d\t%s\t%s\t%s\t%.0f\n", n.Node, n.Host, n.Shards,
+						formatBytes(n.UsedBytes), formatBytes(n.AvailableBytes), formatBytes(n.TotalBytes), n.Percent)
+				}
+				if err := tw.Flush(); err != nil {
+					return err
+				}
+			}
+		}
+
+		if len(unhealthy) > 0 {
+			return cli.Exit(strings.Join(unhealthy, "; "), 1)
+		}
+		return nil
+	}
+}
+
+// clusterLabel identifies a cluster by its role and, if configured, its label, e.g. "primary (eu-prod)".
+func clusterLabel(role, name string) string {
+	if name == "" || name == role {
+		return role
+	}
+	return fmt.Sprintf("%s (%s)", role, name)
+}
+
+// formatBytes formats a size in bytes with a binary unit, e.g. "1.5mb".
+func formatBytes(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%db", n)
+	}
+	div, exp := int64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
 	}
+	return fmt.Sprintf("%.1f%cb", float64(n)/float64(div), "kmgtpe"[exp])
 }
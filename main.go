@@ -72,10 +72,16 @@ func main() {
 		Caller().
 		Logger()
 
-	endpoint := "http://neodxp-opensearch-dev.justrelate.io"
 	ctx := context.Background()
 
-	client, err := opensearch.NewOpenSearch(endpoint, logger)
+	// The engine is configured by a JSON file given as argument, or else by OPENSEARCH_* environment variables.
+	var client opensearch.Engine
+	var err error
+	if len(os.Args) > 1 {
+		client, err = opensearch.NewFromConfig(os.Args[1], logger)
+	} else {
+		client, err = opensearch.NewFromEnv(logger)
+	}
 	if err != nil {
 		log.Fatal(err)
 	}
@@ -0,0 +1,449 @@
+// Package bleve provides a search.SearchEngine backed by Bleve indices embedded in the process, for single-binary
+// deployments, CLIs and offline demos where running a cluster is overkill. Unlike the memory package, documents
+// are analyzed and scored by a full-text engine and can be persisted on disk.
+//
+// Index configurations are not translated from the OpenSearch format: fields are mapped dynamically, with the
+// document metadata indexed as keywords. Only the SearchEngine interface is implemented.
+package bleve
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+
+	blevesearch "github.com/blevesearch/bleve/v2"
+	"github.com/blevesearch/bleve/v2/analysis/analyzer/keyword"
+	"github.com/blevesearch/bleve/v2/mapping"
+	"github.com/blevesearch/bleve/v2/search/query"
+	"github.com/joshilesanmi/open-search-dev/search"
+)
+
+// indexField is the pseudo-field of Term filters restricting a search to an index, as in OpenSearch.
+const indexField = "_index"
+
+// keywordFields are the document metadata fields, indexed as single terms so Term filters match them exactly.
+var keywordFields = []string{"instance_id", "entity_name", "id"}
+
+// Engine is a search.SearchEngine backed by Bleve. Every index of the engine is a Bleve index, stored in a
+// subdirectory of the engine directory named after the index, or in memory. It is safe for concurrent use.
+type Engine struct {
+	dir string
+
+	mu      sync.RWMutex
+	indices map[string]blevesearch.Index
+}
+
+// Ensures the Engine struct correctly implements the search.SearchEngine interface.
+var _ search.SearchEngine = &Engine{}
+
+// New returns an Engine storing its indices in dir, opening the indices stored there already. If dir is empty,
+// the indices are kept in memory and lost when the engine is closed.
+func New(dir string) (*Engine, error) {
+	e := &Engine{dir: dir, indices: make(map[string]blevesearch.Index)}
+	if dir == "" {
+		return e, nil
+	}
+
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		idx, err := blevesearch.Open(filepath.Join(dir, entry.Name()))
+		if err != nil {
+			_ = e.Close()
+			return nil, fmt.Errorf("failed to open index %q: %w", entry.Name(), err)
+		}
+		idx.SetName(entry.Name())
+		e.indices[entry.Name()] = idx
+	}
+	return e, nil
+}
+
+// Close closes the indices of the engine. The engine must not be used afterwards.
+func (e *Engine) Close() error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	var errs []error
+	for name, idx := range e.indices {
+		if err := idx.Close(); err != nil {
+			errs = append(errs, fmt.Errorf("index %q: %w", name, err))
+		}
+		delete(e.indices, name)
+	}
+	return errors.Join(errs...)
+}
+
+// CreateIndex creates an index, unless it exists already. The configuration is ignored.
+func (e *Engine) CreateIndex(_ context.Context, indexName string, _ map[string]interface{}) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	_, err := e.createIndex(indexName)
+	return err
+}
+
+// createIndex returns an index, creating it if it does not exist. The caller must hold the write lock.
+func (e *Engine) createIndex(indexName string) (blevesearch.Index, error) {
+	if idx, ok := e.indices[indexName]; ok {
+		return idx, nil
+	}
+	if indexName == "" || indexName == "." || indexName == ".." || strings.ContainsAny(indexName, `/\`) {
+		return nil, fmt.Errorf("invalid index name %q", indexName)
+	}
+
+	var idx blevesearch.Index
+	var err error
+	if e.dir == "" {
+		idx, err = blevesearch.NewMemOnly(newIndexMapping())
+	} else {
+		idx, err = blevesearch.New(filepath.Join(e.dir, indexName), newIndexMapping())
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to create index %q: %w", indexName, err)
+	}
+	idx.SetName(indexName)
+	e.indices[indexName] = idx
+	return idx, nil
+}
+
+// newIndexMapping returns the mapping of new indices: dynamic, with the metadata fields indexed as keywords.
+func newIndexMapping() mapping.IndexMapping {
+	m := blevesearch.NewIndexMapping()
+	for _, field := range keywordFields {
+		f := blevesearch.NewTextFieldMapping()
+		f.Analyzer = keyword.Name
+		m.DefaultMapping.AddFieldMappingsAt(field, f)
+	}
+	return m
+}
+
+// DeleteIndex closes an index and removes it with its documents.
+func (e *Engine) DeleteIndex(_ context.Context, indexName string, _ ...search.DeleteIndexOption) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	idx, ok := e.indices[indexName]
+	if !ok {
+		return fmt.Errorf("index %q does not exist", indexName)
+	}
+	delete(e.indices, indexName)
+	if err := idx.Close(); err != nil {
+		return err
+	}
+	if e.dir != "" {
+		return os.RemoveAll(filepath.Join(e.dir, indexName))
+	}
+	return nil
+}
+
+// RefreshIndex does nothing, as writes are searchable immediately, but fails for missing indices.
+func (e *Engine) RefreshIndex(_ context.Context, indexName string) error {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+
+	if _, ok := e.indices[indexName]; !ok {
+		return fmt.Errorf("index %q does not exist", indexName)
+	}
+	return nil
+}
+
+// PutDocument indexes a document with its metadata, creating the index if needed. The document is stored as is
+// next to its indexed fields, and returned unchanged by FindDocument and Search.
+func (e *Engine) PutDocument(_ context.Context, instanceID, indexName, entityName, entityID string, document search.Document, _ ...search.IndexOption) error {
+	d, err := document.AddDocumentMetaData(instanceID, entityName, entityID)
+	if err != nil {
+		return fmt.Errorf("missing document meta data %v", err)
+	}
+	source, err := json.Marshal(d)
+	if err != nil {
+		return fmt.Errorf("failed to marshal document %v", err)
+	}
+
+	e.mu.Lock()
+	idx, err := e.createIndex(indexName)
+	e.mu.Unlock()
+	if err != nil {
+		return err
+	}
+
+	id := search.GenerateDocumentID(instanceID, entityName, entityID)
+	batch := idx.NewBatch()
+	if err := batch.Index(id, map[string]interface{}(d)); err != nil {
+		return err
+	}
+	batch.SetInternal([]byte(id), source)
+	return idx.Batch(batch)
+}
+
+// DeleteDocument removes a document.
+func (e *Engine) DeleteDocument(_ context.Context, instanceID, indexName, entityName, entityID string) error {
+	idx, ok := e.index(indexName)
+	if !ok {
+		return search.ErrDocumentNotFound
+	}
+
+	id := search.GenerateDocumentID(instanceID, entityName, entityID)
+	source, err := idx.GetInternal([]byte(id))
+	if err != nil {
+		return err
+	}
+	if source == nil {
+		return search.ErrDocumentNotFound
+	}
+
+	batch := idx.NewBatch()
+	batch.Delete(id)
+	batch.DeleteInternal([]byte(id))
+	return idx.Batch(batch)
+}
+
+// FindDocument returns a document.
+func (e *Engine) FindDocument(_ context.Context, instanceID, indexName, entityName, entityID string, _ ...search.FindOption) (search.Document, error) {
+	idx, ok := e.index(indexName)
+	if !ok {
+		return nil, search.ErrDocumentNotFound
+	}
+	return document(idx, search.GenerateDocumentID(instanceID, entityName, entityID))
+}
+
+// Search returns the documents of an instance matching a query across all indices, best match first unless the
+// query sorts them. Size limits the hits, to 10 by default.
+//
+// QueryModeQueryString values use the query string syntax of Bleve, which resembles the Lucene syntax, and do not
+// support Fields or OperatorAnd. The other modes match the value as plain text against Fields, or all fields.
+// Filters must be Term filters; string values match the metadata fields exactly and other fields as a phrase. A
+// Term filter on "_index" restricts the search to an index, as do the indices of the context if set with
+// search.WithSearchIndices. Relevance is ignored and Collapse is not supported.
+func (e *Engine) Search(ctx context.Context, instanceID string, query search.Query) (search.SearchResult, error) {
+	if query.Collapse != nil {
+		return search.SearchResult{}, errors.New("collapse is not supported by the Bleve engine")
+	}
+
+	q, indexNames, err := searchQuery(instanceID, query)
+	if err != nil {
+		return search.SearchResult{}, err
+	}
+
+	var contextIndices map[string]bool
+	if names := search.SearchIndices(ctx); len(names) > 0 {
+		contextIndices = make(map[string]bool, len(names))
+		for _, name := range names {
+			contextIndices[name] = true
+		}
+	}
+
+	e.mu.RLock()
+	var indices []blevesearch.Index
+	for name, idx := range e.indices {
+		if (indexNames == nil || indexNames[name]) && (contextIndices == nil || contextIndices[name]) {
+			indices = append(indices, idx)
+		}
+	}
+	e.mu.RUnlock()
+
+	result := search.SearchResult{Hits: []search.Hit{}, TotalRelation: "eq"}
+	if len(indices) == 0 {
+		return result, nil
+	}
+
+	size := query.Size
+	if size <= 0 {
+		size = 10
+	}
+	req := blevesearch.NewSearchRequestOptions(q, size, 0, false)
+	if len(query.Sort) > 0 {
+		order := make([]string, 0, len(query.Sort))
+		for _, f := range query.Sort {
+			if f.Descending {
+				order = append(order, "-"+f.Field)
+			} else {
+				order = append(order, f.Field)
+			}
+		}
+		req.SortBy(order)
+	}
+
+	r, err := blevesearch.NewIndexAlias(indices...).Search(req)
+	if err != nil {
+		return search.SearchResult{}, err
+	}
+
+	result.Total = int64(r.Total)
+	result.Took = r.Took
+	for _, hit := range r.Hits {
+		idx, ok := e.index(hit.Index)
+		if !ok {
+			continue // Deleted since the search.
+		}
+		d, err := document(idx, hit.ID)
+		if errors.Is(err, search.ErrDocumentNotFound) {
+			continue
+		}
+		if err != nil {
+			return search.SearchResult{}, err
+		}
+		result.Hits = append(result.Hits, search.Hit{ID: hit.ID, Index: hit.Index, Score: hit.Score, Document: d})
+	}
+	return result, nil
+}
+
+// Ping always succeeds, as the indices are embedded.
+func (e *Engine) Ping(_ context.Context) error {
+	return nil
+}
+
+// index returns an index by its name.
+func (e *Engine) index(indexName string) (blevesearch.Index, bool) {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+
+	idx, ok := e.indices[indexName]
+	return idx, ok
+}
+
+// document returns the stored document of an ID.
+func document(idx blevesearch.Index, id string) (search.Document, error) {
+	source, err := idx.GetInternal([]byte(id))
+	if err != nil {
+		return nil, err
+	}
+	if source == nil {
+		return nil, search.ErrDocumentNotFound
+	}
+
+	var d search.Document
+	if err := json.Unmarshal(source, &d); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal document %v", err)
+	}
+	return d, nil
+}
+
+// searchQuery returns the Bleve query of a search of an instance, and the names of the indices searched, or nil
+// for all indices.
+func searchQuery(instanceID string, q search.Query) (query.Query, map[string]bool, error) {
+	instance := blevesearch.NewTermQuery(instanceID)
+	instance.SetField("instance_id")
+	clauses := []query.Query{instance}
+
+	if q.Value != "" {
+		match, err := valueQuery(q)
+		if err != nil {
+			return nil, nil, err
+		}
+		clauses = append(clauses, match)
+	}
+
+	var indexNames map[string]bool
+	for _, f := range q.Filters {
+		t, ok := f.(search.Term)
+		if !ok {
+			return nil, nil, fmt.Errorf("filter %T is not supported by the Bleve engine", f)
+		}
+		if t.Field == indexField {
+			name := fmt.Sprint(t.Value)
+			if indexNames != nil && !indexNames[name] {
+				// Filters on different indices match nothing.
+				indexNames = map[string]bool{}
+			} else {
+				indexNames = map[string]bool{name: true}
+			}
+			continue
+		}
+		c, err := termQuery(t)
+		if err != nil {
+			return nil, nil, err
+		}
+		clauses = append(clauses, c)
+	}
+
+	return blevesearch.NewConjunctionQuery(clauses...), indexNames, nil
+}
+
+// valueQuery returns the query matching the value of a search, according to its mode.
+func valueQuery(q search.Query) (query.Query, error) {
+	if q.Mode == search.QueryModeDefault || q.Mode == search.QueryModeQueryString {
+		if len(q.Fields) > 0 {
+			return nil, errors.New("fields are not supported with query string queries by the Bleve engine")
+		}
+		if q.Operator == search.OperatorAnd {
+			return nil, errors.New("the and operator is not supported with query string queries by the Bleve engine")
+		}
+		return blevesearch.NewQueryStringQuery(q.Value), nil
+	}
+
+	match := func(field string) query.Query {
+		m := blevesearch.NewMatchQuery(q.Value)
+		if field != "" {
+			// Boosts are given as in OpenSearch, e.g. "title^2".
+			name, boost, ok := strings.Cut(field, "^")
+			m.SetField(name)
+			if b, err := strconv.ParseFloat(boost, 64); ok && err == nil {
+				m.SetBoost(b)
+			}
+		}
+		if q.Operator == search.OperatorAnd {
+			m.SetOperator(query.MatchQueryOperatorAnd)
+		}
+		return m
+	}
+
+	if len(q.Fields) == 0 {
+		return match(""), nil
+	}
+	fields := make([]query.Query, 0, len(q.Fields))
+	for _, f := range q.Fields {
+		fields = append(fields, match(f))
+	}
+	return blevesearch.NewDisjunctionQuery(fields...), nil
+}
+
+// termQuery returns the query of a Term filter.
+func termQuery(t search.Term) (query.Query, error) {
+	switch v := t.Value.(type) {
+	case string:
+		for _, field := range keywordFields {
+			if t.Field == field {
+				q := blevesearch.NewTermQuery(v)
+				q.SetField(t.Field)
+				return q, nil
+			}
+		}
+		q := blevesearch.NewMatchPhraseQuery(v)
+		q.SetField(t.Field)
+		return q, nil
+	case bool:
+		q := blevesearch.NewBoolFieldQuery(v)
+		q.SetField(t.Field)
+		return q, nil
+	case float64:
+		return numericQuery(t.Field, v), nil
+	case int:
+		return numericQuery(t.Field, float64(v)), nil
+	case int64:
+		return numericQuery(t.Field, float64(v)), nil
+	default:
+		return nil, fmt.Errorf("term filter on %q: value %T is not supported by the Bleve engine", t.Field, t.Value)
+	}
+}
+
+// numericQuery returns the query matching a number in a field.
+func numericQuery(field string, n float64) query.Query {
+	inclusive := true
+	q := blevesearch.NewNumericRangeInclusiveQuery(&n, &n, &inclusive, &inclusive)
+	q.SetField(field)
+	return q
+}
@@ -0,0 +1,20 @@
+package bleve
+
+import (
+	"context"
+
+	"github.com/joshilesanmi/open-search-dev/search"
+)
+
+// DriverName is the name the Bleve driver is registered under, see search.Open.
+const DriverName = "bleve"
+
+func init() {
+	search.Register(DriverName, openDriver)
+}
+
+// openDriver opens an engine for search.Open. The DSN is the directory the indices are stored in, see New; an
+// empty DSN keeps them in memory.
+func openDriver(_ context.Context, dsn string, _ search.OpenOptions) (search.SearchEngine, error) {
+	return New(dsn)
+}
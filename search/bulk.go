@@ -0,0 +1,38 @@
+package search
+
+import (
+	"fmt"
+)
+
+// DocumentKey identifies a document by the identifiers used to address it in the search engine.
+type DocumentKey struct {
+	InstanceID string
+	EntityName string
+	EntityID   string
+}
+
+// ItemFailure describes a single document that a bulk operation could not process.
+type ItemFailure struct {
+	DocumentKey
+	Cluster string // The cluster that rejected the document, if the engine writes to several.
+	Status  int    // The HTTP status reported for the document, if any.
+	Reason  string // Why the document was rejected.
+}
+
+// BulkReport summarises the outcome of an operation on many documents. Individual documents may fail without
+// failing the operation, so callers can retry just the failed items.
+type BulkReport struct {
+	Succeeded int           // Documents that were processed.
+	Failed    []ItemFailure // Documents that could not be processed, with their reasons.
+	Retried   int           // Documents that were retried before they succeeded or were reported as failed.
+}
+
+// Err returns an error summarising the failed items, or nil if every item succeeded.
+func (r BulkReport) Err() error {
+	if len(r.Failed) == 0 {
+		return nil
+	}
+	first := r.Failed[0]
+	return fmt.Errorf("%d of %d documents failed, first %s/%s: %s",
+		len(r.Failed), len(r.Failed)+r.Succeeded, first.EntityName, first.EntityID, first.Reason)
+}
@@ -0,0 +1,69 @@
+package search
+
+import (
+	"crypto/rand"
+	"fmt"
+	"time"
+)
+
+// Clock provides the current time. Implementations can be injected so that time-dependent behaviour such as
+// timestamps, retention and retries can be tested deterministically.
+type Clock interface {
+	Now() time.Time
+}
+
+// ClockFunc is an adapter to allow the use of an ordinary function as a Clock.
+type ClockFunc func() time.Time
+
+// Now calls f().
+func (f ClockFunc) Now() time.Time {
+	return f()
+}
+
+// SystemClock is the Clock backed by the system time.
+var SystemClock Clock = ClockFunc(time.Now)
+
+// TimerClock is a Clock that also controls waiting, so waits such as retry backoffs can advance with a fake
+// clock in tests.
+type TimerClock interface {
+	Clock
+	After(d time.Duration) <-chan time.Time
+}
+
+// After returns a channel receiving the time once d has elapsed on the clock. Clocks that do not implement
+// TimerClock wait on the system time.
+func After(clock Clock, d time.Duration) <-chan time.Time {
+	if c, ok := clock.(TimerClock); ok {
+		return c.After(d)
+	}
+	return time.After(d)
+}
+
+// IDGenerator provides unique identifiers, for example for jobs and point-in-time or scroll handles.
+type IDGenerator interface {
+	NewID() string
+}
+
+// IDGeneratorFunc is an adapter to allow the use of an ordinary function as an IDGenerator.
+type IDGeneratorFunc func() string
+
+// NewID calls f().
+func (f IDGeneratorFunc) NewID() string {
+	return f()
+}
+
+// UUIDGenerator is the IDGenerator producing random (version 4) UUIDs.
+var UUIDGenerator IDGenerator = IDGeneratorFunc(newUUID)
+
+// newUUID returns a random version 4 UUID.
+func newUUID() string {
+	var b [16]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		panic(fmt.Sprintf("failed to read random bytes: %v", err))
+	}
+
+	b[6] = (b[6] & 0x0f) | 0x40 // version 4
+	b[8] = (b[8] & 0x3f) | 0x80 // variant 10
+
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16])
+}
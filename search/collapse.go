@@ -0,0 +1,26 @@
+package search
+
+// CollapsedKey is the key under which a collapsed search result holds the documents it represents, as a
+// []Document, when the Collapse of the query requests inner hits.
+const CollapsedKey = "_collapsed"
+
+// Collapse deduplicates search results server-side: only the best scoring document of each distinct value of a
+// keyword field is returned, e.g. one person per e-mail address.
+type Collapse struct {
+	Field     string // A keyword field, e.g. "email".
+	InnerHits int    // Number of documents per value to return under CollapsedKey. Zero returns none.
+}
+
+// dsl returns the collapse clause of a search body.
+func (c *Collapse) dsl() map[string]interface{} {
+	collapse := map[string]interface{}{
+		"field": c.Field,
+	}
+	if c.InnerHits > 0 {
+		collapse["inner_hits"] = map[string]interface{}{
+			"name": CollapsedKey,
+			"size": c.InnerHits,
+		}
+	}
+	return collapse
+}
@@ -0,0 +1,231 @@
+// Package composite provides a search.SearchEngine that writes to two engines of any kind, e.g. an OpenSearch
+// cluster and an in-memory or Elasticsearch engine, to evaluate a backend against production traffic. Reads are
+// served by the primary engine; searches can be shadowed to the secondary and their results compared.
+package composite
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"time"
+
+	"github.com/joshilesanmi/open-search-dev/search"
+)
+
+// SecondaryError is returned when an operation succeeded on the primary engine but failed on the secondary.
+type SecondaryError struct {
+	Method string // The failed method, e.g. "PutDocument".
+	Err    error
+}
+
+func (e *SecondaryError) Error() string {
+	return fmt.Sprintf("secondary engine: %s: %v", e.Method, e.Err)
+}
+
+func (e *SecondaryError) Unwrap() error {
+	return e.Err
+}
+
+// Comparison is the outcome of a search shadowed to the secondary engine.
+type Comparison struct {
+	InstanceID   string
+	Query        search.Query
+	Primary      search.SearchResult
+	Secondary    search.SearchResult
+	SecondaryErr error    // Why the secondary search failed, if it did.
+	Missing      []string // IDs of hits of the primary missing from the secondary results.
+	Extra        []string // IDs of hits of the secondary missing from the primary results.
+	OrderChanged bool     // True if the IDs returned by both are in a different order.
+}
+
+// Identical reports whether both engines returned the same hits in the same order.
+func (c Comparison) Identical() bool {
+	return c.SecondaryErr == nil && len(c.Missing) == 0 && len(c.Extra) == 0 && !c.OrderChanged
+}
+
+// Option is a function type that applies configuration options to an Engine.
+type Option func(*Engine) error
+
+// WithBestEffort does not fail operations whose write to the secondary engine fails, which suits a secondary
+// that is only being evaluated. The failures are passed to fn, if set. By default they are returned as a
+// *SecondaryError after the primary engine was written.
+func WithBestEffort(fn func(err *SecondaryError)) Option {
+	return func(e *Engine) error {
+		e.bestEffort = true
+		e.onSecondaryError = fn
+		return nil
+	}
+}
+
+// WithShadowSearch runs the searches on the secondary engine too, in the background, and passes the comparison
+// of the results to fn. The shadow search is cancelled after timeout; it never delays or fails the search. At
+// most 8 shadow searches run at once, see WithShadowSampling; searches made while they do are not shadowed.
+func WithShadowSearch(timeout time.Duration, fn func(Comparison)) Option {
+	return func(e *Engine) error {
+		if fn == nil {
+			return errors.New("shadow search comparison func must not be nil")
+		}
+		if timeout <= 0 {
+			return errors.New("shadow search timeout must be positive")
+		}
+		e.shadowTimeout, e.onComparison = timeout, fn
+		return nil
+	}
+}
+
+// WithShadowSampling shadows only a fraction of the searches, between 0 and 1, with at most maxConcurrent shadow
+// searches in flight at once, so shadowing cannot pile up goroutines under load. It defaults to every search,
+// with 8 concurrent shadow searches.
+func WithShadowSampling(rate float64, maxConcurrent int) Option {
+	return func(e *Engine) error {
+		if rate <= 0 || rate > 1 {
+			return errors.New("shadow sample rate must be greater than 0 and at most 1")
+		}
+		if maxConcurrent <= 0 {
+			return errors.New("shadow search concurrency must be positive")
+		}
+		e.shadowRate, e.shadowMaxConcurrent = rate, maxConcurrent
+		return nil
+	}
+}
+
+// Engine is a search.SearchEngine writing to a primary and a secondary engine.
+type Engine struct {
+	primary   search.SearchEngine
+	secondary search.SearchEngine
+
+	bestEffort       bool
+	onSecondaryError func(err *SecondaryError)
+	shadowTimeout    time.Duration
+	onComparison     func(Comparison)
+
+	shadowRate          float64
+	shadowMaxConcurrent int
+	shadowSlots         chan struct{} // Holds a slot for every shadow search in flight.
+}
+
+// Ensures the Engine struct correctly implements the search.SearchEngine interface.
+var _ search.SearchEngine = &Engine{}
+
+// New returns an Engine writing to both engines and reading from the primary.
+func New(primary, secondary search.SearchEngine, opts ...Option) (*Engine, error) {
+	if primary == nil || secondary == nil {
+		return nil, errors.New("primary and secondary engine must not be nil")
+	}
+
+	e := &Engine{primary: primary, secondary: secondary, shadowRate: 1, shadowMaxConcurrent: 8}
+	for _, opt := range opts {
+		if err := opt(e); err != nil {
+			return nil, err
+		}
+	}
+	e.shadowSlots = make(chan struct{}, e.shadowMaxConcurrent)
+	return e, nil
+}
+
+// CreateIndex creates an index on both engines.
+func (e *Engine) CreateIndex(ctx context.Context, indexName string, config map[string]interface{}) error {
+	if err := e.primary.CreateIndex(ctx, indexName, config); err != nil {
+		return err
+	}
+	return e.secondaryWrite("CreateIndex", e.secondary.CreateIndex(ctx, indexName, config))
+}
+
+// DeleteIndex deletes an index from both engines.
+func (e *Engine) DeleteIndex(ctx context.Context, indexName string, opts ...search.DeleteIndexOption) error {
+	if err := e.primary.DeleteIndex(ctx, indexName, opts...); err != nil {
+		return err
+	}
+	return e.secondaryWrite("DeleteIndex", e.secondary.DeleteIndex(ctx, indexName, opts...))
+}
+
+// RefreshIndex refreshes an index on both engines.
+func (e *Engine) RefreshIndex(ctx context.Context, indexName string) error {
+	if err := e.primary.RefreshIndex(ctx, indexName); err != nil {
+		return err
+	}
+	return e.secondaryWrite("RefreshIndex", e.secondary.RefreshIndex(ctx, indexName))
+}
+
+// PutDocument stores a document on both engines.
+func (e *Engine) PutDocument(ctx context.Context, instanceID, indexName, entityName, entityID string, document search.Document, opts ...search.IndexOption) error {
+	if err := e.primary.PutDocument(ctx, instanceID, indexName, entityName, entityID, document, opts...); err != nil {
+		return err
+	}
+	err := e.secondary.PutDocument(ctx, instanceID, indexName, entityName, entityID, document, opts...)
+	return e.secondaryWrite("PutDocument", err)
+}
+
+// DeleteDocument deletes a document from both engines. A document missing from the secondary engine only is
+// not an error.
+func (e *Engine) DeleteDocument(ctx context.Context, instanceID, indexName, entityName, entityID string) error {
+	if err := e.primary.DeleteDocument(ctx, instanceID, indexName, entityName, entityID); err != nil {
+		return err
+	}
+	err := e.secondary.DeleteDocument(ctx, instanceID, indexName, entityName, entityID)
+	if errors.Is(err, search.ErrDocumentNotFound) {
+		err = nil
+	}
+	return e.secondaryWrite("DeleteDocument", err)
+}
+
+// FindDocument returns a document from the primary engine.
+func (e *Engine) FindDocument(ctx context.Context, instanceID, indexName, entityName, entityID string, opts ...search.FindOption) (search.Document, error) {
+	return e.primary.FindDocument(ctx, instanceID, indexName, entityName, entityID, opts...)
+}
+
+// Search searches the primary engine and, with WithShadowSearch, compares the result with the secondary.
+func (e *Engine) Search(ctx context.Context, instanceID string, query search.Query) (search.SearchResult, error) {
+	result, err := e.primary.Search(ctx, instanceID, query)
+	if err != nil {
+		return result, err
+	}
+	if e.onComparison != nil && rand.Float64() < e.shadowRate {
+		select {
+		case e.shadowSlots <- struct{}{}:
+			go e.shadowSearch(instanceID, query, result)
+		default:
+		}
+	}
+	return result, nil
+}
+
+// Ping pings both engines.
+func (e *Engine) Ping(ctx context.Context) error {
+	if err := e.primary.Ping(ctx); err != nil {
+		return err
+	}
+	return e.secondaryWrite("Ping", e.secondary.Ping(ctx))
+}
+
+// secondaryWrite applies the error policy to the outcome of an operation on the secondary engine.
+func (e *Engine) secondaryWrite(method string, err error) error {
+	if err == nil {
+		return nil
+	}
+	secondaryErr := &SecondaryError{Method: method, Err: err}
+	if !e.bestEffort {
+		return secondaryErr
+	}
+	if e.onSecondaryError != nil {
+		e.onSecondaryError(secondaryErr)
+	}
+	return nil
+}
+
+// shadowSearch runs a search on the secondary engine and reports the comparison with the primary result. It
+// frees its slot once done.
+func (e *Engine) shadowSearch(instanceID string, query search.Query, primary search.SearchResult) {
+	defer func() { <-e.shadowSlots }()
+
+	ctx, cancel := context.WithTimeout(context.Background(), e.shadowTimeout)
+	defer cancel()
+
+	c := Comparison{InstanceID: instanceID, Query: query, Primary: primary}
+	c.Secondary, c.SecondaryErr = e.secondary.Search(ctx, instanceID, query)
+	if c.SecondaryErr == nil {
+		c.Missing, c.Extra, c.OrderChanged = search.DiffHits(primary.Hits, c.Secondary.Hits)
+	}
+	e.onComparison(c)
+}
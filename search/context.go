@@ -0,0 +1,60 @@
+package search
+
+import "context"
+
+// requestIDKey is the context key of the request ID.
+type requestIDKey struct{}
+
+// WithRequestID returns a context carrying a request or correlation ID. Search engines attach it to the requests
+// they send and to their logs, so cluster logs can be correlated with application traces.
+func WithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, id)
+}
+
+// RequestID returns the request ID carried by the context, or an empty string.
+func RequestID(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
+
+// ClusterTarget selects the clusters an operation is sent to by engines that replicate to several clusters.
+type ClusterTarget int
+
+const (
+	DefaultClusters ClusterTarget = iota // Writes go to every cluster and reads to the cluster configured for them.
+	Primary                              // Only the primary cluster.
+	Secondary                            // Only the secondary cluster.
+	Both                                 // Writes go to both clusters; document lookups are verified across both.
+)
+
+// clusterTargetKey is the context key of the cluster target.
+type clusterTargetKey struct{}
+
+// WithCluster returns a context targeting the operations made with it at the given clusters, e.g. to read a
+// document from the secondary cluster only in verification tooling, or to backfill it in migration scripts.
+// Engines with a single cluster ignore it.
+func WithCluster(ctx context.Context, target ClusterTarget) context.Context {
+	return context.WithValue(ctx, clusterTargetKey{}, target)
+}
+
+// TargetCluster returns the cluster target carried by the context, or DefaultClusters.
+func TargetCluster(ctx context.Context) ClusterTarget {
+	target, _ := ctx.Value(clusterTargetKey{}).(ClusterTarget)
+	return target
+}
+
+// searchIndicesKey is the context key of the indices searches are sent to.
+type searchIndicesKey struct{}
+
+// WithSearchIndices returns a context sending the searches made with it to the given indices only, instead of
+// every index the engine searches by default, so clusters do not fan the search out to the shards of other
+// indices.
+func WithSearchIndices(ctx context.Context, indices ...string) context.Context {
+	return context.WithValue(ctx, searchIndicesKey{}, indices)
+}
+
+// SearchIndices returns the indices carried by the context, or nil if searches are not restricted.
+func SearchIndices(ctx context.Context) []string {
+	indices, _ := ctx.Value(searchIndicesKey{}).([]string)
+	return indices
+}
@@ -0,0 +1,72 @@
+package search
+
+import (
+	"time"
+)
+
+// DedupKeep selects which copy of a document is kept when hits are deduplicated.
+type DedupKeep int
+
+const (
+	KeepHighestScore DedupKeep = iota // The copy with the highest score, i.e. the first one of a ranked list.
+	KeepNewest                        // The copy with the latest value of a timestamp field.
+)
+
+// DedupConfig configures the deduplication of hits returned by several indices or clusters.
+type DedupConfig struct {
+	Keep           DedupKeep // Which copy of a document is kept. Defaults to KeepHighestScore.
+	TimestampField string    // The document field compared by KeepNewest, e.g. "updated_at".
+}
+
+// Deduplicate removes hits sharing the ID of an earlier hit, which happens when overlapping indices or aliases
+// are searched together. The kept copy takes the position of the first copy, so the ranking is preserved.
+func Deduplicate(hits []Hit, config DedupConfig) []Hit {
+	positions := make(map[string]int, len(hits))
+	deduped := make([]Hit, 0, len(hits))
+
+	for _, hit := range hits {
+		i, ok := positions[hit.ID]
+		if !ok {
+			positions[hit.ID] = len(deduped)
+			deduped = append(deduped, hit)
+			continue
+		}
+
+		kept := deduped[i]
+		switch config.Keep {
+		case KeepNewest:
+			if newer(hit.Document[config.TimestampField], kept.Document[config.TimestampField]) {
+				deduped[i] = hit
+			}
+		default:
+			if hit.Score > kept.Score {
+				deduped[i] = hit
+			}
+		}
+	}
+
+	return deduped
+}
+
+// newer reports whether timestamp a is later than timestamp b. Timestamps are either RFC 3339 strings or
+// numbers, such as epoch milliseconds; a missing or unparsable timestamp is older than any other.
+func newer(a, b interface{}) bool {
+	switch at := a.(type) {
+	case float64:
+		bt, ok := b.(float64)
+		return !ok || at > bt
+	case string:
+		ta, err := time.Parse(time.RFC3339Nano, at)
+		if err != nil {
+			return false
+		}
+		bs, ok := b.(string)
+		if !ok {
+			return true
+		}
+		tb, err := time.Parse(time.RFC3339Nano, bs)
+		return err != nil || ta.After(tb)
+	default:
+		return false
+	}
+}
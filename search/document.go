@@ -1,6 +1,9 @@
 package search
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
 	"errors"
 	"fmt"
 )
@@ -8,6 +11,41 @@ import (
 // Document represents a generic structure for storing document data within a search engine.
 type Document map[string]interface{}
 
+// MetaKey is the key metadata is nested under in documents read with MetadataNested.
+const MetaKey = "_meta"
+
+// metadataFields are the fields added to every document by AddDocumentMetaData.
+var metadataFields = []string{"id", "instance_id", "entity_name"}
+
+// MetadataMode selects how the metadata added by the engine is presented in documents that are read back.
+type MetadataMode int
+
+const (
+	MetadataInline MetadataMode = iota // Metadata fields are top-level fields of the document, as stored.
+	MetadataStrip                      // Metadata fields are removed from the document.
+	MetadataNested                     // Metadata fields are moved under MetaKey.
+)
+
+// ApplyMetadataMode rearranges the metadata fields of a document read back from the search engine according to
+// the mode. Only the fields present in the document are moved.
+func (d Document) ApplyMetadataMode(mode MetadataMode) {
+	if mode == MetadataInline || d == nil {
+		return
+	}
+
+	meta := make(map[string]interface{}, len(metadataFields))
+	for _, f := range metadataFields {
+		if v, ok := d[f]; ok {
+			meta[f] = v
+			delete(d, f)
+		}
+	}
+
+	if mode == MetadataNested && len(meta) > 0 {
+		d[MetaKey] = meta
+	}
+}
+
 // GenerateDocumentKey creates a unique key for storing the document.
 func GenerateDocumentID(instanceID, entityName, entityID string) string {
 	return fmt.Sprintf("%s-%s-%s", instanceID, entityName, entityID)
@@ -34,3 +72,26 @@ func (d Document) AddDocumentMetaData(instanceID, entityName, entityID string) (
 
 	return d, nil
 }
+
+// Hash returns a stable SHA-256 digest of the document contents. The document is normalised through a JSON
+// round-trip first, so a document read back from the search engine hashes the same as the one that was written.
+func (d Document) Hash() (string, error) {
+	b, err := json.Marshal(d)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal document %v", err)
+	}
+
+	var normalised interface{}
+	if err := json.Unmarshal(b, &normalised); err != nil {
+		return "", fmt.Errorf("failed to unmarshal document %v", err)
+	}
+
+	// encoding/json sorts map keys, which makes the second encoding canonical.
+	b, err = json.Marshal(normalised)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal document %v", err)
+	}
+
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:]), nil
+}
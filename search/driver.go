@@ -0,0 +1,99 @@
+package search
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// Driver constructs an engine from a data source name, whose format is defined by the driver, e.g. the endpoint
+// of a cluster.
+type Driver func(ctx context.Context, dsn string, options OpenOptions) (SearchEngine, error)
+
+// OpenOption is a function type that applies configuration options to an OpenOptions instance.
+type OpenOption func(*OpenOptions)
+
+// OpenOptions defines configuration options for Open.
+type OpenOptions struct {
+	Middlewares []EngineMiddleware     // Wrap the opened engine; the first middleware is the outermost.
+	Settings    map[string]interface{} // Driver-specific settings, e.g. a logger, documented by each driver.
+}
+
+// WithOpenMiddleware returns an OpenOption that wraps the opened engine with the middlewares.
+func WithOpenMiddleware(mws ...EngineMiddleware) OpenOption {
+	return func(opts *OpenOptions) {
+		opts.Middlewares = append(opts.Middlewares, mws...)
+	}
+}
+
+// WithDriverSetting returns an OpenOption that passes a driver-specific setting to the driver.
+func WithDriverSetting(key string, value interface{}) OpenOption {
+	return func(opts *OpenOptions) {
+		if opts.Settings == nil {
+			opts.Settings = make(map[string]interface{})
+		}
+		opts.Settings[key] = value
+	}
+}
+
+var (
+	driversMu sync.RWMutex
+	drivers   = make(map[string]Driver)
+)
+
+// Register makes a driver available by name to Open, so applications select the engine by configuration rather
+// than by code. Engine packages register their drivers when they are imported, e.g. for side effects only:
+//
+//	import _ "github.com/joshilesanmi/open-search-dev/search/opensearch"
+//
+// Register panics if the driver is nil or a driver is already registered under the name.
+func Register(name string, driver Driver) {
+	driversMu.Lock()
+	defer driversMu.Unlock()
+
+	if driver == nil {
+		panic("search: Register driver is nil")
+	}
+	if _, ok := drivers[name]; ok {
+		panic("search: Register called twice for driver " + name)
+	}
+	drivers[name] = driver
+}
+
+// Drivers returns the sorted names of the registered drivers.
+func Drivers() []string {
+	driversMu.RLock()
+	defer driversMu.RUnlock()
+
+	names := make([]string, 0, len(drivers))
+	for name := range drivers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Open constructs an engine with a registered driver.
+func Open(ctx context.Context, driverName, dsn string, opts ...OpenOption) (SearchEngine, error) {
+	driversMu.RLock()
+	driver, ok := drivers[driverName]
+	driversMu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("search: unknown driver %q (forgotten import?)", driverName)
+	}
+
+	options := OpenOptions{}
+	for _, opt := range opts {
+		opt(&options)
+	}
+
+	engine, err := driver(ctx, dsn, options)
+	if err != nil {
+		return nil, fmt.Errorf("search: open %s: %w", driverName, err)
+	}
+	for i := len(options.Middlewares) - 1; i >= 0; i-- {
+		engine = options.Middlewares[i](engine)
+	}
+	return engine, nil
+}
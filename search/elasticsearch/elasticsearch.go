@@ -0,0 +1,306 @@
+// Package elasticsearch provides a search.SearchEngine backed by an Elasticsearch 7 or 8 cluster, for deployments
+// that have not moved to OpenSearch. It sends its requests with the official go-elasticsearch client, whose 7.17
+// release talks to both versions. Only the SearchEngine interface is implemented: dual writes, protections and
+// the other features of the opensearch package are not available.
+package elasticsearch
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"time"
+
+	elasticsearch "github.com/elastic/go-elasticsearch/v7"
+	"github.com/elastic/go-elasticsearch/v7/esapi"
+	"github.com/joshilesanmi/open-search-dev/search"
+)
+
+// Error is an error response of the cluster.
+type Error struct {
+	Status int    // The HTTP status code.
+	Type   string // The error type, e.g. "index_not_found_exception".
+	Reason string // The error reason.
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("elasticsearch: %d %s: %s", e.Status, e.Type, e.Reason)
+}
+
+// Option is a function type that applies configuration options to an Engine.
+type Option func(*Engine) error
+
+// WithHTTPClient sets the HTTP client the go-elasticsearch client sends its requests with. Defaults to a client
+// with a 30 second timeout.
+func WithHTTPClient(client *http.Client) Option {
+	return func(e *Engine) error {
+		if client == nil {
+			return errors.New("http client must not be nil")
+		}
+		e.httpClient = client
+		return nil
+	}
+}
+
+// WithBasicAuth authenticates the requests with a username and password.
+func WithBasicAuth(username, password string) Option {
+	return func(e *Engine) error {
+		e.username, e.password = username, password
+		return nil
+	}
+}
+
+// WithAPIKey authenticates the requests with an encoded API key, as returned by the create API key API.
+func WithAPIKey(key string) Option {
+	return func(e *Engine) error {
+		e.apiKey = key
+		return nil
+	}
+}
+
+// Engine is a search.SearchEngine backed by an Elasticsearch cluster. It is safe for concurrent use.
+type Engine struct {
+	client *elasticsearch.Client
+
+	httpClient *http.Client
+	username   string
+	password   string
+	apiKey     string
+}
+
+// Ensures the Engine struct correctly implements the search.SearchEngine interface.
+var _ search.SearchEngine = &Engine{}
+
+// New returns an Engine for the cluster at endpoint, e.g. "https://localhost:9200".
+func New(endpoint string, opts ...Option) (*Engine, error) {
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return nil, fmt.Errorf("invalid endpoint: %w", err)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return nil, fmt.Errorf("invalid endpoint %q: scheme must be http or https", endpoint)
+	}
+
+	e := &Engine{httpClient: &http.Client{Timeout: 30 * time.Second}}
+	for _, opt := range opts {
+		if err := opt(e); err != nil {
+			return nil, err
+		}
+	}
+
+	e.client, err = elasticsearch.NewClient(elasticsearch.Config{
+		Addresses: []string{endpoint},
+		Username:  e.username,
+		Password:  e.password,
+		APIKey:    e.apiKey,
+		Transport: clientTransport{client: e.httpClient},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create client: %w", err)
+	}
+	return e, nil
+}
+
+// clientTransport sends the requests of the go-elasticsearch client with an *http.Client, so its timeout, cookie
+// jar and redirect policy apply as they would to any other request.
+type clientTransport struct {
+	client *http.Client
+}
+
+func (t clientTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	return t.client.Do(req)
+}
+
+// CreateIndex creates an index with the given settings and mappings, unless it exists already.
+func (e *Engine) CreateIndex(ctx context.Context, indexName string, config map[string]interface{}) error {
+	req := esapi.IndicesCreateRequest{Index: url.PathEscape(indexName)}
+	if config != nil {
+		body, err := jsonBody(config)
+		if err != nil {
+			return err
+		}
+		req.Body = body
+	}
+
+	err := e.do(ctx, req, nil)
+	var apiErr *Error
+	if errors.As(err, &apiErr) && apiErr.Type == "resource_already_exists_exception" {
+		return nil
+	}
+	return err
+}
+
+// DeleteIndex deletes an index. The options are ignored, as the engine has no index protections.
+func (e *Engine) DeleteIndex(ctx context.Context, indexName string, _ ...search.DeleteIndexOption) error {
+	return e.do(ctx, esapi.IndicesDeleteRequest{Index: []string{url.PathEscape(indexName)}}, nil)
+}
+
+// RefreshIndex makes the recent writes to an index searchable.
+func (e *Engine) RefreshIndex(ctx context.Context, indexName string) error {
+	return e.do(ctx, esapi.IndicesRefreshRequest{Index: []string{url.PathEscape(indexName)}}, nil)
+}
+
+// PutDocument stores a document with its metadata under the ID generated from the metadata.
+func (e *Engine) PutDocument(ctx context.Context, instanceID, indexName, entityName, entityID string, document search.Document, opts ...search.IndexOption) error {
+	options := &search.IndexOptions{}
+	for _, opt := range opts {
+		opt(options)
+	}
+
+	d, err := document.AddDocumentMetaData(instanceID, entityName, entityID)
+	if err != nil {
+		return fmt.Errorf("missing document meta data %v", err)
+	}
+	body, err := jsonBody(d)
+	if err != nil {
+		return err
+	}
+
+	req := esapi.IndexRequest{
+		Index:      url.PathEscape(indexName),
+		DocumentID: documentID(instanceID, entityName, entityID),
+		Body:       body,
+	}
+	if options.Refresh {
+		req.Refresh = "true"
+	}
+	return e.do(ctx, req, nil)
+}
+
+// DeleteDocument deletes a document. It returns search.ErrDocumentNotFound if the document does not exist.
+func (e *Engine) DeleteDocument(ctx context.Context, instanceID, indexName, entityName, entityID string) error {
+	req := esapi.DeleteRequest{Index: url.PathEscape(indexName), DocumentID: documentID(instanceID, entityName, entityID)}
+	return notFound(e.do(ctx, req, nil))
+}
+
+// FindDocument returns a document. It returns search.ErrDocumentNotFound if the document or its index does not
+// exist. The options are ignored, as there is a single cluster.
+func (e *Engine) FindDocument(ctx context.Context, instanceID, indexName, entityName, entityID string, _ ...search.FindOption) (search.Document, error) {
+	var r struct {
+		Source search.Document `json:"_source"`
+	}
+	req := esapi.GetRequest{Index: url.PathEscape(indexName), DocumentID: documentID(instanceID, entityName, entityID)}
+	if err := e.do(ctx, req, &r); err != nil {
+		return nil, notFound(err)
+	}
+	return r.Source, nil
+}
+
+// Search returns the documents of an instance matching a query across all indices, or the indices of the context
+// if set with search.WithSearchIndices, using the same query DSL as the opensearch package.
+func (e *Engine) Search(ctx context.Context, instanceID string, query search.Query) (search.SearchResult, error) {
+	var r struct {
+		Took int64 `json:"took"`
+		Hits struct {
+			Total struct {
+				Value    int64  `json:"value"`
+				Relation string `json:"relation"`
+			} `json:"total"`
+			Hits []struct {
+				ID     string          `json:"_id"`
+				Index  string          `json:"_index"`
+				Score  float64         `json:"_score"`
+				Source search.Document `json:"_source"`
+			} `json:"hits"`
+		} `json:"hits"`
+	}
+
+	body, err := jsonBody(query.DSL(instanceID))
+	if err != nil {
+		return search.SearchResult{}, err
+	}
+	if err := e.do(ctx, esapi.SearchRequest{Index: search.SearchIndices(ctx), Body: body}, &r); err != nil {
+		return search.SearchResult{}, err
+	}
+
+	result := search.SearchResult{
+		Hits:          make([]search.Hit, 0, len(r.Hits.Hits)),
+		Total:         r.Hits.Total.Value,
+		TotalRelation: r.Hits.Total.Relation,
+		Took:          time.Duration(r.Took) * time.Millisecond,
+	}
+	for _, hit := range r.Hits.Hits {
+		result.Hits = append(result.Hits, search.Hit{ID: hit.ID, Index: hit.Index, Score: hit.Score, Document: hit.Source})
+	}
+	return result, nil
+}
+
+// Ping checks that the cluster is reachable.
+func (e *Engine) Ping(ctx context.Context) error {
+	return e.do(ctx, esapi.PingRequest{}, nil)
+}
+
+// do sends a request and decodes the response into result, if set. Error responses are returned as *Error.
+func (e *Engine) do(ctx context.Context, req esapi.Request, result interface{}) error {
+	res, err := req.Do(ctx, e.client)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	if res.IsError() {
+		return decodeError(res)
+	}
+	if result == nil {
+		return nil
+	}
+	if err := json.NewDecoder(res.Body).Decode(result); err != nil {
+		return fmt.Errorf("failed to decode response %v", err)
+	}
+	return nil
+}
+
+// jsonBody returns the JSON encoding of a request body.
+func jsonBody(v interface{}) (io.Reader, error) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal request body %v", err)
+	}
+	return bytes.NewReader(b), nil
+}
+
+// decodeError converts an error response into an *Error.
+func decodeError(res *esapi.Response) error {
+	apiErr := &Error{Status: res.StatusCode}
+
+	b, err := io.ReadAll(res.Body)
+	if err != nil {
+		return apiErr
+	}
+	var r struct {
+		Error json.RawMessage `json:"error"`
+	}
+	if err := json.Unmarshal(b, &r); err != nil {
+		return apiErr
+	}
+
+	var cause struct {
+		Type   string `json:"type"`
+		Reason string `json:"reason"`
+	}
+	if err := json.Unmarshal(r.Error, &cause); err == nil {
+		apiErr.Type, apiErr.Reason = cause.Type, cause.Reason
+	} else {
+		// Some errors are reported as a plain string.
+		_ = json.Unmarshal(r.Error, &apiErr.Reason)
+	}
+	return apiErr
+}
+
+// notFound maps 404 responses to search.ErrDocumentNotFound.
+func notFound(err error) error {
+	var apiErr *Error
+	if errors.As(err, &apiErr) && apiErr.Status == http.StatusNotFound {
+		return search.ErrDocumentNotFound
+	}
+	return err
+}
+
+// documentID returns the generated ID of a document, escaped for the request path.
+func documentID(instanceID, entityName, entityID string) string {
+	return url.PathEscape(search.GenerateDocumentID(instanceID, entityName, entityID))
+}
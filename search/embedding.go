@@ -0,0 +1,11 @@
+package search
+
+import (
+	"context"
+)
+
+// Embedder converts texts into vector embeddings for semantic search. Implementations typically call an
+// embedding model; Embed must return one vector per input text, in the same order.
+type Embedder interface {
+	Embed(ctx context.Context, texts []string) ([][]float32, error)
+}
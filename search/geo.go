@@ -0,0 +1,50 @@
+package search
+
+// Filter is a clause restricting the results of a Query without affecting their scores.
+type Filter interface {
+	// FilterDSL returns the query DSL of the filter clause.
+	FilterDSL() map[string]interface{}
+}
+
+// GeoPoint is a location given by latitude and longitude in degrees.
+type GeoPoint struct {
+	Lat float64 `json:"lat"`
+	Lon float64 `json:"lon"`
+}
+
+// GeoDistance is a Filter matching documents whose location field lies within a distance of a center point,
+// e.g. "people within 50km of Berlin".
+type GeoDistance struct {
+	Field    string   // A field mapped as geo_point.
+	Center   GeoPoint // The center of the circle.
+	Distance string   // The radius including its unit, e.g. "50km".
+}
+
+// FilterDSL returns the geo_distance clause of the filter.
+func (f GeoDistance) FilterDSL() map[string]interface{} {
+	return map[string]interface{}{
+		"geo_distance": map[string]interface{}{
+			"distance": f.Distance,
+			f.Field:    f.Center,
+		},
+	}
+}
+
+// GeoBoundingBox is a Filter matching documents whose location field lies within a rectangle.
+type GeoBoundingBox struct {
+	Field       string   // A field mapped as geo_point.
+	TopLeft     GeoPoint // The north-west corner of the box.
+	BottomRight GeoPoint // The south-east corner of the box.
+}
+
+// FilterDSL returns the geo_bounding_box clause of the filter.
+func (f GeoBoundingBox) FilterDSL() map[string]interface{} {
+	return map[string]interface{}{
+		"geo_bounding_box": map[string]interface{}{
+			f.Field: map[string]interface{}{
+				"top_left":     f.TopLeft,
+				"bottom_right": f.BottomRight,
+			},
+		},
+	}
+}
@@ -0,0 +1,220 @@
+package search
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"sync"
+)
+
+// ErrTooManyEngines is an error indicating that a Manager did not construct an engine because it already holds
+// the maximum number of engines.
+var ErrTooManyEngines = errors.New("too many engines")
+
+// EngineFactory constructs the engine for a configuration key, e.g. a region or a tenant cluster.
+type EngineFactory func(ctx context.Context, key string) (SearchEngine, error)
+
+// EngineMiddleware wraps a SearchEngine with additional behaviour, such as logging or retries.
+type EngineMiddleware func(SearchEngine) SearchEngine
+
+// ManagerOption is a function type that applies configuration options to a Manager.
+type ManagerOption func(*Manager)
+
+// WithMaxEngines bounds the number of engines a Manager holds. Zero means unlimited. It does not bound the
+// connections of the engines, see WithMaxConnections.
+func WithMaxEngines(n int) ManagerOption {
+	return func(m *Manager) {
+		m.maxEngines = n
+	}
+}
+
+// WithMaxConnections bounds the number of connections open at once through the transport returned by
+// Manager.Transport, across all engines and hosts. Requests needing a new connection while the limit is reached
+// wait for one to be closed, or for their context to be done. Zero means unlimited.
+func WithMaxConnections(n int) ManagerOption {
+	return func(m *Manager) {
+		m.maxConns = n
+	}
+}
+
+// WithEngineMiddleware wraps every engine constructed by a Manager with the middlewares. The first middleware
+// is the outermost.
+func WithEngineMiddleware(mws ...EngineMiddleware) ManagerOption {
+	return func(m *Manager) {
+		m.middlewares = append(m.middlewares, mws...)
+	}
+}
+
+// Manager lazily constructs and caches engines by configuration key, so services talking to several clusters,
+// e.g. one per region, share a single registry. It is safe for concurrent use; concurrent requests for the same
+// key construct the engine only once. Failed constructions are not cached.
+type Manager struct {
+	factory     EngineFactory
+	middlewares []EngineMiddleware
+	maxEngines  int
+	maxConns    int
+	transport   *http.Transport // Shared by the engines whose factory uses Transport.
+
+	mu      sync.Mutex
+	engines map[string]*managedEngine
+}
+
+// managedEngine is an engine that is constructed, or being constructed, by a Manager.
+type managedEngine struct {
+	ready  chan struct{} // Closed once the construction has finished.
+	engine SearchEngine  // The wrapped engine, once ready.
+	raw    SearchEngine  // The engine as returned by the factory, for closing.
+	err    error
+}
+
+// NewManager creates a Manager constructing engines with the factory.
+func NewManager(factory EngineFactory, opts ...ManagerOption) *Manager {
+	m := &Manager{
+		factory: factory,
+		engines: make(map[string]*managedEngine),
+	}
+	for _, opt := range opts {
+		opt(m)
+	}
+
+	m.transport = http.DefaultTransport.(*http.Transport).Clone()
+	if m.maxConns > 0 {
+		m.transport.DialContext = limitConns(m.transport.DialContext, m.maxConns)
+		m.transport.MaxIdleConns = m.maxConns
+	}
+	return m
+}
+
+// Transport returns the HTTP transport shared by the engines of the Manager, which the factory passes to the
+// engines it constructs so their connections are pooled, and bounded by WithMaxConnections:
+//
+//	var m *search.Manager
+//	m = search.NewManager(func(ctx context.Context, region string) (search.SearchEngine, error) {
+//		return opensearch.NewOpenSearch(endpoints[region], logger, opensearch.WithTransport(m.Transport()))
+//	}, search.WithMaxConnections(64))
+func (m *Manager) Transport() http.RoundTripper {
+	return m.transport
+}
+
+// Engine returns the engine for a configuration key, constructing it on first use.
+func (m *Manager) Engine(ctx context.Context, key string) (SearchEngine, error) {
+	m.mu.Lock()
+	e, ok := m.engines[key]
+	if !ok {
+		if m.maxEngines > 0 && len(m.engines) >= m.maxEngines {
+			m.mu.Unlock()
+			return nil, fmt.Errorf("engine %q: %w", key, ErrTooManyEngines)
+		}
+		e = &managedEngine{ready: make(chan struct{})}
+		m.engines[key] = e
+	}
+	m.mu.Unlock()
+
+	if !ok {
+		m.construct(ctx, key, e)
+	}
+
+	select {
+	case <-e.ready:
+		return e.engine, e.err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// construct runs the factory for a key and publishes the result to the waiting callers.
+func (m *Manager) construct(ctx context.Context, key string, e *managedEngine) {
+	defer close(e.ready)
+
+	raw, err := m.factory(ctx, key)
+	if err != nil {
+		e.err = fmt.Errorf("engine %q: %w", key, err)
+		m.mu.Lock()
+		// The key may have been evicted and recreated while the factory ran.
+		if m.engines[key] == e {
+			delete(m.engines, key)
+		}
+		m.mu.Unlock()
+		return
+	}
+
+	engine := raw
+	for i := len(m.middlewares) - 1; i >= 0; i-- {
+		engine = m.middlewares[i](engine)
+	}
+
+	e.raw = raw
+	e.engine = engine
+}
+
+// Keys returns the keys of the engines constructed so far.
+func (m *Manager) Keys() []string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	keys := make([]string, 0, len(m.engines))
+	for k := range m.engines {
+		keys = append(keys, k)
+	}
+	return keys
+}
+
+// Close removes every engine from the Manager, closing those that implement io.Closer. Engines still being
+// constructed are waited for.
+func (m *Manager) Close() error {
+	m.mu.Lock()
+	engines := m.engines
+	m.engines = make(map[string]*managedEngine)
+	m.mu.Unlock()
+
+	defer m.transport.CloseIdleConnections()
+
+	var errs []error
+	for key, e := range engines {
+		<-e.ready
+		if c, ok := e.raw.(io.Closer); ok {
+			if err := c.Close(); err != nil {
+				errs = append(errs, fmt.Errorf("engine %q: %w", key, err))
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// dialFunc is the signature of http.Transport.DialContext.
+type dialFunc func(ctx context.Context, network, addr string) (net.Conn, error)
+
+// limitConns returns a dialFunc that allows at most n connections dialled with dial to be open at once.
+func limitConns(dial dialFunc, n int) dialFunc {
+	slots := make(chan struct{}, n)
+	return func(ctx context.Context, network, addr string) (net.Conn, error) {
+		select {
+		case slots <- struct{}{}:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+
+		conn, err := dial(ctx, network, addr)
+		if err != nil {
+			<-slots
+			return nil, err
+		}
+		return &limitedConn{Conn: conn, release: func() { <-slots }}, nil
+	}
+}
+
+// limitedConn is a connection that frees its slot once it is closed.
+type limitedConn struct {
+	net.Conn
+	once    sync.Once
+	release func()
+}
+
+func (c *limitedConn) Close() error {
+	err := c.Conn.Close()
+	c.once.Do(c.release)
+	return err
+}
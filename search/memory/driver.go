@@ -0,0 +1,17 @@
+package memory
+
+import (
+	"context"
+
+	"github.com/joshilesanmi/open-search-dev/search"
+)
+
+// DriverName is the name the in-memory driver is registered under, see search.Open.
+const DriverName = "memory"
+
+func init() {
+	search.Register(DriverName, func(context.Context, string, search.OpenOptions) (search.SearchEngine, error) {
+		// The DSN is ignored: every opened engine is empty and independent.
+		return New(), nil
+	})
+}
@@ -0,0 +1,172 @@
+package memory
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/joshilesanmi/open-search-dev/search"
+)
+
+// Match records that a query term matched a field of a document.
+type Match struct {
+	Term  string
+	Field string
+}
+
+// term is a single term of a query value: an optional field, the lowercased text and whether it is a prefix.
+type term struct {
+	field  string
+	text   string
+	prefix bool
+}
+
+// matcher evaluates a query against documents. Terms are matched case-insensitively against the words of string
+// values; "field:term" restricts a term to a field in QueryModeQueryString, and a trailing "*" matches prefixes.
+type matcher struct {
+	terms  []term
+	all    bool // Whether every term must match.
+	fields map[string]bool
+	query  search.Query
+}
+
+func newMatcher(query search.Query) (*matcher, error) {
+	m := &matcher{all: query.Operator == search.OperatorAnd, query: query}
+
+	if len(query.Fields) > 0 {
+		m.fields = make(map[string]bool, len(query.Fields))
+		for _, f := range query.Fields {
+			// Boosts do not affect matching.
+			m.fields[strings.SplitN(f, "^", 2)[0]] = true
+		}
+	}
+
+	for _, raw := range strings.Fields(query.Value) {
+		t := term{}
+		if query.Mode == search.QueryModeDefault || query.Mode == search.QueryModeQueryString {
+			if i := strings.Index(raw, ":"); i > 0 {
+				t.field, raw = raw[:i], raw[i+1:]
+			}
+		}
+		if query.Mode != search.QueryModeMultiMatch && strings.HasSuffix(raw, "*") {
+			t.prefix, raw = true, strings.TrimSuffix(raw, "*")
+		}
+		words := tokenize(raw)
+		if len(words) == 0 {
+			continue
+		}
+		for _, w := range words {
+			t.text = w
+			m.terms = append(m.terms, t)
+		}
+	}
+
+	for _, f := range query.Filters {
+		switch f.(type) {
+		case search.Term:
+		default:
+			return nil, fmt.Errorf("filter %T is not supported by the in-memory engine", f)
+		}
+	}
+
+	return m, nil
+}
+
+// match reports whether a document matches, which terms matched which fields and the score of the document.
+func (m *matcher) match(d search.Document) ([]Match, float64, bool) {
+	for _, f := range m.query.Filters {
+		if t, ok := f.(search.Term); ok && !termFilterMatches(d, t) {
+			return nil, 0, false
+		}
+	}
+
+	values := make(map[string][]string)
+	collectWords(map[string]interface{}(d), "", values)
+
+	var matches []Match
+	matched := 0
+	for _, t := range m.terms {
+		found := false
+		for field, words := range values {
+			if t.field != "" && t.field != field {
+				continue
+			}
+			if t.field == "" && m.fields != nil && !m.fields[field] {
+				continue
+			}
+			for _, w := range words {
+				if w == t.text || (t.prefix && strings.HasPrefix(w, t.text)) {
+					matches = append(matches, Match{Term: t.text, Field: field})
+					found = true
+					break
+				}
+			}
+		}
+		if found {
+			matched++
+		} else if m.all {
+			return nil, 0, false
+		}
+	}
+
+	if len(m.terms) > 0 && matched == 0 {
+		return nil, 0, false
+	}
+
+	sort.Slice(matches, func(i, j int) bool {
+		if matches[i].Term != matches[j].Term {
+			return matches[i].Term < matches[j].Term
+		}
+		return matches[i].Field < matches[j].Field
+	})
+
+	return matches, float64(len(matches)), true
+}
+
+// termFilterMatches reports whether a top-level field of a document equals the value of a term filter.
+func termFilterMatches(d search.Document, t search.Term) bool {
+	v, ok := d[t.Field]
+	if !ok {
+		return false
+	}
+	if items, ok := v.([]interface{}); ok {
+		for _, item := range items {
+			if fmt.Sprint(item) == fmt.Sprint(t.Value) {
+				return true
+			}
+		}
+		return false
+	}
+	return fmt.Sprint(v) == fmt.Sprint(t.Value)
+}
+
+// collectWords collects the lowercased words of every string value of a document, keyed by dotted field path.
+func collectWords(value interface{}, path string, values map[string][]string) {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for k, child := range v {
+			p := k
+			if path != "" {
+				p = path + "." + k
+			}
+			collectWords(child, p, values)
+		}
+	case []interface{}:
+		for _, item := range v {
+			collectWords(item, path, values)
+		}
+	case string:
+		values[path] = append(values[path], tokenize(v)...)
+	case nil:
+	default:
+		values[path] = append(values[path], strings.ToLower(fmt.Sprint(v)))
+	}
+}
+
+// tokenize splits text into lowercased words of letters and digits.
+func tokenize(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
@@ -0,0 +1,327 @@
+// Package memory provides a search.SearchEngine keeping documents in process, for local development and tests
+// that should not depend on a running cluster. Queries are evaluated by a simple term matcher, which approximates
+// but does not reproduce the analysis and scoring of OpenSearch.
+//
+// Single-binary deployments, CLIs and offline demos that need persistence or full-text analysis can embed the
+// bleve package instead.
+package memory
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/joshilesanmi/open-search-dev/search"
+)
+
+// Engine is an in-memory search.SearchEngine. Writes are visible to searches immediately.
+type Engine struct {
+	mu      sync.RWMutex
+	indices map[string]*index
+}
+
+type index struct {
+	config    map[string]interface{}
+	documents map[string]search.Document // Keyed by document ID.
+}
+
+// Ensures the Engine struct correctly implements the search.SearchEngine interface.
+var _ search.SearchEngine = &Engine{}
+
+// New returns an empty Engine.
+func New() *Engine {
+	return &Engine{indices: make(map[string]*index)}
+}
+
+// CreateIndex creates an index, unless it exists already.
+func (e *Engine) CreateIndex(_ context.Context, indexName string, config map[string]interface{}) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if _, ok := e.indices[indexName]; !ok {
+		e.indices[indexName] = &index{config: config, documents: make(map[string]search.Document)}
+	}
+	return nil
+}
+
+// DeleteIndex removes an index and its documents.
+func (e *Engine) DeleteIndex(_ context.Context, indexName string, _ ...search.DeleteIndexOption) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if _, ok := e.indices[indexName]; !ok {
+		return fmt.Errorf("index %q does not exist", indexName)
+	}
+	delete(e.indices, indexName)
+	return nil
+}
+
+// RefreshIndex does nothing, as writes are visible immediately, but fails for missing indices.
+func (e *Engine) RefreshIndex(_ context.Context, indexName string) error {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+
+	if _, ok := e.indices[indexName]; !ok {
+		return fmt.Errorf("index %q does not exist", indexName)
+	}
+	return nil
+}
+
+// PutDocument stores a copy of a document with its metadata, creating the index if needed.
+func (e *Engine) PutDocument(_ context.Context, instanceID, indexName, entityName, entityID string, document search.Document, _ ...search.IndexOption) error {
+	d, err := copyDocument(document)
+	if err != nil {
+		return err
+	}
+
+	if d, err = d.AddDocumentMetaData(instanceID, entityName, entityID); err != nil {
+		return fmt.Errorf("missing document meta data %v", err)
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	idx, ok := e.indices[indexName]
+	if !ok {
+		idx = &index{documents: make(map[string]search.Document)}
+		e.indices[indexName] = idx
+	}
+	idx.documents[search.GenerateDocumentID(instanceID, entityName, entityID)] = d
+
+	return nil
+}
+
+// DeleteDocument removes a document.
+func (e *Engine) DeleteDocument(_ context.Context, instanceID, indexName, entityName, entityID string) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	idx, ok := e.indices[indexName]
+	documentID := search.GenerateDocumentID(instanceID, entityName, entityID)
+	if !ok || idx.documents[documentID] == nil {
+		return search.ErrDocumentNotFound
+	}
+	delete(idx.documents, documentID)
+
+	return nil
+}
+
+// FindDocument returns a copy of a document.
+func (e *Engine) FindDocument(_ context.Context, instanceID, indexName, entityName, entityID string, _ ...search.FindOption) (search.Document, error) {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+
+	idx, ok := e.indices[indexName]
+	if !ok {
+		return nil, search.ErrDocumentNotFound
+	}
+	d, ok := idx.documents[search.GenerateDocumentID(instanceID, entityName, entityID)]
+	if !ok {
+		return nil, search.ErrDocumentNotFound
+	}
+
+	return copyDocument(d)
+}
+
+// Search returns the documents of an instance matching a query across all indices, or the indices of the context
+// if set with search.WithSearchIndices, best match first unless the query sorts them. A Collapse of the query
+// keeps the best match of every value of its field; Total still counts every match. Size limits the hits, to 10
+// by default.
+func (e *Engine) Search(ctx context.Context, instanceID string, query search.Query) (search.SearchResult, error) {
+	hits, err := e.Explain(ctx, instanceID, query)
+	if err != nil {
+		return search.SearchResult{}, err
+	}
+
+	result := search.SearchResult{
+		Hits:          make([]search.Hit, 0, len(hits)),
+		Total:         int64(len(hits)),
+		TotalRelation: "eq",
+	}
+	for _, h := range hits {
+		result.Hits = append(result.Hits, h.Hit)
+	}
+	if len(query.Sort) > 0 {
+		sortHits(result.Hits, query.Sort)
+	}
+	if query.Collapse != nil {
+		result.Hits = collapse(result.Hits, *query.Collapse)
+	}
+
+	size := query.Size
+	if size <= 0 {
+		size = 10
+	}
+	if len(result.Hits) > size {
+		result.Hits = result.Hits[:size]
+	}
+
+	return result, nil
+}
+
+// sortHits orders hits by the sort fields, comparing numbers numerically and other values as text. Hits missing
+// a field sort after the others, whatever the order.
+func sortHits(hits []search.Hit, fields []search.SortField) {
+	sort.SliceStable(hits, func(i, j int) bool {
+		for _, f := range fields {
+			var a, b interface{}
+			if f.Field == search.ScoreField {
+				a, b = hits[i].Score, hits[j].Score
+			} else {
+				a, b = hits[i].Document[f.Field], hits[j].Document[f.Field]
+			}
+
+			switch {
+			case a == nil && b == nil:
+				continue
+			case a == nil:
+				return false
+			case b == nil:
+				return true
+			}
+
+			c := compareValues(a, b)
+			if c == 0 {
+				continue
+			}
+			if f.Descending {
+				return c > 0
+			}
+			return c < 0
+		}
+		return false
+	})
+}
+
+// compareValues returns -1, 0 or 1 as a is less than, equal to or greater than b.
+func compareValues(a, b interface{}) int {
+	x, okA := a.(float64)
+	y, okB := b.(float64)
+	if !okA || !okB {
+		s, t := fmt.Sprint(a), fmt.Sprint(b)
+		switch {
+		case s < t:
+			return -1
+		case s > t:
+			return 1
+		}
+		return 0
+	}
+	switch {
+	case x < y:
+		return -1
+	case x > y:
+		return 1
+	}
+	return 0
+}
+
+// collapse keeps the first hit of every value of the collapse field, adding up to InnerHits documents with that
+// value under search.CollapsedKey. Hits without the field are collapsed together.
+func collapse(hits []search.Hit, c search.Collapse) []search.Hit {
+	kept := make([]search.Hit, 0, len(hits))
+	groups := make(map[string]int)
+	inner := make(map[string][]search.Document)
+
+	for _, h := range hits {
+		key := fmt.Sprint(h.Document[c.Field])
+		if len(inner[key]) < c.InnerHits {
+			inner[key] = append(inner[key], h.Document)
+		}
+		if _, ok := groups[key]; ok {
+			continue
+		}
+		groups[key] = len(kept)
+		kept = append(kept, h)
+	}
+
+	if c.InnerHits > 0 {
+		for key, i := range groups {
+			d := make(search.Document, len(kept[i].Document)+1)
+			for k, v := range kept[i].Document {
+				d[k] = v
+			}
+			d[search.CollapsedKey] = inner[key]
+			kept[i].Document = d
+		}
+	}
+	return kept
+}
+
+// Ping always succeeds.
+func (e *Engine) Ping(_ context.Context) error {
+	return nil
+}
+
+// ExplainedHit is a search hit with the terms that made it match.
+type ExplainedHit struct {
+	search.Hit
+	Matches []Match
+}
+
+// Explain evaluates a query like Search and reports, for every hit, which terms matched which fields.
+func (e *Engine) Explain(ctx context.Context, instanceID string, query search.Query) ([]ExplainedHit, error) {
+	m, err := newMatcher(query)
+	if err != nil {
+		return nil, err
+	}
+
+	var indexNames map[string]bool
+	if indices := search.SearchIndices(ctx); len(indices) > 0 {
+		indexNames = make(map[string]bool, len(indices))
+		for _, name := range indices {
+			indexNames[name] = true
+		}
+	}
+
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+
+	var hits []ExplainedHit
+	for name, idx := range e.indices {
+		if indexNames != nil && !indexNames[name] {
+			continue
+		}
+		for id, d := range idx.documents {
+			if d["instance_id"] != instanceID {
+				continue
+			}
+
+			matches, score, ok := m.match(d)
+			if !ok {
+				continue
+			}
+
+			c, err := copyDocument(d)
+			if err != nil {
+				return nil, err
+			}
+			hits = append(hits, ExplainedHit{Hit: search.Hit{ID: id, Index: name, Score: score, Document: c}, Matches: matches})
+		}
+	}
+
+	sort.Slice(hits, func(i, j int) bool {
+		if hits[i].Score != hits[j].Score {
+			return hits[i].Score > hits[j].Score
+		}
+		return hits[i].ID < hits[j].ID
+	})
+
+	return hits, nil
+}
+
+// copyDocument returns a deep copy of a document, so callers cannot modify stored documents.
+func copyDocument(d search.Document) (search.Document, error) {
+	b, err := json.Marshal(d)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal document %v", err)
+	}
+
+	var c search.Document
+	if err := json.Unmarshal(b, &c); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal document %v", err)
+	}
+	return c, nil
+}
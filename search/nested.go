@@ -0,0 +1,43 @@
+package search
+
+// Term is a Filter matching documents whose field contains exactly the given value.
+type Term struct {
+	Field string
+	Value interface{}
+}
+
+// FilterDSL returns the term clause of the filter.
+func (f Term) FilterDSL() map[string]interface{} {
+	return map[string]interface{}{
+		"term": map[string]interface{}{
+			f.Field: f.Value,
+		},
+	}
+}
+
+// Nested is a Filter matching documents with at least one object in a nested field that satisfies all of the
+// given filters, e.g. a phone number whose "phones.type" is "mobile" and whose "phones.value" is a given number.
+// The field must be mapped as nested; the filters refer to its sub-fields by their full path.
+type Nested struct {
+	Path    string   // The nested field, e.g. "phones".
+	Filters []Filter // Conditions that must hold for the same nested object.
+}
+
+// FilterDSL returns the nested clause of the filter.
+func (f Nested) FilterDSL() map[string]interface{} {
+	filters := make([]interface{}, 0, len(f.Filters))
+	for _, filter := range f.Filters {
+		filters = append(filters, filter.FilterDSL())
+	}
+
+	return map[string]interface{}{
+		"nested": map[string]interface{}{
+			"path": f.Path,
+			"query": map[string]interface{}{
+				"bool": map[string]interface{}{
+					"filter": filters,
+				},
+			},
+		},
+	}
+}
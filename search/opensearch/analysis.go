@@ -0,0 +1,222 @@
+package opensearch
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// Analysis is a typed builder for the analysis settings of an index: char filters, tokenizers, token filters
+// and custom analyzers. It marshals to the JSON expected under "settings.analysis", so it can be placed in an
+// index configuration with ApplyTo and passed to CreateIndex, which validates it before creating the index.
+type Analysis struct {
+	CharFilters map[string]Component
+	Tokenizers  map[string]Component
+	Filters     map[string]Component
+	Analyzers   map[string]Analyzer
+}
+
+// Component is a configured char filter, tokenizer or token filter.
+type Component struct {
+	Type   string                 // The component type, e.g. "edge_ngram".
+	Params map[string]interface{} // Type specific parameters, e.g. "min_gram".
+}
+
+// Analyzer is a custom analyzer composed of declared or built-in components, referenced by name.
+type Analyzer struct {
+	Tokenizer   string
+	CharFilters []string
+	Filters     []string
+}
+
+// NewAnalysis returns an empty Analysis.
+func NewAnalysis() *Analysis {
+	return &Analysis{
+		CharFilters: make(map[string]Component),
+		Tokenizers:  make(map[string]Component),
+		Filters:     make(map[string]Component),
+		Analyzers:   make(map[string]Analyzer),
+	}
+}
+
+// CharFilter declares a char filter under the given name.
+func (a *Analysis) CharFilter(name string, c Component) *Analysis {
+	a.CharFilters[name] = c
+	return a
+}
+
+// Tokenizer declares a tokenizer under the given name.
+func (a *Analysis) Tokenizer(name string, c Component) *Analysis {
+	a.Tokenizers[name] = c
+	return a
+}
+
+// Filter declares a token filter under the given name.
+func (a *Analysis) Filter(name string, c Component) *Analysis {
+	a.Filters[name] = c
+	return a
+}
+
+// Analyzer declares a custom analyzer under the given name.
+func (a *Analysis) Analyzer(name string, an Analyzer) *Analysis {
+	a.Analyzers[name] = an
+	return a
+}
+
+// Validate checks that every component has a type and every analyzer has a tokenizer. Components referenced by
+// name may be built-in, so references that are not declared in the Analysis are left to the cluster to resolve.
+func (a *Analysis) Validate() error {
+	for kind, components := range map[string]map[string]Component{
+		"char filter": a.CharFilters,
+		"tokenizer":   a.Tokenizers,
+		"filter":      a.Filters,
+	} {
+		for name, c := range components {
+			if name == "" {
+				return fmt.Errorf("%s name must not be empty", kind)
+			}
+			if c.Type == "" {
+				return fmt.Errorf("%s %q: type is required", kind, name)
+			}
+		}
+	}
+
+	for name, an := range a.Analyzers {
+		if name == "" {
+			return errors.New("analyzer name must not be empty")
+		}
+		if an.Tokenizer == "" {
+			return fmt.Errorf("analyzer %q: tokenizer is required", name)
+		}
+	}
+
+	return nil
+}
+
+// ApplyTo sets the analysis on an index configuration, creating its "settings" section if needed.
+func (a *Analysis) ApplyTo(config map[string]interface{}) error {
+	settings, ok := config["settings"].(map[string]interface{})
+	if !ok {
+		if config["settings"] != nil {
+			return errors.New("index config settings must be a map")
+		}
+		settings = make(map[string]interface{})
+		config["settings"] = settings
+	}
+
+	settings["analysis"] = a
+	return nil
+}
+
+// MarshalJSON encodes the analysis in the format expected by OpenSearch.
+func (a *Analysis) MarshalJSON() ([]byte, error) {
+	out := make(map[string]interface{})
+
+	for key, components := range map[string]map[string]Component{
+		"char_filter": a.CharFilters,
+		"tokenizer":   a.Tokenizers,
+		"filter":      a.Filters,
+	} {
+		if len(components) > 0 {
+			out[key] = components
+		}
+	}
+
+	if len(a.Analyzers) > 0 {
+		out["analyzer"] = a.Analyzers
+	}
+
+	return json.Marshal(out)
+}
+
+// MarshalJSON encodes the component as its type merged with its parameters.
+func (c Component) MarshalJSON() ([]byte, error) {
+	out := make(map[string]interface{}, len(c.Params)+1)
+	for k, v := range c.Params {
+		out[k] = v
+	}
+	out["type"] = c.Type
+
+	return json.Marshal(out)
+}
+
+// MarshalJSON encodes the analyzer as a custom analyzer.
+func (an Analyzer) MarshalJSON() ([]byte, error) {
+	out := map[string]interface{}{
+		"type":      "custom",
+		"tokenizer": an.Tokenizer,
+	}
+	if len(an.CharFilters) > 0 {
+		out["char_filter"] = an.CharFilters
+	}
+	if len(an.Filters) > 0 {
+		out["filter"] = an.Filters
+	}
+
+	return json.Marshal(out)
+}
+
+// EdgeNGramTokenizer returns an edge_ngram tokenizer emitting prefixes between minGram and maxGram characters
+// long, splitting on characters that do not belong to one of the given classes (e.g. "letter", "digit").
+func EdgeNGramTokenizer(minGram, maxGram int, tokenChars ...string) Component {
+	params := map[string]interface{}{
+		"min_gram": minGram,
+		"max_gram": maxGram,
+	}
+	if len(tokenChars) > 0 {
+		params["token_chars"] = tokenChars
+	}
+	return Component{Type: "edge_ngram", Params: params}
+}
+
+// UAXURLEmailTokenizer returns a tokenizer that keeps URLs and e-mail addresses as single tokens.
+func UAXURLEmailTokenizer() Component {
+	return Component{Type: "uax_url_email"}
+}
+
+// PatternTokenizer returns a tokenizer that splits text on matches of a regular expression.
+func PatternTokenizer(pattern string) Component {
+	return Component{Type: "pattern", Params: map[string]interface{}{"pattern": pattern}}
+}
+
+// EdgeNGramFilter returns an edge_ngram token filter emitting prefixes between minGram and maxGram characters long.
+func EdgeNGramFilter(minGram, maxGram int) Component {
+	return Component{Type: "edge_ngram", Params: map[string]interface{}{
+		"min_gram": minGram,
+		"max_gram": maxGram,
+	}}
+}
+
+// ASCIIFoldingFilter returns a filter that folds non-ASCII characters (e.g. umlauts) into their ASCII equivalents.
+// If preserveOriginal is true, the unfolded token is kept as well.
+func ASCIIFoldingFilter(preserveOriginal bool) Component {
+	return Component{Type: "asciifolding", Params: map[string]interface{}{"preserve_original": preserveOriginal}}
+}
+
+// StopFilter returns a filter removing the given stop words.
+func StopFilter(stopwords ...string) Component {
+	return Component{Type: "stop", Params: map[string]interface{}{"stopwords": stopwords}}
+}
+
+// MappingCharFilter returns a char filter replacing characters according to mappings of the form "ä => ae".
+func MappingCharFilter(mappings ...string) Component {
+	return Component{Type: "mapping", Params: map[string]interface{}{"mappings": mappings}}
+}
+
+// PatternReplaceCharFilter returns a char filter replacing matches of a regular expression.
+func PatternReplaceCharFilter(pattern, replacement string) Component {
+	return Component{Type: "pattern_replace", Params: map[string]interface{}{
+		"pattern":     pattern,
+		"replacement": replacement,
+	}}
+}
+
+// analysisFromConfig returns the typed Analysis placed in an index configuration, if any.
+func analysisFromConfig(config map[string]interface{}) *Analysis {
+	settings, ok := config["settings"].(map[string]interface{})
+	if !ok {
+		return nil
+	}
+	a, _ := settings["analysis"].(*Analysis)
+	return a
+}
@@ -0,0 +1,56 @@
+package opensearch
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+
+	opensearchapi "github.com/opensearch-project/opensearch-go/v2/opensearchapi"
+)
+
+// Token is a single token produced by an analyzer.
+type Token struct {
+	Token       string `json:"token"`
+	StartOffset int    `json:"start_offset"`
+	EndOffset   int    `json:"end_offset"`
+	Type        string `json:"type"`
+	Position    int    `json:"position"`
+}
+
+// AnalyzeText runs text through an analyzer on the primary cluster and returns the resulting tokens. It is meant
+// for debugging why a query does not match, e.g. how e-mail addresses or names with umlauts are tokenized.
+// If indexName is set, custom analyzers defined on that index can be used. If analyzer is empty, the default
+// analyzer of the index (or the standard analyzer) is used.
+func (os *OpenSearch) AnalyzeText(ctx context.Context, indexName, analyzer, text string) ([]Token, error) {
+	body := map[string]interface{}{
+		"text": text,
+	}
+	if analyzer != "" {
+		body["analyzer"] = analyzer
+	}
+
+	b, err := json.Marshal(body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal analyze request: %v", err)
+	}
+
+	req := opensearchapi.IndicesAnalyzeRequest{
+		Index: indexName,
+		Body:  bytes.NewReader(b),
+	}
+
+	resp, err := os.executeReadRequest(ctx, os.primary().client, req)
+	if err != nil {
+		return nil, err
+	}
+
+	var r struct {
+		Tokens []Token `json:"tokens"`
+	}
+	if err := os.decodeResponse(resp, &r); err != nil {
+		return nil, err
+	}
+
+	return r.Tokens, nil
+}
@@ -0,0 +1,198 @@
+package opensearch
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/joshilesanmi/open-search-dev/search"
+	opensearchapi "github.com/opensearch-project/opensearch-go/v2/opensearchapi"
+)
+
+// asyncSearchPath is the endpoint of the asynchronous search plugin.
+const asyncSearchPath = "/_plugins/_asynchronous_search"
+
+// AsyncSearchState is the state of an asynchronous search, as reported by the cluster.
+type AsyncSearchState string
+
+const (
+	AsyncSearchInit          AsyncSearchState = "INIT"
+	AsyncSearchRunning       AsyncSearchState = "RUNNING"
+	AsyncSearchSucceeded     AsyncSearchState = "SUCCEEDED"
+	AsyncSearchFailed        AsyncSearchState = "FAILED"
+	AsyncSearchPersisting    AsyncSearchState = "PERSISTING"
+	AsyncSearchPersisted     AsyncSearchState = "PERSISTED"
+	AsyncSearchPersistFailed AsyncSearchState = "PERSIST_FAILED"
+	AsyncSearchStoreResident AsyncSearchState = "STORE_RESIDENT"
+)
+
+// AsyncSearch is a search job running in the background on the cluster.
+type AsyncSearch struct {
+	ID      string              // Identifies the job to GetAsyncSearch and CancelAsyncSearch.
+	State   AsyncSearchState    // The state of the job.
+	Result  search.SearchResult // The result, once the job has completed; partial results may be set before.
+	Error   string              // Why the job failed, if it did.
+	Expires time.Time           // When the job and its result are discarded by the cluster.
+}
+
+// Done reports whether the job has finished, successfully or not.
+func (s AsyncSearch) Done() bool {
+	switch s.State {
+	case AsyncSearchInit, AsyncSearchRunning, AsyncSearchPersisting:
+		return false
+	}
+	return true
+}
+
+// AsyncSearchOption is a function type that applies configuration options to an AsyncSearchOptions instance.
+type AsyncSearchOption func(*AsyncSearchOptions)
+
+// AsyncSearchOptions defines configuration options for asynchronous searches.
+type AsyncSearchOptions struct {
+	KeepAlive         time.Duration // How long the job and its result are kept. Defaults to 1 hour.
+	WaitForCompletion time.Duration // How long the submission waits for fast searches to complete. Defaults to 1s.
+}
+
+// WithKeepAlive returns an AsyncSearchOption that sets how long the job and its result are kept.
+func WithKeepAlive(d time.Duration) AsyncSearchOption {
+	return func(opts *AsyncSearchOptions) {
+		opts.KeepAlive = d
+	}
+}
+
+// WithWaitForCompletion returns an AsyncSearchOption that sets how long the submission waits for the search to
+// complete before returning a running job.
+func WithWaitForCompletion(d time.Duration) AsyncSearchOption {
+	return func(opts *AsyncSearchOptions) {
+		opts.WaitForCompletion = d
+	}
+}
+
+// SubmitAsyncSearch starts a search in the background on the cluster serving searches and returns the job, for
+// heavy analytical queries that would otherwise exceed client timeouts. The search is built and restricted to
+// the instance exactly as by Search. Searches completing within the wait for completion are returned done.
+func (os *OpenSearch) SubmitAsyncSearch(ctx context.Context, instanceID string, query search.Query, opts ...AsyncSearchOption) (AsyncSearch, error) {
+	options := &AsyncSearchOptions{KeepAlive: time.Hour, WaitForCompletion: time.Second}
+	for _, opt := range opts {
+		opt(options)
+	}
+
+	os.recordQuery(instanceID)
+
+	searchQuery, err := os.constructSearchQuery(instanceID, query)
+	if err != nil {
+		return AsyncSearch{}, err
+	}
+	body, err := json.Marshal(searchQuery)
+	if err != nil {
+		return AsyncSearch{}, fmt.Errorf("failed to marshal search query: %v", err)
+	}
+	recordSentQuery(ctx, json.RawMessage(body))
+
+	path := asyncSearchPath
+	if indices := os.searchIndices(ctx, instanceID); indices != nil {
+		path = "/" + strings.Join(indices, ",") + asyncSearchPath
+	}
+
+	read, preference, err := os.readCluster(ctx, ReadSearch)
+	if err != nil {
+		return AsyncSearch{}, err
+	}
+
+	params := map[string]string{
+		"keep_alive":                  durationParam(options.KeepAlive),
+		"wait_for_completion_timeout": durationParam(options.WaitForCompletion),
+		"keep_on_completion":          "true",
+	}
+	if preference != "" {
+		params["preference"] = preference
+	}
+
+	resp, err := os.executeReadRequest(ctx, read.client, rawRequest{
+		Method: http.MethodPost,
+		Path:   path,
+		Params: params,
+		Body:   bytes.NewReader(body),
+	})
+	if err != nil {
+		return AsyncSearch{}, err
+	}
+
+	return os.decodeAsyncSearch(resp)
+}
+
+// GetAsyncSearch returns the current state and, once available, the result of an asynchronous search.
+func (os *OpenSearch) GetAsyncSearch(ctx context.Context, id string) (AsyncSearch, error) {
+	read, _, err := os.readCluster(ctx, ReadSearch)
+	if err != nil {
+		return AsyncSearch{}, err
+	}
+
+	resp, err := os.executeReadRequest(ctx, read.client, rawRequest{
+		Method: http.MethodGet,
+		Path:   asyncSearchPath + "/" + url.PathEscape(id),
+	})
+	if err != nil {
+		return AsyncSearch{}, err
+	}
+
+	return os.decodeAsyncSearch(resp)
+}
+
+// CancelAsyncSearch cancels an asynchronous search, or discards its result if it has completed.
+func (os *OpenSearch) CancelAsyncSearch(ctx context.Context, id string) error {
+	read, _, err := os.readCluster(ctx, ReadSearch)
+	if err != nil {
+		return err
+	}
+
+	return os.executeRequest(ctx, read.client, rawRequest{
+		Method: http.MethodDelete,
+		Path:   asyncSearchPath + "/" + url.PathEscape(id),
+	})
+}
+
+// asyncSearchResponse is the body of a response of the asynchronous search plugin.
+type asyncSearchResponse struct {
+	ID                     string           `json:"id"`
+	State                  AsyncSearchState `json:"state"`
+	ExpirationTimeInMillis int64            `json:"expiration_time_in_millis"`
+	Response               *searchResponse  `json:"response"`
+	Error                  json.RawMessage  `json:"error"`
+}
+
+// decodeAsyncSearch decodes a response of the asynchronous search plugin into a job.
+func (os *OpenSearch) decodeAsyncSearch(resp *opensearchapi.Response) (AsyncSearch, error) {
+	var r asyncSearchResponse
+	if err := os.decodeResponse(resp, &r); err != nil {
+		if errors.Is(err, ErrDocumentNotFound) {
+			return AsyncSearch{}, errors.New("asynchronous search not found or expired")
+		}
+		return AsyncSearch{}, err
+	}
+
+	job := AsyncSearch{
+		ID:      r.ID,
+		State:   r.State,
+		Expires: time.UnixMilli(r.ExpirationTimeInMillis),
+	}
+	if r.Response != nil {
+		job.Result = os.searchResult(*r.Response)
+	}
+	if len(r.Error) > 0 {
+		job.Error = string(r.Error)
+	}
+
+	return job, nil
+}
+
+// durationParam formats a duration as a time unit parameter.
+func durationParam(d time.Duration) string {
+	return fmt.Sprintf("%dms", d.Milliseconds())
+}
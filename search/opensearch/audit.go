@@ -0,0 +1,106 @@
+package opensearch
+
+import (
+	"encoding/json"
+	"reflect"
+
+	"github.com/joshilesanmi/open-search-dev/search"
+	"github.com/rs/zerolog"
+)
+
+// injectedMarker is the key marking injected filter clauses in audited queries.
+const injectedMarker = "_injected"
+
+// WithQueryAudit logs the final body of every search query at debug level, with the tenant isolation filter
+// injected by the engine marked by an "_injected" key, so security reviews can audit that isolation was applied
+// on every request. A query missing the filter is logged as a warning. The marker only appears in the log; the
+// query sent to the cluster is unchanged.
+func WithQueryAudit(logger zerolog.Logger) OpenSearchOption {
+	return func(os *OpenSearch) error {
+		l := logger.With().Str("search", "OpenSearch").Str("audit", "query").Logger()
+		os.queryAudit = &l
+		return nil
+	}
+}
+
+// WithQueryAuditRedactor redacts the query bodies logged by WithQueryAudit, so that the audit log does not carry
+// the values of PII fields searched for. The isolation check runs on the unredacted query.
+func WithQueryAuditRedactor(r *search.Redactor) OpenSearchOption {
+	return func(os *OpenSearch) error {
+		os.queryRedactor = r
+		return nil
+	}
+}
+
+// auditQuery logs a search query body with its injected filters marked, if query auditing is enabled.
+func (os *OpenSearch) auditQuery(instanceID string, body map[string]interface{}) {
+	if os.queryAudit == nil {
+		return
+	}
+
+	marked, isolated := markInjected(body, search.InstanceFilter(instanceID), "tenant")
+
+	event := os.queryAudit.Debug()
+	if !isolated {
+		event = os.queryAudit.Warn()
+	}
+	if !event.Enabled() {
+		return
+	}
+
+	if os.queryRedactor != nil {
+		redacted, err := os.queryRedactor.RedactQuery(marked)
+		if err != nil {
+			event.Str("instanceID", instanceID).Bool("isolated", isolated).AnErr("err", err).Msg("search query")
+			return
+		}
+		marked = redacted
+	}
+
+	b, err := json.Marshal(marked)
+	if err != nil {
+		event.Str("instanceID", instanceID).Bool("isolated", isolated).AnErr("err", err).Msg("search query")
+		return
+	}
+
+	event.
+		Str("instanceID", instanceID).
+		Bool("isolated", isolated).
+		RawJSON("query", b).
+		Msg("search query")
+}
+
+// markInjected returns a copy of a query body in which every clause equal to the injected one carries the
+// marker key with the given label, and reports whether the clause was found.
+func markInjected(v interface{}, injected map[string]interface{}, label string) (interface{}, bool) {
+	if m, ok := v.(map[string]interface{}); ok && reflect.DeepEqual(m, injected) {
+		marked := make(map[string]interface{}, len(m)+1)
+		for k, c := range m {
+			marked[k] = c
+		}
+		marked[injectedMarker] = label
+		return marked, true
+	}
+
+	found := false
+	switch t := v.(type) {
+	case map[string]interface{}:
+		c := make(map[string]interface{}, len(t))
+		for k, e := range t {
+			var ok bool
+			c[k], ok = markInjected(e, injected, label)
+			found = found || ok
+		}
+		return c, found
+	case []interface{}:
+		c := make([]interface{}, len(t))
+		for i, e := range t {
+			var ok bool
+			c[i], ok = markInjected(e, injected, label)
+			found = found || ok
+		}
+		return c, found
+	default:
+		return v, false
+	}
+}
@@ -0,0 +1,98 @@
+package opensearch
+
+import (
+	"context"
+	"errors"
+	"net/http"
+)
+
+// Credentials are the username and password of a cluster user.
+type Credentials struct {
+	Username string
+	Password string
+}
+
+// CredentialsProvider returns the current credentials of the cluster user. It is called for every request, so
+// rotated passwords are picked up without recreating the engine; providers reading from a secret store should
+// cache the credentials.
+type CredentialsProvider func(ctx context.Context) (Credentials, error)
+
+// WithBasicAuth authenticates every request to the clusters with HTTP basic authentication, as required by
+// clusters running the security plugin. Only one authentication option takes effect; the last one given wins.
+// It cannot be combined with WithRequestSigner.
+func WithBasicAuth(username, password string) OpenSearchOption {
+	return WithCredentialsProvider(func(context.Context) (Credentials, error) {
+		return Credentials{Username: username, Password: password}, nil
+	})
+}
+
+// WithCredentialsProvider authenticates every request to the clusters with HTTP basic authentication, using the
+// credentials returned by the provider at the time of the request.
+func WithCredentialsProvider(provider CredentialsProvider) OpenSearchOption {
+	return func(os *OpenSearch) error {
+		if provider == nil {
+			return errors.New("credentials provider must not be nil")
+		}
+		os.authorize = func(req *http.Request) error {
+			creds, err := provider(req.Context())
+			if err != nil {
+				return err
+			}
+			req.SetBasicAuth(creds.Username, creds.Password)
+			return nil
+		}
+		return nil
+	}
+}
+
+// TokenProvider returns the current token authenticating requests, e.g. an OIDC access token. It is called for
+// every request, so it should cache the token and only refresh it shortly before it expires.
+type TokenProvider func(ctx context.Context) (string, error)
+
+// WithBearerToken authenticates every request to the clusters with the token returned by the provider, sent as
+// a bearer token, as expected by clusters fronted by an OIDC proxy.
+func WithBearerToken(provider TokenProvider) OpenSearchOption {
+	return withTokenAuth("Bearer", provider)
+}
+
+// WithAPIKey authenticates every request to the clusters with the API key returned by the provider, sent with
+// the ApiKey scheme. The key is sent as returned, typically the base64 encoding of "id:key".
+func WithAPIKey(provider TokenProvider) OpenSearchOption {
+	return withTokenAuth("ApiKey", provider)
+}
+
+// withTokenAuth authenticates every request with a token sent in the Authorization header with the scheme.
+func withTokenAuth(scheme string, provider TokenProvider) OpenSearchOption {
+	return func(os *OpenSearch) error {
+		if provider == nil {
+			return errors.New("token provider must not be nil")
+		}
+		os.authorize = func(req *http.Request) error {
+			token, err := provider(req.Context())
+			if err != nil {
+				return err
+			}
+			req.Header.Set("Authorization", scheme+" "+token)
+			return nil
+		}
+		return nil
+	}
+}
+
+// authTransport adds authentication to every request before sending it through the next transport.
+type authTransport struct {
+	authorize func(*http.Request) error
+	next      http.RoundTripper
+}
+
+func (t *authTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	// A RoundTripper must not modify the request it was given.
+	req = req.Clone(req.Context())
+	if err := t.authorize(req); err != nil {
+		if req.Body != nil {
+			req.Body.Close()
+		}
+		return nil, err
+	}
+	return t.next.RoundTrip(req)
+}
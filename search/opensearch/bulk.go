@@ -0,0 +1,356 @@
+package opensearch
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/joshilesanmi/open-search-dev/search"
+	opensearch "github.com/opensearch-project/opensearch-go/v2"
+	opensearchapi "github.com/opensearch-project/opensearch-go/v2/opensearchapi"
+)
+
+// BulkRetryConfig configures how items of a bulk request rejected for transient reasons are retried.
+type BulkRetryConfig struct {
+	MaxRetries int           // Maximum number of retries of an item. Zero disables retries.
+	Backoff    time.Duration // Delay before the first retry, doubled for every following retry.
+	MaxBackoff time.Duration // Upper bound of the delay between retries.
+}
+
+// defaultBulkRetry is used unless WithBulkRetry is given.
+var defaultBulkRetry = BulkRetryConfig{
+	MaxRetries: 3,
+	Backoff:    100 * time.Millisecond,
+	MaxBackoff: 5 * time.Second,
+}
+
+// WithBulkRetry configures the retries of bulk items rejected for transient reasons, such as a full write queue
+// (429) or a timeout. By default items are retried 3 times, starting with a 100ms backoff.
+func WithBulkRetry(config BulkRetryConfig) OpenSearchOption {
+	return func(os *OpenSearch) error {
+		if config.MaxRetries < 0 || config.Backoff < 0 {
+			return errors.New("bulk retries and backoff must not be negative")
+		}
+		if config.MaxBackoff < config.Backoff {
+			config.MaxBackoff = config.Backoff
+		}
+		os.bulkRetry = &config
+		return nil
+	}
+}
+
+// bulkRetryConfig returns the configured bulk retry behaviour.
+func (os *OpenSearch) bulkRetryConfig() BulkRetryConfig {
+	if os.bulkRetry == nil {
+		return defaultBulkRetry
+	}
+	return *os.bulkRetry
+}
+
+// bulkItem is a single action of a bulk request.
+type bulkItem struct {
+	key    search.DocumentKey
+	action string // "index" or "delete".
+	id     string
+	source []byte // The document, for index actions.
+}
+
+// BulkPutDocuments adds or updates many documents of an index in a single bulk request per cluster, with the
+// same metadata and enrichment as PutDocument. Documents rejected individually are listed in the report instead
+// of failing the call; the error is only set if the request as a whole failed. Like PutDocument, the secondary
+// cluster only receives the documents written to the primary.
+func (os *OpenSearch) BulkPutDocuments(ctx context.Context, indexName string, documents []search.SourceDocument, opts ...search.IndexOption) (search.BulkReport, error) {
+	var report search.BulkReport
+
+	if err := os.checkWritable(indexName); err != nil {
+		return report, err
+	}
+
+	options := &search.IndexOptions{Refresh: false}
+	for _, opt := range opts {
+		opt(options)
+	}
+
+	items := make([]bulkItem, 0, len(documents))
+	for _, src := range documents {
+		key := search.DocumentKey{InstanceID: src.InstanceID, EntityName: src.EntityName, EntityID: src.EntityID}
+
+		source, err := os.prepareDocument(ctx, src)
+		if err != nil {
+			report.Failed = append(report.Failed, search.ItemFailure{DocumentKey: key, Reason: err.Error()})
+			continue
+		}
+
+		items = append(items, bulkItem{
+			key:    key,
+			action: "index",
+			id:     search.GenerateDocumentID(src.InstanceID, src.EntityName, src.EntityID),
+			source: source,
+		})
+	}
+
+	return os.bulk(ctx, indexName, items, strconv.FormatBool(options.Refresh), report)
+}
+
+// BulkDeleteDocuments removes many documents of an index in a single bulk request per cluster. Documents that
+// do not exist count as deleted. Failures are reported as for BulkPutDocuments.
+func (os *OpenSearch) BulkDeleteDocuments(ctx context.Context, indexName string, keys []search.DocumentKey) (search.BulkReport, error) {
+	var report search.BulkReport
+
+	if err := os.checkWritable(indexName); err != nil {
+		return report, err
+	}
+
+	items := make([]bulkItem, 0, len(keys))
+	for _, key := range keys {
+		items = append(items, bulkItem{
+			key:    key,
+			action: "delete",
+			id:     search.GenerateDocumentID(key.InstanceID, key.EntityName, key.EntityID),
+		})
+	}
+
+	return os.bulk(ctx, indexName, items, "false", report)
+}
+
+// prepareDocument adds the metadata and enrichment of PutDocument to a document and marshals it.
+func (os *OpenSearch) prepareDocument(ctx context.Context, src search.SourceDocument) ([]byte, error) {
+	d := make(search.Document, len(src.Document)+3)
+	for k, v := range src.Document {
+		d[k] = v
+	}
+
+	d, err := d.AddDocumentMetaData(src.InstanceID, src.EntityName, src.EntityID)
+	if err != nil {
+		return nil, fmt.Errorf("missing document meta data %v", err)
+	}
+
+	if os.embedding != nil {
+		if err := os.embed(ctx, d); err != nil {
+			return nil, err
+		}
+	}
+
+	b, err := json.Marshal(d)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal document %v", err)
+	}
+
+	return b, nil
+}
+
+// bulk sends the items to every configured cluster in turn. Items failing on a cluster are added to the report
+// and not sent to the following clusters. A failed request to the secondary may be suppressed by the WritePolicy,
+// in which case the items written to the primary are reported as succeeded.
+func (os *OpenSearch) bulk(ctx context.Context, indexName string, items []bulkItem, refresh string, report search.BulkReport) (search.BulkReport, error) {
+	clusters, err := os.targetClusters(ctx)
+	if err != nil {
+		return report, err
+	}
+	replicated := len(clusters) > 1
+
+	for _, c := range clusters {
+		if len(items) == 0 {
+			break
+		}
+		secondary := c.role != PrimaryCluster
+		queue := replicated && secondary && os.writeBehind != nil
+		if replicated && secondary && c.policy.skip(c.name, os.clock.Now()) {
+			os.replication.skipped.Add(1)
+			if queue {
+				os.enqueueItems(ctx, c, indexName, items)
+			}
+			continue
+		}
+
+		var written []bulkItem
+		var failed []search.ItemFailure
+		var retried int
+		var err error
+		if secondary {
+			err = os.writeSecondary(func() (int, error) {
+				var err error
+				written, failed, retried, err = os.bulkWithRetry(ctx, c.client, indexName, items, refresh)
+				return len(failed), err
+			})
+		} else {
+			written, failed, retried, err = os.bulkWithRetry(ctx, c.client, indexName, items, refresh)
+		}
+		report.Retried += retried
+		if err != nil {
+			if queue && os.enqueueItems(ctx, c, indexName, items) {
+				continue
+			}
+			if replicated && secondary && c.policy.suppress(c.name, err, os.clock.Now()) {
+				continue
+			}
+			return report, &ClusterError{Cluster: c.role, Name: c.name, Err: err}
+		}
+		if secondary {
+			os.replayInBackground()
+		}
+
+		for _, failure := range failed {
+			failure.Cluster = c.name
+			report.Failed = append(report.Failed, failure)
+		}
+		items = written
+	}
+
+	report.Succeeded = len(items)
+	return report, nil
+}
+
+// bulkWithRetry sends the items to a cluster, retrying items rejected for transient reasons such as a full
+// write queue or a timeout with exponential backoff. It returns the written items, the items that failed
+// permanently or ran out of retries, and the number of retried items.
+func (os *OpenSearch) bulkWithRetry(ctx context.Context, client *opensearch.Client, indexName string, items []bulkItem, refresh string) ([]bulkItem, []search.ItemFailure, int, error) {
+	config := os.bulkRetryConfig()
+
+	var written []bulkItem
+	var failed []search.ItemFailure
+	retried := 0
+	backoff := config.Backoff
+
+	for attempt := 0; ; attempt++ {
+		failures, err := os.bulkRequest(ctx, client, indexName, items, refresh)
+		if err != nil {
+			// A throttled request is retried as a whole, after the delay asked for by the cluster if it is longer
+			// than the backoff.
+			var apiErr *APIError
+			if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusTooManyRequests || attempt >= config.MaxRetries {
+				return nil, nil, retried, err
+			}
+			wait := backoff
+			if apiErr.RetryAfter > wait {
+				wait = apiErr.RetryAfter
+			}
+			select {
+			case <-ctx.Done():
+				return nil, nil, retried, err
+			case <-search.After(os.clock, wait):
+			}
+			retried += len(items)
+			if backoff *= 2; backoff > config.MaxBackoff {
+				backoff = config.MaxBackoff
+			}
+			continue
+		}
+
+		var retry []bulkItem
+		var retryFailures []search.ItemFailure
+		for i, item := range items {
+			failure, ok := failures[i]
+			if !ok {
+				written = append(written, item)
+				continue
+			}
+			failure.DocumentKey = item.key
+			if retryableItem(failure) && attempt < config.MaxRetries {
+				retry = append(retry, item)
+				retryFailures = append(retryFailures, failure)
+				continue
+			}
+			failed = append(failed, failure)
+		}
+
+		if len(retry) == 0 {
+			return written, failed, retried, nil
+		}
+
+		select {
+		case <-ctx.Done():
+			// Report the items that were waiting for a retry with their last failure.
+			return written, append(failed, retryFailures...), retried, nil
+		case <-search.After(os.clock, backoff):
+		}
+
+		retried += len(retry)
+		items = retry
+		if backoff *= 2; backoff > config.MaxBackoff {
+			backoff = config.MaxBackoff
+		}
+	}
+}
+
+// retryableItem reports whether a bulk item failed for a transient reason, so that retrying it may succeed.
+func retryableItem(failure search.ItemFailure) bool {
+	switch failure.Status {
+	case http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+
+	for _, prefix := range []string{"es_rejected_execution_exception", "timeout_exception", "process_cluster_event_timeout_exception"} {
+		if strings.HasPrefix(failure.Reason, prefix+":") {
+			return true
+		}
+	}
+
+	return false
+}
+
+// bulkRequest executes a bulk request and returns the failed items keyed by their position in the request.
+func (os *OpenSearch) bulkRequest(ctx context.Context, client *opensearch.Client, indexName string, items []bulkItem, refresh string) (map[int]search.ItemFailure, error) {
+	var body bytes.Buffer
+	for _, item := range items {
+		meta, err := json.Marshal(map[string]interface{}{
+			item.action: map[string]string{"_id": item.id},
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal bulk action %v", err)
+		}
+		body.Write(meta)
+		body.WriteByte('\n')
+		if item.source != nil {
+			body.Write(item.source)
+			body.WriteByte('\n')
+		}
+	}
+
+	resp, err := os.executeReadRequest(ctx, client, opensearchapi.BulkRequest{
+		Index:   indexName,
+		Body:    &body,
+		Refresh: refresh,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var r struct {
+		Items []map[string]struct {
+			Status int `json:"status"`
+			Error  *struct {
+				Type   string `json:"type"`
+				Reason string `json:"reason"`
+			} `json:"error"`
+		} `json:"items"`
+	}
+	if err := os.decodeResponse(resp, &r); err != nil {
+		return nil, err
+	}
+	if len(r.Items) != len(items) {
+		return nil, fmt.Errorf("bulk response has %d items for %d actions", len(r.Items), len(items))
+	}
+
+	failures := make(map[int]search.ItemFailure)
+	for i, result := range r.Items {
+		for action, item := range result {
+			// Deleting a missing document reports not_found, which leaves the desired state in place.
+			if item.Error == nil || (action == "delete" && item.Status == 404) {
+				continue
+			}
+			failures[i] = search.ItemFailure{
+				Status: item.Status,
+				Reason: item.Error.Type + ": " + item.Error.Reason,
+			}
+		}
+	}
+
+	return failures, nil
+}
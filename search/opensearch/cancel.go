@@ -0,0 +1,77 @@
+package opensearch
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/joshilesanmi/open-search-dev/search"
+	opensearch "github.com/opensearch-project/opensearch-go/v2"
+	opensearchapi "github.com/opensearch-project/opensearch-go/v2/opensearchapi"
+)
+
+// opaqueIDHeader tags requests so the cluster tasks they start can be found again.
+const opaqueIDHeader = "X-Opaque-Id"
+
+// taskCancelTimeout bounds the time spent cancelling the tasks of an abandoned request.
+const taskCancelTimeout = 5 * time.Second
+
+// cancellable prepares a long-running request so that cancelling ctx also cancels the tasks it started on the
+// cluster. Closing the HTTP connection alone does not stop every kind of task, so an abandoned expensive query
+// could otherwise keep burning cluster CPU. The returned header must be set on the request, and done must be
+// called once the response has been read.
+func (os *OpenSearch) cancellable(ctx context.Context, client *opensearch.Client) (http.Header, func()) {
+	id := os.ids.NewID()
+	// Prefixing the request ID keeps the request correlatable in the slow logs of the cluster.
+	if requestID := search.RequestID(ctx); requestID != "" {
+		id = requestID + "/" + id
+	}
+	header := http.Header{}
+	header.Set(opaqueIDHeader, id)
+
+	finished := make(chan struct{})
+	go func() {
+		select {
+		case <-finished:
+		case <-ctx.Done():
+			os.cancelTasks(client, id)
+		}
+	}()
+
+	return header, func() { close(finished) }
+}
+
+// cancelTasks cancels every cancellable task tagged with the opaque ID. It is best effort: the task may
+// already have completed, and failures are not reported to the caller whose context has gone.
+func (os *OpenSearch) cancelTasks(client *opensearch.Client, opaqueID string) {
+	ctx, cancel := context.WithTimeout(context.Background(), taskCancelTimeout)
+	defer cancel()
+
+	detailed := true
+	resp, err := os.executeReadRequest(ctx, client, opensearchapi.TasksListRequest{Detailed: &detailed})
+	if err != nil {
+		return
+	}
+
+	var r struct {
+		Nodes map[string]struct {
+			Tasks map[string]struct {
+				Cancellable bool              `json:"cancellable"`
+				Headers     map[string]string `json:"headers"`
+			} `json:"tasks"`
+		} `json:"nodes"`
+	}
+	if err := os.decodeResponse(resp, &r); err != nil {
+		return
+	}
+
+	for _, node := range r.Nodes {
+		for taskID, task := range node.Tasks {
+			if !task.Cancellable || task.Headers[opaqueIDHeader] != opaqueID {
+				continue
+			}
+			req := opensearchapi.TasksCancelRequest{TaskID: taskID}
+			_ = os.executeRequest(ctx, client, &req)
+		}
+	}
+}
@@ -0,0 +1,179 @@
+package opensearch
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/joshilesanmi/open-search-dev/search"
+	"github.com/rs/zerolog"
+)
+
+// cancelTransport stubs a cluster whose searches never complete. It lists the search as a cancellable task
+// tagged with the opaque ID of the search request, next to a task of another request, and records the tasks
+// cancelled.
+type cancelTransport struct {
+	mu        sync.Mutex
+	opaqueID  string
+	cancelled []string
+
+	searching chan struct{} // Closed once the search request is received.
+	once      sync.Once
+	cancels   chan string // Receives the task ID of every cancel request.
+}
+
+func newCancelTransport() *cancelTransport {
+	return &cancelTransport{searching: make(chan struct{}), cancels: make(chan string, 10)}
+}
+
+func (t *cancelTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	switch {
+	case strings.HasSuffix(req.URL.Path, "/_search"):
+		t.mu.Lock()
+		t.opaqueID = req.Header.Get(opaqueIDHeader)
+		t.mu.Unlock()
+		t.once.Do(func() { close(t.searching) })
+
+		<-req.Context().Done()
+		return nil, req.Context().Err()
+
+	case req.Method == http.MethodGet && req.URL.Path == "/_tasks":
+		t.mu.Lock()
+		opaqueID := t.opaqueID
+		t.mu.Unlock()
+		return stubResponse(http.StatusOK, fmt.Sprintf(`{"nodes": {"n1": {"tasks": {
+			"n1:42": {"cancellable": true, "headers": {%q: %q}},
+			"n1:43": {"cancellable": true, "headers": {%q: "other"}}
+		}}}}`, opaqueIDHeader, opaqueID, opaqueIDHeader)), nil
+
+	case req.Method == http.MethodPost && strings.HasPrefix(req.URL.Path, "/_tasks/") &&
+		strings.HasSuffix(req.URL.Path, "/_cancel"):
+		taskID := strings.TrimSuffix(strings.TrimPrefix(req.URL.Path, "/_tasks/"), "/_cancel")
+		t.mu.Lock()
+		t.cancelled = append(t.cancelled, taskID)
+		t.mu.Unlock()
+		t.cancels <- taskID
+		return stubResponse(http.StatusOK, `{"nodes": {}}`), nil
+	}
+
+	return stubResponse(http.StatusNotFound, `{}`), nil
+}
+
+func stubResponse(status int, body string) *http.Response {
+	return &http.Response{
+		StatusCode: status,
+		Header:     http.Header{"Content-Type": []string{"application/json"}},
+		Body:       io.NopCloser(strings.NewReader(body)),
+	}
+}
+
+// waitForCancel returns the task ID of the first cancel request, failing the test if none is sent.
+func (t *cancelTransport) waitForCancel(tb testing.TB) string {
+	tb.Helper()
+	select {
+	case taskID := <-t.cancels:
+		return taskID
+	case <-time.After(taskCancelTimeout):
+		tb.Fatal("the search task was not cancelled")
+		return ""
+	}
+}
+
+func TestSearchCancelsTasks(t *testing.T) {
+	tests := []struct {
+		name   string
+		ctx    func() (context.Context, context.CancelFunc)
+		cancel func(t *cancelTransport, cancel context.CancelFunc) // Ends the search once it is received.
+		err    error
+	}{
+		{
+			name: "context cancelled",
+			ctx: func() (context.Context, context.CancelFunc) {
+				return context.WithCancel(context.Background())
+			},
+			cancel: func(t *cancelTransport, cancel context.CancelFunc) {
+				<-t.searching
+				cancel()
+			},
+			err: context.Canceled,
+		},
+		{
+			name: "deadline exceeded",
+			ctx: func() (context.Context, context.CancelFunc) {
+				return context.WithTimeout(context.Background(), 50*time.Millisecond)
+			},
+			cancel: func(*cancelTransport, context.CancelFunc) {},
+			err:    context.DeadlineExceeded,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			transport := newCancelTransport()
+			engine, err := NewOpenSearch("http://localhost:9200", zerolog.Nop(), WithTransport(transport))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			ctx, cancel := tt.ctx()
+			defer cancel()
+			go tt.cancel(transport, cancel)
+
+			_, err = engine.Search(search.WithRequestID(ctx, "req-1"), "instance", search.Query{Value: "foo"})
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("Search() error = %v, want %v", err, tt.err)
+			}
+
+			if taskID := transport.waitForCancel(t); taskID != "n1:42" {
+				t.Errorf("cancelled task %q, want n1:42", taskID)
+			}
+
+			transport.mu.Lock()
+			defer transport.mu.Unlock()
+			if !strings.HasPrefix(transport.opaqueID, "req-1/") {
+				t.Errorf("opaque ID %q is not prefixed by the request ID", transport.opaqueID)
+			}
+			if len(transport.cancelled) != 1 {
+				t.Errorf("cancelled tasks %v, want only the task of the search", transport.cancelled)
+			}
+		})
+	}
+}
+
+func TestSearchDoesNotCancelCompletedTasks(t *testing.T) {
+	transport := newCancelTransport()
+	engine, err := NewOpenSearch("http://localhost:9200", zerolog.Nop(), WithTransport(roundTripFunc(
+		func(req *http.Request) (*http.Response, error) {
+			if strings.HasSuffix(req.URL.Path, "/_search") {
+				return stubResponse(http.StatusOK, `{"took": 1, "hits": {"total": {"value": 0, "relation": "eq"}, "hits": []}}`), nil
+			}
+			return transport.RoundTrip(req)
+		})))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	if _, err := engine.Search(ctx, "instance", search.Query{Value: "foo"}); err != nil {
+		t.Fatal(err)
+	}
+	cancel()
+
+	select {
+	case taskID := <-transport.cancels:
+		t.Errorf("cancelled task %q of a completed search", taskID)
+	case <-time.After(100 * time.Millisecond):
+	}
+}
+
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
@@ -0,0 +1,138 @@
+package opensearch
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	opensearch "github.com/opensearch-project/opensearch-go/v2"
+	opensearchapi "github.com/opensearch-project/opensearch-go/v2/opensearchapi"
+)
+
+// CascadeAction is what happens to the children of a deleted parent document.
+type CascadeAction int
+
+const (
+	CascadeDelete   CascadeAction = iota // Delete the children.
+	CascadeReparent                      // Point the children at another parent, or clear their parent field.
+)
+
+// Relationship describes child entities referring to a parent entity, e.g. persons referring to their company
+// through a "company_id" field holding the entity ID of the company.
+type Relationship struct {
+	Parent     string        // The entity name of the parent, e.g. "company".
+	Child      string        // The entity name of the children, e.g. "person".
+	Field      string        // The field of the children holding the entity ID of their parent.
+	Action     CascadeAction // What happens to the children when the parent is deleted.
+	ReparentTo string        // The parent entity ID assigned by CascadeReparent. Empty clears the field.
+}
+
+// WithRelationships configures parent-child relationships between entities. Deleting a parent with
+// DeleteDocument then deletes or re-parents its children with a delete-by-query or update-by-query, keeping the
+// index free of orphaned documents. Deleting children is a destructive operation evaluated by the policies
+// configured with WithDestructivePolicy as OperationDeleteByQuery; DeleteDocument fails before deleting the parent
+// if they do not allow it. Cascades are not transitive: children of deleted children are left as is,
+// unless they refer to the deleted parent themselves.
+func WithRelationships(relationships ...Relationship) OpenSearchOption {
+	return func(os *OpenSearch) error {
+		for _, r := range relationships {
+			if r.Parent == "" || r.Child == "" || r.Field == "" {
+				return errors.New("relationship requires parent, child and field")
+			}
+		}
+		os.relationships = append(os.relationships, relationships...)
+		return nil
+	}
+}
+
+// authorizeCascade evaluates the destructive operation policies for the delete-by-query of the children of a
+// deleted parent, before the parent is deleted.
+func (os *OpenSearch) authorizeCascade(ctx context.Context, indexName, entityName string) error {
+	for _, r := range os.relationships {
+		if r.Parent == entityName && r.Action == CascadeDelete {
+			return os.authorizeDestructive(ctx, OperationDeleteByQuery, indexName, false)
+		}
+	}
+	return nil
+}
+
+// cascade applies the configured relationships of a deleted parent to its children on every configured cluster.
+func (os *OpenSearch) cascade(ctx context.Context, instanceID, indexName, entityName, entityID string) error {
+	for _, r := range os.relationships {
+		if r.Parent != entityName {
+			continue
+		}
+
+		query := map[string]interface{}{
+			"bool": map[string]interface{}{
+				"filter": []interface{}{
+					map[string]interface{}{"term": map[string]string{"instance_id": instanceID}},
+					map[string]interface{}{"term": map[string]string{"entity_name": r.Child}},
+					map[string]interface{}{"term": map[string]string{r.Field: entityID}},
+				},
+			},
+		}
+
+		var body []byte
+		var err error
+		switch r.Action {
+		case CascadeReparent:
+			var parent interface{}
+			if r.ReparentTo != "" {
+				parent = r.ReparentTo
+			}
+			body, err = json.Marshal(map[string]interface{}{
+				"query": query,
+				"script": map[string]interface{}{
+					"lang":   "painless",
+					"source": "ctx._source[params.field] = params.parent",
+					"params": map[string]interface{}{"field": r.Field, "parent": parent},
+				},
+			})
+		default:
+			body, err = json.Marshal(map[string]interface{}{"query": query})
+		}
+		if err != nil {
+			return fmt.Errorf("failed to marshal cascade query %v", err)
+		}
+
+		err = os.write(ctx, func(client *opensearch.Client) error {
+			if r.Action == CascadeReparent {
+				_, err := os.updateByQuery(ctx, client, indexName, body)
+				return err
+			}
+			return os.deleteByQuery(ctx, client, indexName, body)
+		})
+		if err != nil {
+			return fmt.Errorf("cascade to %s children: %w", r.Child, err)
+		}
+	}
+
+	return nil
+}
+
+// deleteByQuery deletes the documents of an index matching a query.
+func (os *OpenSearch) deleteByQuery(ctx context.Context, client *opensearch.Client, indexName string, body []byte) error {
+	resp, err := os.executeReadRequest(ctx, client, opensearchapi.DeleteByQueryRequest{
+		Index:     []string{indexName},
+		Body:      bytes.NewReader(body),
+		Conflicts: "proceed",
+	})
+	if err != nil {
+		return err
+	}
+
+	var r struct {
+		Failures []json.RawMessage `json:"failures"`
+	}
+	if err := os.decodeResponse(resp, &r); err != nil {
+		return err
+	}
+	if len(r.Failures) > 0 {
+		return fmt.Errorf("%d documents failed to delete, first: %s", len(r.Failures), r.Failures[0])
+	}
+
+	return nil
+}
@@ -0,0 +1,252 @@
+package opensearch
+
+import (
+	"context"
+	"sort"
+	"strconv"
+
+	opensearch "github.com/opensearch-project/opensearch-go/v2"
+	opensearchapi "github.com/opensearch-project/opensearch-go/v2/opensearchapi"
+)
+
+// HealthStatus is the health status reported by an OpenSearch cluster.
+type HealthStatus string
+
+const (
+	HealthGreen  HealthStatus = "green"  // All primary and replica shards are allocated.
+	HealthYellow HealthStatus = "yellow" // All primary shards are allocated, but some replicas are not.
+	HealthRed    HealthStatus = "red"    // At least one primary shard is not allocated.
+)
+
+// ClusterHealth describes the health of a single OpenSearch cluster.
+type ClusterHealth struct {
+	Cluster              string       `json:"-"` // The role of the cluster, e.g. PrimaryCluster.
+	Name                 string       `json:"-"` // The configured label of the cluster.
+	ClusterName          string       `json:"cluster_name"`
+	Status               HealthStatus `json:"status"`
+	TimedOut             bool         `json:"timed_out"`
+	NumberOfNodes        int          `json:"number_of_nodes"`
+	NumberOfDataNodes    int          `json:"number_of_data_nodes"`
+	ActivePrimaryShards  int          `json:"active_primary_shards"`
+	ActiveShards         int          `json:"active_shards"`
+	RelocatingShards     int          `json:"relocating_shards"`
+	InitializingShards   int          `json:"initializing_shards"`
+	UnassignedShards     int          `json:"unassigned_shards"`
+	NumberOfPendingTasks int          `json:"number_of_pending_tasks"`
+}
+
+// NodeInfo describes a single node of an OpenSearch cluster.
+type NodeInfo struct {
+	ID      string   `json:"-"`
+	Name    string   `json:"name"`
+	Host    string   `json:"host"`
+	IP      string   `json:"ip"`
+	Version string   `json:"version"`
+	Roles   []string `json:"roles"`
+}
+
+// NodesInfo lists the nodes of a single OpenSearch cluster.
+type NodesInfo struct {
+	Cluster string     // The role of the cluster, e.g. PrimaryCluster.
+	Name    string     // The configured label of the cluster.
+	Nodes   []NodeInfo // The nodes of the cluster, ordered by name.
+}
+
+// NodeDiskUsage is the disk usage of a single data node, as reported by the allocation API.
+type NodeDiskUsage struct {
+	Node           string  `json:"node"`
+	Host           string  `json:"host"`
+	Shards         int     `json:"shards"`          // Number of shards allocated to the node.
+	UsedBytes      int64   `json:"used_bytes"`      // Disk used on the node, by indices and anything else.
+	AvailableBytes int64   `json:"available_bytes"` // Disk available on the node.
+	TotalBytes     int64   `json:"total_bytes"`     // Disk size of the node.
+	Percent        float64 `json:"percent"`         // Share of the disk that is used.
+}
+
+// DiskUsage lists the disk usage of the data nodes of a single OpenSearch cluster.
+type DiskUsage struct {
+	Cluster string          // The role of the cluster, e.g. PrimaryCluster.
+	Name    string          // The configured label of the cluster.
+	Nodes   []NodeDiskUsage // The data nodes of the cluster, ordered by name.
+}
+
+// cluster pairs an OpenSearch client with its role and the label used to identify it in errors and reports.
+type cluster struct {
+	role   string // PrimaryCluster, SecondaryCluster or ReplicaCluster.
+	name   string // The configured label, e.g. "eu-prod".
+	client *opensearch.Client
+	policy *dualWrite // Handles failed writes to a secondary or replica cluster; strict if nil.
+}
+
+// clusters returns the configured clusters: the primary, the secondary and the replicas, in that order.
+func (os *OpenSearch) clusters() []cluster {
+	t := os.topology.Load()
+	c := []cluster{t.primary}
+	if t.secondary != nil {
+		c = append(c, *t.secondary)
+	}
+	return append(c, t.replicas...)
+}
+
+// eachCluster runs fn against every configured cluster, or those targeted by the context, primary first, and
+// stops at the first failure.
+func (os *OpenSearch) eachCluster(ctx context.Context, fn func(client *opensearch.Client) error) error {
+	clusters, err := os.targetClusters(ctx)
+	if err != nil {
+		return err
+	}
+	for _, c := range clusters {
+		if err := fn(c.client); err != nil {
+			return &ClusterError{Cluster: c.role, Name: c.name, Err: err}
+		}
+	}
+
+	return nil
+}
+
+// Ping checks that the primary and, if configured, the secondary cluster are reachable.
+func (os *OpenSearch) Ping(ctx context.Context) error {
+	return os.eachCluster(ctx, func(client *opensearch.Client) error {
+		req := opensearchapi.PingRequest{}
+		return os.executeRequest(ctx, client, &req)
+	})
+}
+
+// ClusterHealth returns the health of the primary and, if configured, the secondary cluster, primary first.
+func (os *OpenSearch) ClusterHealth(ctx context.Context) ([]ClusterHealth, error) {
+	var health []ClusterHealth
+	for _, c := range os.clusters() {
+		h, err := os.clusterHealth(ctx, c.client)
+		if err != nil {
+			return nil, &ClusterError{Cluster: c.role, Name: c.name, Err: err}
+		}
+		h.Cluster = c.role
+		h.Name = c.name
+		health = append(health, h)
+	}
+
+	return health, nil
+}
+
+// NodesInfo returns the nodes of the primary and, if configured, the secondary cluster, primary first.
+func (os *OpenSearch) NodesInfo(ctx context.Context) ([]NodesInfo, error) {
+	var info []NodesInfo
+	for _, c := range os.clusters() {
+		nodes, err := os.nodesInfo(ctx, c.client)
+		if err != nil {
+			return nil, &ClusterError{Cluster: c.role, Name: c.name, Err: err}
+		}
+		info = append(info, NodesInfo{Cluster: c.role, Name: c.name, Nodes: nodes})
+	}
+
+	return info, nil
+}
+
+// DiskUsage returns the disk usage of the data nodes of the primary and, if configured, the secondary cluster,
+// primary first.
+func (os *OpenSearch) DiskUsage(ctx context.Context) ([]DiskUsage, error) {
+	var usage []DiskUsage
+	for _, c := range os.clusters() {
+		nodes, err := os.diskUsage(ctx, c.client)
+		if err != nil {
+			return nil, &ClusterError{Cluster: c.role, Name: c.name, Err: err}
+		}
+		usage = append(usage, DiskUsage{Cluster: c.role, Name: c.name, Nodes: nodes})
+	}
+
+	return usage, nil
+}
+
+// clusterHealth requests the cluster health using the provided OpenSearch client.
+func (os *OpenSearch) clusterHealth(ctx context.Context, client *opensearch.Client) (ClusterHealth, error) {
+	req := opensearchapi.ClusterHealthRequest{}
+
+	resp, err := os.executeReadRequest(ctx, client, req)
+	if err != nil {
+		return ClusterHealth{}, err
+	}
+
+	var h ClusterHealth
+	if err := os.decodeResponse(resp, &h); err != nil {
+		return ClusterHealth{}, err
+	}
+
+	return h, nil
+}
+
+// nodesInfo requests information about every node of the cluster using the provided OpenSearch client.
+func (os *OpenSearch) nodesInfo(ctx context.Context, client *opensearch.Client) ([]NodeInfo, error) {
+	req := opensearchapi.NodesInfoRequest{}
+
+	resp, err := os.executeReadRequest(ctx, client, req)
+	if err != nil {
+		return nil, err
+	}
+
+	var r struct {
+		Nodes map[string]NodeInfo `json:"nodes"`
+	}
+	if err := os.decodeResponse(resp, &r); err != nil {
+		return nil, err
+	}
+
+	nodes := make([]NodeInfo, 0, len(r.Nodes))
+	for id, n := range r.Nodes {
+		n.ID = id
+		nodes = append(nodes, n)
+	}
+
+	sort.Slice(nodes, func(i, j int) bool {
+		return nodes[i].Name < nodes[j].Name
+	})
+
+	return nodes, nil
+}
+
+// diskUsage requests the disk usage of every data node of the cluster using the provided OpenSearch client.
+func (os *OpenSearch) diskUsage(ctx context.Context, client *opensearch.Client) ([]NodeDiskUsage, error) {
+	req := opensearchapi.CatAllocationRequest{Format: "json", Bytes: "b"}
+
+	resp, err := os.executeReadRequest(ctx, client, req)
+	if err != nil {
+		return nil, err
+	}
+
+	// The cat API reports every value as a string.
+	var rows []struct {
+		Node        string `json:"node"`
+		Host        string `json:"host"`
+		Shards      string `json:"shards"`
+		DiskUsed    string `json:"disk.used"`
+		DiskAvail   string `json:"disk.avail"`
+		DiskTotal   string `json:"disk.total"`
+		DiskPercent string `json:"disk.percent"`
+	}
+	if err := os.decodeResponse(resp, &rows); err != nil {
+		return nil, err
+	}
+
+	nodes := make([]NodeDiskUsage, 0, len(rows))
+	for _, r := range rows {
+		// Unassigned shards are reported as a pseudo-node without disk usage.
+		percent, err := strconv.ParseFloat(r.DiskPercent, 64)
+		if err != nil {
+			continue
+		}
+		nodes = append(nodes, NodeDiskUsage{
+			Node:           r.Node,
+			Host:           r.Host,
+			Shards:         int(parseCount(r.Shards)),
+			UsedBytes:      parseCount(r.DiskUsed),
+			AvailableBytes: parseCount(r.DiskAvail),
+			TotalBytes:     parseCount(r.DiskTotal),
+			Percent:        percent,
+		})
+	}
+
+	sort.Slice(nodes, func(i, j int) bool {
+		return nodes[i].Node < nodes[j].Node
+	})
+
+	return nodes, nil
+}
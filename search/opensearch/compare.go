@@ -0,0 +1,197 @@
+package opensearch
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"sort"
+	"strconv"
+
+	"github.com/joshilesanmi/open-search-dev/search"
+)
+
+// WithIgnoredFields ignores the fields at the given dotted paths, e.g. "updated_at" or "audit.synced_at", when
+// documents are compared across clusters by FindDocument and Reconcile. Volatile fields such as timestamps set by
+// the cluster or an ingest pipeline would otherwise report every document as divergent.
+func WithIgnoredFields(paths ...string) OpenSearchOption {
+	return func(os *OpenSearch) error {
+		for _, p := range paths {
+			if p == "" {
+				return errors.New("ignored field path must not be empty")
+			}
+		}
+		os.ignoredFields = append(os.ignoredFields, paths...)
+		return nil
+	}
+}
+
+// compareDocuments reports whether two documents are semantically equal. Objects are compared regardless of key
+// order and arrays element by element; numbers are equal if they have the same value, whatever their Go type
+// after decoding. Fields at the ignored dotted paths are skipped.
+func compareDocuments(doc1, doc2 search.Document, ignored ...string) bool {
+	skip := make(map[string]struct{}, len(ignored))
+	for _, p := range ignored {
+		skip[p] = struct{}{}
+	}
+	return equalValues(map[string]interface{}(doc1), map[string]interface{}(doc2), "", skip)
+}
+
+// DiffDocuments returns the sorted dotted paths of the fields that differ between two documents, e.g. the
+// versions of a document on the primary and the secondary cluster. Fields at the ignored dotted paths are
+// skipped, as with WithIgnoredFields.
+func DiffDocuments(doc1, doc2 search.Document, ignored ...string) []string {
+	return diffFields(doc1, doc2, ignored...)
+}
+
+// diffFields returns the sorted dotted paths of the fields that differ between two documents, as compared by
+// compareDocuments. Objects are descended into; arrays and other values are reported as a whole.
+func diffFields(doc1, doc2 search.Document, ignored ...string) []string {
+	skip := make(map[string]struct{}, len(ignored))
+	for _, p := range ignored {
+		skip[p] = struct{}{}
+	}
+	var fields []string
+	collectDiffs(doc1, doc2, "", skip, &fields)
+	sort.Strings(fields)
+	return fields
+}
+
+// collectDiffs appends the paths of the fields that differ between two objects to fields.
+func collectDiffs(a, b map[string]interface{}, path string, skip map[string]struct{}, fields *[]string) {
+	for key, value := range a {
+		p := fieldPath(path, key)
+		if _, ok := skip[p]; ok {
+			continue
+		}
+		other, ok := b[key]
+		if !ok {
+			*fields = append(*fields, p)
+			continue
+		}
+		o1, ok1 := object(value)
+		o2, ok2 := object(other)
+		if ok1 && ok2 {
+			collectDiffs(o1, o2, p, skip, fields)
+			continue
+		}
+		if !equalValues(value, other, p, skip) {
+			*fields = append(*fields, p)
+		}
+	}
+	for key := range b {
+		p := fieldPath(path, key)
+		if _, ok := skip[p]; ok {
+			continue
+		}
+		if _, ok := a[key]; !ok {
+			*fields = append(*fields, p)
+		}
+	}
+}
+
+// object returns a decoded JSON object, whether it is a map or a search.Document.
+func object(v interface{}) (map[string]interface{}, bool) {
+	switch o := v.(type) {
+	case map[string]interface{}:
+		return o, true
+	case search.Document:
+		return o, true
+	}
+	return nil, false
+}
+
+// equalValues compares two decoded JSON values at a dotted path.
+func equalValues(v1, v2 interface{}, path string, skip map[string]struct{}) bool {
+	if n1, ok := number(v1); ok {
+		n2, ok := number(v2)
+		return ok && n1 == n2
+	}
+
+	switch a := v1.(type) {
+	case search.Document:
+		return equalValues(map[string]interface{}(a), v2, path, skip)
+	case map[string]interface{}:
+		var b map[string]interface{}
+		switch t := v2.(type) {
+		case map[string]interface{}:
+			b = t
+		case search.Document:
+			b = t
+		default:
+			return false
+		}
+		return equalObjects(a, b, path, skip)
+	case []interface{}:
+		b, ok := v2.([]interface{})
+		if !ok || len(a) != len(b) {
+			return false
+		}
+		for i := range a {
+			if !equalValues(a[i], b[i], path, skip) {
+				return false
+			}
+		}
+		return true
+	case nil, string, bool:
+		return v1 == v2
+	default:
+		return reflect.DeepEqual(v1, v2)
+	}
+}
+
+// equalObjects compares the fields of two objects that are not ignored.
+func equalObjects(a, b map[string]interface{}, path string, skip map[string]struct{}) bool {
+	for key, value := range a {
+		p := fieldPath(path, key)
+		if _, ok := skip[p]; ok {
+			continue
+		}
+		other, ok := b[key]
+		if !ok || !equalValues(value, other, p, skip) {
+			return false
+		}
+	}
+	for key := range b {
+		if _, ok := skip[fieldPath(path, key)]; ok {
+			continue
+		}
+		if _, ok := a[key]; !ok {
+			return false
+		}
+	}
+	return true
+}
+
+func fieldPath(parent, key string) string {
+	if parent == "" {
+		return key
+	}
+	return parent + "." + key
+}
+
+// number returns the value of a numeric JSON value, whether it was decoded as a float64, a json.Number or set
+// as a Go integer before being written.
+func number(v interface{}) (float64, bool) {
+	switch n := v.(type) {
+	case float64:
+		return n, true
+	case float32:
+		return float64(n), true
+	case int:
+		return float64(n), true
+	case int32:
+		return float64(n), true
+	case int64:
+		return float64(n), true
+	case uint:
+		return float64(n), true
+	case uint32:
+		return float64(n), true
+	case uint64:
+		return float64(n), true
+	case json.Number:
+		f, err := strconv.ParseFloat(string(n), 64)
+		return f, err == nil
+	}
+	return 0, false
+}
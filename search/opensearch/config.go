@@ -0,0 +1,384 @@
+package opensearch
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/joshilesanmi/open-search-dev/search"
+	"github.com/rs/zerolog"
+	"gopkg.in/yaml.v3"
+)
+
+// Config describes an engine in a JSON or YAML file, so services and the CLI do not hardcode endpoints and credentials.
+// Only the endpoint is required; everything else defaults like the corresponding OpenSearchOption.
+//
+//	{
+//	  "endpoint": "https://primary:9200",
+//	  "secondary_endpoint": "https://secondary:9200",
+//	  "auth": {"username": "search", "password": "secret", "ca_certificate_file": "/etc/ssl/ca.pem"},
+//	  "timeouts": {"connect": "5s", "request": "10s", "methods": {"FindDocument": "2s"}},
+//	  "retry": {"requests": {"max_attempts": 3}, "bulk": {"max_retries": 5, "backoff": "200ms"}},
+//	  "index_defaults": {"number_of_shards": 3, "number_of_replicas": 1}
+//	}
+//
+// YAML files use the same keys, e.g. "endpoint: https://primary:9200".
+type Config struct {
+	Endpoint          string   `json:"endpoint"`
+	PrimaryName       string   `json:"primary_name,omitempty"`
+	SecondaryEndpoint string   `json:"secondary_endpoint,omitempty"`
+	SecondaryName     string   `json:"secondary_name,omitempty"`
+	ProtectedIndices  []string `json:"protected_indices,omitempty"`
+
+	Auth          AuthConfig     `json:"auth"`
+	Timeouts      TimeoutConfig  `json:"timeouts"`
+	Retry         RetrySettings  `json:"retry"`
+	IndexDefaults *IndexDefaults `json:"index_defaults,omitempty"`
+}
+
+// AuthConfig configures authentication and TLS, see WithBasicAuth and WithCACertificateFile.
+type AuthConfig struct {
+	Username           string `json:"username,omitempty"`
+	Password           string `json:"password,omitempty"`
+	CACertificateFile  string `json:"ca_certificate_file,omitempty"`
+	InsecureSkipVerify bool   `json:"insecure_skip_verify,omitempty"`
+}
+
+// TimeoutConfig configures timeouts, see WithConnectTimeout, WithRequestTimeout and WithMethodTimeout.
+type TimeoutConfig struct {
+	Connect Duration            `json:"connect,omitempty"`
+	Request Duration            `json:"request,omitempty"`
+	Methods map[string]Duration `json:"methods,omitempty"`
+}
+
+// RetrySettings configures retries, see OpenSearchRetryMiddleware, WithBulkRetry and WithThrottleRetry. Operations
+// are only retried by the middleware if Requests is set; nil Bulk and Throttle sections keep their defaults.
+type RetrySettings struct {
+	Requests *RequestRetrySettings  `json:"requests,omitempty"`
+	Bulk     *BulkRetrySettings     `json:"bulk,omitempty"`
+	Throttle *ThrottleRetrySettings `json:"throttle,omitempty"`
+}
+
+// RequestRetrySettings is the JSON form of RetryConfig.
+type RequestRetrySettings struct {
+	MaxAttempts int      `json:"max_attempts"`
+	Backoff     Duration `json:"backoff"`
+	MaxBackoff  Duration `json:"max_backoff"`
+}
+
+// BulkRetrySettings is the JSON form of BulkRetryConfig.
+type BulkRetrySettings struct {
+	MaxRetries int      `json:"max_retries"`
+	Backoff    Duration `json:"backoff"`
+	MaxBackoff Duration `json:"max_backoff"`
+}
+
+// ThrottleRetrySettings is the JSON form of ThrottleConfig.
+type ThrottleRetrySettings struct {
+	MaxRetries int      `json:"max_retries"`
+	MaxWait    Duration `json:"max_wait"`
+}
+
+// Duration is a time.Duration written in JSON as a string such as "1.5s" or "300ms".
+type Duration time.Duration
+
+// UnmarshalJSON parses a duration string.
+func (d *Duration) UnmarshalJSON(b []byte) error {
+	var s string
+	if err := json.Unmarshal(b, &s); err != nil {
+		return fmt.Errorf("duration must be a string such as \"5s\": %w", err)
+	}
+	v, err := time.ParseDuration(s)
+	if err != nil {
+		return err
+	}
+	*d = Duration(v)
+	return nil
+}
+
+// MarshalJSON formats the duration as a string.
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(time.Duration(d).String())
+}
+
+// LoadConfig reads a Config from a JSON or YAML file, see DecodeConfigFile. Unknown fields are rejected, so
+// misspelt settings do not silently fall back to defaults.
+func LoadConfig(path string) (Config, error) {
+	var config Config
+	if err := DecodeConfigFile(path, &config); err != nil {
+		return config, err
+	}
+	if err := config.Validate(); err != nil {
+		return config, fmt.Errorf("config %s: %w", path, err)
+	}
+	return config, nil
+}
+
+// DecodeConfigFile decodes a JSON file, or a YAML file if its extension is .yaml or .yml, into v. YAML is decoded
+// through the JSON form of the document, so both formats use the json tags and unmarshalers of v, e.g. durations
+// such as "1.5s". Fields unknown to v are rejected.
+func DecodeConfigFile(path string, v interface{}) error {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		if b, err = yamlToJSON(b); err != nil {
+			return fmt.Errorf("config %s: %w", path, err)
+		}
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(v); err != nil {
+		return fmt.Errorf("config %s: %w", path, err)
+	}
+	return nil
+}
+
+// yamlToJSON converts a YAML document to JSON. Mappings must have string keys, as JSON objects do.
+func yamlToJSON(b []byte) ([]byte, error) {
+	var doc interface{}
+	if err := yaml.Unmarshal(b, &doc); err != nil {
+		return nil, err
+	}
+	if doc == nil {
+		return nil, errors.New("empty YAML document")
+	}
+
+	var convert func(v interface{}) (interface{}, error)
+	convert = func(v interface{}) (interface{}, error) {
+		switch v := v.(type) {
+		case map[string]interface{}:
+			for k, item := range v {
+				c, err := convert(item)
+				if err != nil {
+					return nil, fmt.Errorf("%s: %w", k, err)
+				}
+				v[k] = c
+			}
+			return v, nil
+		case map[interface{}]interface{}:
+			return nil, errors.New("mapping keys must be strings")
+		case []interface{}:
+			for i, item := range v {
+				c, err := convert(item)
+				if err != nil {
+					return nil, fmt.Errorf("[%d]: %w", i, err)
+				}
+				v[i] = c
+			}
+			return v, nil
+		case time.Time:
+			// Unquoted timestamps are decoded as times; keep them in their YAML form.
+			return v.Format(time.RFC3339Nano), nil
+		default:
+			return v, nil
+		}
+	}
+
+	doc, err := convert(doc)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(doc)
+}
+
+// Validate checks that the configuration is complete and consistent.
+func (c Config) Validate() error {
+	if c.Endpoint == "" {
+		return errors.New("endpoint is required")
+	}
+	for _, endpoint := range []string{c.Endpoint, c.SecondaryEndpoint} {
+		if endpoint == "" {
+			continue
+		}
+		u, err := url.Parse(endpoint)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("invalid endpoint %q: must be an http or https URL", endpoint)
+		}
+	}
+	if c.SecondaryName != "" && c.SecondaryEndpoint == "" {
+		return errors.New("secondary_name requires secondary_endpoint")
+	}
+	if c.Auth.Password != "" && c.Auth.Username == "" {
+		return errors.New("auth password requires a username")
+	}
+	return nil
+}
+
+// Options returns the OpenSearchOptions applying the configuration, except the endpoint of the primary cluster.
+func (c Config) Options() []OpenSearchOption {
+	var opts []OpenSearchOption
+	if c.PrimaryName != "" {
+		opts = append(opts, WithPrimaryName(c.PrimaryName))
+	}
+	if c.SecondaryEndpoint != "" {
+		opts = append(opts, WithSecondaryEndpoint(c.SecondaryEndpoint))
+	}
+	if c.SecondaryName != "" {
+		opts = append(opts, WithSecondaryName(c.SecondaryName))
+	}
+	if len(c.ProtectedIndices) > 0 {
+		opts = append(opts, WithProtectedIndices(c.ProtectedIndices...))
+	}
+
+	if c.Auth.Username != "" {
+		opts = append(opts, WithBasicAuth(c.Auth.Username, c.Auth.Password))
+	}
+	if c.Auth.CACertificateFile != "" {
+		opts = append(opts, WithCACertificateFile(c.Auth.CACertificateFile))
+	}
+	if c.Auth.InsecureSkipVerify {
+		opts = append(opts, WithInsecureSkipVerify())
+	}
+
+	if c.Timeouts.Connect != 0 {
+		opts = append(opts, WithConnectTimeout(time.Duration(c.Timeouts.Connect)))
+	}
+	if c.Timeouts.Request != 0 {
+		opts = append(opts, WithRequestTimeout(time.Duration(c.Timeouts.Request)))
+	}
+	for method, timeout := range c.Timeouts.Methods {
+		opts = append(opts, WithMethodTimeout(method, time.Duration(timeout)))
+	}
+
+	if r := c.Retry.Bulk; r != nil {
+		opts = append(opts, WithBulkRetry(BulkRetryConfig{
+			MaxRetries: r.MaxRetries,
+			Backoff:    time.Duration(r.Backoff),
+			MaxBackoff: time.Duration(r.MaxBackoff),
+		}))
+	}
+	if r := c.Retry.Throttle; r != nil {
+		opts = append(opts, WithThrottleRetry(ThrottleConfig{MaxRetries: r.MaxRetries, MaxWait: time.Duration(r.MaxWait)}))
+	}
+
+	if c.IndexDefaults != nil {
+		opts = append(opts, WithIndexDefaults(*c.IndexDefaults))
+	}
+	return opts
+}
+
+// NewEngine creates an engine from the configuration, wrapped with OpenSearchRetryMiddleware if request retries
+// are configured. The given options are applied after the ones of the configuration.
+func (c Config) NewEngine(logger zerolog.Logger, opts ...OpenSearchOption) (Engine, error) {
+	// The retries wait on the clock of the engine, known once every option is applied.
+	var clock search.Clock
+	opts = append(append(c.Options(), opts...), func(os *OpenSearch) error {
+		clock = os.clock
+		return nil
+	})
+
+	engine, err := NewOpenSearch(c.Endpoint, logger, opts...)
+	if err != nil {
+		return nil, err
+	}
+	if r := c.Retry.Requests; r != nil {
+		engine = OpenSearchRetryMiddleware(RetryConfig{
+			MaxAttempts: r.MaxAttempts,
+			Backoff:     time.Duration(r.Backoff),
+			MaxBackoff:  time.Duration(r.MaxBackoff),
+			Clock:       clock,
+		})(engine)
+	}
+	return engine, nil
+}
+
+// NewFromConfig creates an engine from a JSON or YAML configuration file, see Config and Config.NewEngine.
+func NewFromConfig(path string, logger zerolog.Logger, opts ...OpenSearchOption) (Engine, error) {
+	config, err := LoadConfig(path)
+	if err != nil {
+		return nil, err
+	}
+	return config.NewEngine(logger, opts...)
+}
+
+// IndexDefaults are index settings applied by CreateIndex when the index configuration does not set them.
+type IndexDefaults struct {
+	NumberOfShards   *int   `json:"number_of_shards,omitempty"`
+	NumberOfReplicas *int   `json:"number_of_replicas,omitempty"`
+	RefreshInterval  string `json:"refresh_interval,omitempty"` // E.g. "30s", or "-1" to disable refreshes.
+
+	// Language, if set, generates the analyzer of the language and sets it on the text fields of every index,
+	// see ApplyLanguage.
+	Language Language `json:"language,omitempty"`
+}
+
+// WithIndexDefaults applies default settings to the indices created by CreateIndex, e.g. more replicas in
+// production than in development, without changing the index configurations of the callers.
+func WithIndexDefaults(defaults IndexDefaults) OpenSearchOption {
+	return func(os *OpenSearch) error {
+		if n := defaults.NumberOfShards; n != nil && *n <= 0 {
+			return errors.New("default number of shards must be positive")
+		}
+		if n := defaults.NumberOfReplicas; n != nil && *n < 0 {
+			return errors.New("default number of replicas must not be negative")
+		}
+		if defaults.Language != "" {
+			if _, err := languageComponents(defaults.Language); err != nil {
+				return fmt.Errorf("default language: %w", err)
+			}
+		}
+		os.indexDefaults = &defaults
+		return nil
+	}
+}
+
+// applyIndexDefaults returns a copy of an index configuration with the defaults added to its index settings.
+// Settings given either under "settings.index" or as flat "settings" keys are kept.
+func (os *OpenSearch) applyIndexDefaults(config map[string]interface{}) map[string]interface{} {
+	d := os.indexDefaults
+	if d == nil {
+		return config
+	}
+
+	out := make(map[string]interface{}, len(config)+1)
+	for k, v := range config {
+		out[k] = v
+	}
+	settings := make(map[string]interface{})
+	if s, ok := config["settings"].(map[string]interface{}); ok {
+		for k, v := range s {
+			settings[k] = v
+		}
+	}
+	index := make(map[string]interface{})
+	if i, ok := settings["index"].(map[string]interface{}); ok {
+		for k, v := range i {
+			index[k] = v
+		}
+	}
+
+	set := func(key string, value interface{}) {
+		_, nested := index[key]
+		_, flat := settings[key]
+		_, dotted := settings["index."+key]
+		if !nested && !flat && !dotted {
+			index[key] = value
+		}
+	}
+	if d.NumberOfShards != nil {
+		set("number_of_shards", *d.NumberOfShards)
+	}
+	if d.NumberOfReplicas != nil {
+		set("number_of_replicas", *d.NumberOfReplicas)
+	}
+	if d.RefreshInterval != "" {
+		set("refresh_interval", d.RefreshInterval)
+	}
+
+	if len(index) > 0 {
+		settings["index"] = index
+	}
+	out["settings"] = settings
+	return out
+}
@@ -0,0 +1,177 @@
+package opensearch
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/joshilesanmi/open-search-dev/search"
+	opensearch "github.com/opensearch-project/opensearch-go/v2"
+	opensearchapi "github.com/opensearch-project/opensearch-go/v2/opensearchapi"
+)
+
+// deletedSeqNo marks a document of a ConsistencyToken that must no longer be searchable.
+const deletedSeqNo = -1
+
+// ConsistencyToken records the latest writes of a set of documents, so that WaitForSearchable can wait until
+// they are visible to search without forcing refreshes.
+type ConsistencyToken struct {
+	Index  string           // The index the documents were written to.
+	SeqNos map[string]int64 // The sequence number of the latest write of each document, keyed by document ID.
+}
+
+// ConsistencyToken returns a token for the latest writes of the given documents of an index, typically called
+// after a batch of writes. It reads the documents in real time from the cluster serving searches, which reflects
+// writes before they are refreshed.
+func (os *OpenSearch) ConsistencyToken(ctx context.Context, indexName string, keys []search.DocumentKey) (ConsistencyToken, error) {
+	token := ConsistencyToken{Index: indexName, SeqNos: make(map[string]int64, len(keys))}
+	if len(keys) == 0 {
+		return token, nil
+	}
+
+	ids := make([]string, 0, len(keys))
+	for _, key := range keys {
+		ids = append(ids, search.GenerateDocumentID(key.InstanceID, key.EntityName, key.EntityID))
+	}
+
+	body, err := json.Marshal(map[string]interface{}{"ids": ids})
+	if err != nil {
+		return token, fmt.Errorf("failed to marshal document ids %v", err)
+	}
+
+	read, preference, err := os.readCluster(ctx, ReadSearch)
+	if err != nil {
+		return token, err
+	}
+
+	realtime := true
+	resp, err := os.executeReadRequest(ctx, read.client, opensearchapi.MgetRequest{
+		Index:      indexName,
+		Body:       bytes.NewReader(body),
+		Preference: preference,
+		Realtime:   &realtime,
+		Source:     false,
+	})
+	if err != nil {
+		return token, err
+	}
+
+	var r struct {
+		Docs []struct {
+			ID    string `json:"_id"`
+			Found bool   `json:"found"`
+			SeqNo int64  `json:"_seq_no"`
+		} `json:"docs"`
+	}
+	if err := os.decodeResponse(resp, &r); err != nil {
+		return token, err
+	}
+
+	for _, d := range r.Docs {
+		if d.Found {
+			token.SeqNos[d.ID] = d.SeqNo
+		} else {
+			token.SeqNos[d.ID] = deletedSeqNo
+		}
+	}
+
+	return token, nil
+}
+
+// WaitForSearchable waits until the writes recorded in a token are visible to search: written documents are
+// found with at least the recorded sequence number and deleted documents are no longer found. It polls with an
+// increasing interval until the writes are visible or the context is done.
+func (os *OpenSearch) WaitForSearchable(ctx context.Context, token ConsistencyToken) error {
+	if len(token.SeqNos) == 0 {
+		return nil
+	}
+
+	ids := make([]string, 0, len(token.SeqNos))
+	for id := range token.SeqNos {
+		ids = append(ids, id)
+	}
+
+	body, err := json.Marshal(map[string]interface{}{
+		"size":                len(ids),
+		"_source":             false,
+		"seq_no_primary_term": true,
+		"query": map[string]interface{}{
+			"ids": map[string]interface{}{"values": ids},
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to marshal search query: %v", err)
+	}
+
+	read, preference, err := os.readCluster(ctx, ReadSearch)
+	if err != nil {
+		return err
+	}
+
+	interval := 50 * time.Millisecond
+	for {
+		visible, err := os.searchable(ctx, read.client, token, body, preference)
+		if err != nil {
+			return err
+		}
+		if visible {
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-search.After(os.clock, interval):
+		}
+
+		if interval *= 2; interval > time.Second {
+			interval = time.Second
+		}
+	}
+}
+
+// searchable reports whether every write recorded in the token is visible to search.
+func (os *OpenSearch) searchable(ctx context.Context, client *opensearch.Client, token ConsistencyToken, body []byte, preference string) (bool, error) {
+	resp, err := os.executeReadRequest(ctx, client, opensearchapi.SearchRequest{
+		Index:      []string{token.Index},
+		Body:       bytes.NewReader(body),
+		Preference: preference,
+	})
+	if err != nil {
+		return false, err
+	}
+
+	var r struct {
+		Hits struct {
+			Hits []struct {
+				ID    string `json:"_id"`
+				SeqNo int64  `json:"_seq_no"`
+			} `json:"hits"`
+		} `json:"hits"`
+	}
+	if err := os.decodeResponse(resp, &r); err != nil {
+		return false, err
+	}
+
+	found := make(map[string]int64, len(r.Hits.Hits))
+	for _, hit := range r.Hits.Hits {
+		found[hit.ID] = hit.SeqNo
+	}
+
+	for id, want := range token.SeqNos {
+		got, ok := found[id]
+		if want == deletedSeqNo {
+			if ok {
+				return false, nil
+			}
+			continue
+		}
+		if !ok || got < want {
+			return false, nil
+		}
+	}
+
+	return true, nil
+}
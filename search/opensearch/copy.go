@@ -0,0 +1,168 @@
+package opensearch
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/joshilesanmi/open-search-dev/search"
+)
+
+// CopyOption is a function type that applies configuration options to a CopyOptions instance.
+type CopyOption func(*CopyOptions)
+
+// CopyOptions defines configuration options for CopyIndex.
+type CopyOptions struct {
+	BatchSize          int           // Number of documents read and written per round trip. Defaults to 500.
+	DocumentsPerSecond float64       // Upper bound of the copy throughput; unlimited if zero.
+	KeepAlive          time.Duration // How long the scroll is kept between batches. Defaults to one minute.
+}
+
+// WithCopyBatchSize returns a CopyOption that sets the number of documents copied per round trip.
+func WithCopyBatchSize(size int) CopyOption {
+	return func(opts *CopyOptions) {
+		opts.BatchSize = size
+	}
+}
+
+// WithCopyThrottle returns a CopyOption that limits the copy to the given number of documents per second, so a
+// backfill does not starve the production traffic of either cluster.
+func WithCopyThrottle(documentsPerSecond float64) CopyOption {
+	return func(opts *CopyOptions) {
+		opts.DocumentsPerSecond = documentsPerSecond
+	}
+}
+
+// WithCopyKeepAlive returns a CopyOption that sets how long the scroll is kept between batches, e.g. longer
+// when a throttled copy of large batches spends more than a minute on a batch.
+func WithCopyKeepAlive(keepAlive time.Duration) CopyOption {
+	return func(opts *CopyOptions) {
+		opts.KeepAlive = keepAlive
+	}
+}
+
+// CopyReport summarises a copy of an index between clusters.
+type CopyReport struct {
+	Index   string               // The copied index.
+	Scanned int64                // Documents read from the source cluster.
+	Copied  int64                // Documents written to the target cluster.
+	Failed  []search.ItemFailure // Documents the target cluster rejected, with their reasons.
+	Retried int                  // Documents that were retried before they were written or reported as failed.
+}
+
+// CopyIndex streams the documents of an index from one configured cluster to another with a scroll search and
+// bulk requests, e.g. to backfill the secondary before enabling dual writes. The index must exist on the target
+// cluster, so it is not created with dynamic mappings; existing documents are overwritten. Documents rejected by
+// the target are reported rather than failing the copy. Retries of bulk requests follow WithBulkRetry.
+func (os *OpenSearch) CopyIndex(ctx context.Context, indexName string, from, to search.ClusterTarget, opts ...CopyOption) (CopyReport, error) {
+	options := &CopyOptions{BatchSize: 500, KeepAlive: time.Minute}
+	for _, opt := range opts {
+		opt(options)
+	}
+
+	report := CopyReport{Index: indexName}
+	if options.BatchSize <= 0 {
+		return report, errors.New("copy batch size must be positive")
+	}
+	if options.KeepAlive <= 0 {
+		return report, errors.New("copy keep-alive must be positive")
+	}
+	if options.DocumentsPerSecond < 0 {
+		return report, errors.New("copy throughput must not be negative")
+	}
+
+	source, err := os.clusterFor(from)
+	if err != nil {
+		return report, err
+	}
+	target, err := os.clusterFor(to)
+	if err != nil {
+		return report, err
+	}
+	if source.name == target.name {
+		return report, errors.New("source and target cluster must differ")
+	}
+	if err := os.checkWritable(indexName); err != nil {
+		return report, err
+	}
+
+	exists, err := os.indexExists(ctx, target.client, indexName)
+	if err != nil {
+		return report, &ClusterError{Cluster: target.role, Name: target.name, Err: err}
+	}
+	if !exists {
+		return report, &ClusterError{Cluster: target.role, Name: target.name, Err: fmt.Errorf("index %q: %w", indexName, ErrIndexNotFound)}
+	}
+
+	begin := os.clock.Now()
+	err = os.scroll(ctx, source, indexName, options.BatchSize, options.KeepAlive, true, func(hits []searchHit) error {
+		items := make([]bulkItem, 0, len(hits))
+		for _, hit := range hits {
+			b, err := json.Marshal(hit.Source)
+			if err != nil {
+				return fmt.Errorf("failed to marshal document %v", err)
+			}
+			items = append(items, bulkItem{key: documentKey(hit.Source), action: "index", id: hit.ID, source: b})
+		}
+		report.Scanned += int64(len(items))
+
+		written, failed, retried, err := os.bulkWithRetry(ctx, target.client, indexName, items, "false")
+		report.Retried += retried
+		if err != nil {
+			return &ClusterError{Cluster: target.role, Name: target.name, Err: err}
+		}
+		report.Copied += int64(len(written))
+		for _, failure := range failed {
+			failure.Cluster = target.name
+			report.Failed = append(report.Failed, failure)
+		}
+
+		return throttle(ctx, os.clock, begin, report.Scanned, options.DocumentsPerSecond)
+	})
+
+	return report, err
+}
+
+// documentKey returns the key of a stored document from its metadata fields.
+func documentKey(d search.Document) search.DocumentKey {
+	str := func(field string) string {
+		s, _ := d[field].(string)
+		return s
+	}
+	return search.DocumentKey{InstanceID: str("instance_id"), EntityName: str("entity_name"), EntityID: str("id")}
+}
+
+// clusterFor returns the primary or the secondary cluster.
+func (os *OpenSearch) clusterFor(target search.ClusterTarget) (cluster, error) {
+	switch target {
+	case search.Primary:
+		return os.primary(), nil
+	case search.Secondary:
+		secondary, ok := os.secondary()
+		if !ok {
+			return cluster{}, ErrNoSecondary
+		}
+		return secondary, nil
+	}
+	return cluster{}, errors.New("cluster must be search.Primary or search.Secondary")
+}
+
+// throttle waits on the clock until processing n documents since begin stays within the rate, in documents per
+// second. A rate of zero is unlimited.
+func throttle(ctx context.Context, clock search.Clock, begin time.Time, n int64, rate float64) error {
+	if rate <= 0 {
+		return nil
+	}
+	wait := time.Duration(float64(n)/rate*float64(time.Second)) - clock.Now().Sub(begin)
+	if wait <= 0 {
+		return nil
+	}
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-search.After(clock, wait):
+		return nil
+	}
+}
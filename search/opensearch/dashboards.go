@@ -0,0 +1,264 @@
+package opensearch
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// VisualizationKind selects the chart of a provisioned visualization.
+type VisualizationKind int
+
+const (
+	VisualizationCount    VisualizationKind = iota // A metric showing the number of documents.
+	VisualizationTimeline                          // A histogram of documents over the date field Field.
+	VisualizationTerms                             // A table of the most frequent values of the keyword field Field.
+)
+
+// Visualization describes a saved visualization provisioned for every instance.
+type Visualization struct {
+	Name  string            // Short name, unique per instance, used in the saved object ID.
+	Title string            // Title shown in Dashboards. The instance ID is appended.
+	Kind  VisualizationKind // The chart type.
+	Field string            // The field charted by VisualizationTimeline and VisualizationTerms.
+}
+
+// DefaultVisualizations returns a basic set of visualizations: the document count, the documents over the given
+// date field and the documents by entity.
+func DefaultVisualizations(timeField string) []Visualization {
+	return []Visualization{
+		{Name: "count", Title: "Documents", Kind: VisualizationCount},
+		{Name: "timeline", Title: "Documents over time", Kind: VisualizationTimeline, Field: timeField},
+		{Name: "entities", Title: "Documents by entity", Kind: VisualizationTerms, Field: "entity_name"},
+	}
+}
+
+// Dashboards provisions saved objects through the saved-objects API of OpenSearch Dashboards.
+type Dashboards struct {
+	endpoint  string
+	client    *http.Client
+	timeField string
+	header    http.Header
+}
+
+// DashboardsOption is a function type that applies configuration options to a Dashboards instance.
+type DashboardsOption func(*Dashboards)
+
+// WithDashboardsClient sets the HTTP client used to call Dashboards. Defaults to http.DefaultClient.
+func WithDashboardsClient(client *http.Client) DashboardsOption {
+	return func(d *Dashboards) {
+		d.client = client
+	}
+}
+
+// WithDashboardsTimeField sets the date field of the provisioned index patterns, enabling the time picker.
+func WithDashboardsTimeField(field string) DashboardsOption {
+	return func(d *Dashboards) {
+		d.timeField = field
+	}
+}
+
+// WithDashboardsHeader adds a header to every request, e.g. for authentication or the security tenant.
+func WithDashboardsHeader(key, value string) DashboardsOption {
+	return func(d *Dashboards) {
+		d.header.Add(key, value)
+	}
+}
+
+// NewDashboards returns a Dashboards provisioning saved objects on the Dashboards instance at endpoint.
+func NewDashboards(endpoint string, opts ...DashboardsOption) *Dashboards {
+	d := &Dashboards{
+		endpoint: strings.TrimRight(endpoint, "/"),
+		client:   http.DefaultClient,
+		header:   make(http.Header),
+	}
+	for _, opt := range opts {
+		opt(d)
+	}
+	return d
+}
+
+// WithDashboards makes ProvisionInstance also provision the Dashboards index pattern and visualizations of the
+// instance, so its dashboards exist as soon as it is provisioned.
+func WithDashboards(d *Dashboards, visualizations ...Visualization) OpenSearchOption {
+	return func(os *OpenSearch) error {
+		if d == nil {
+			return errors.New("dashboards must not be nil")
+		}
+		os.dashboards = d
+		os.visualizations = visualizations
+		return nil
+	}
+}
+
+// savedObject is an entry of a saved-objects bulk create request.
+type savedObject struct {
+	Type       string                 `json:"type"`
+	ID         string                 `json:"id"`
+	Attributes map[string]interface{} `json:"attributes"`
+	References []savedObjectReference `json:"references,omitempty"`
+}
+
+type savedObjectReference struct {
+	Name string `json:"name"`
+	Type string `json:"type"`
+	ID   string `json:"id"`
+}
+
+// indexPatternRef is the name of the reference from a visualization to its index pattern.
+const indexPatternRef = "kibanaSavedObjectMeta.searchSourceJSON.index"
+
+// Provision creates or overwrites the index pattern of the instance alias of an index, see InstanceAlias, and
+// the given visualizations on it. Saved object IDs are derived from the alias, so provisioning again updates the
+// existing objects.
+func (d *Dashboards) Provision(ctx context.Context, indexName, instanceID string, visualizations ...Visualization) error {
+	alias := InstanceAlias(indexName, instanceID)
+	patternID := "index-pattern-" + alias
+
+	pattern := map[string]interface{}{"title": alias}
+	if d.timeField != "" {
+		pattern["timeFieldName"] = d.timeField
+	}
+
+	objects := []savedObject{{Type: "index-pattern", ID: patternID, Attributes: pattern}}
+	for _, v := range visualizations {
+		attributes, err := v.attributes(instanceID)
+		if err != nil {
+			return err
+		}
+		objects = append(objects, savedObject{
+			Type:       "visualization",
+			ID:         "visualization-" + alias + "-" + v.Name,
+			Attributes: attributes,
+			References: []savedObjectReference{{Name: indexPatternRef, Type: "index-pattern", ID: patternID}},
+		})
+	}
+
+	body, err := json.Marshal(objects)
+	if err != nil {
+		return fmt.Errorf("failed to marshal saved objects %v", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, d.endpoint+"/api/saved_objects/_bulk_create?overwrite=true", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	for k, vs := range d.header {
+		for _, v := range vs {
+			req.Header.Add(k, v)
+		}
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("osd-xsrf", "true")
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	var r struct {
+		SavedObjects []struct {
+			ID    string `json:"id"`
+			Error *struct {
+				Message string `json:"message"`
+			} `json:"error"`
+		} `json:"saved_objects"`
+	}
+	b, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode >= http.StatusBadRequest {
+		return fmt.Errorf("saved objects request failed with status %d: %s", resp.StatusCode, b)
+	}
+	if err := json.Unmarshal(b, &r); err != nil {
+		return fmt.Errorf("failed to decode saved objects response %v", err)
+	}
+
+	var msgs []string
+	for _, o := range r.SavedObjects {
+		if o.Error != nil {
+			msgs = append(msgs, fmt.Sprintf("%s: %s", o.ID, o.Error.Message))
+		}
+	}
+	if len(msgs) > 0 {
+		return fmt.Errorf("failed to provision saved objects: %s", strings.Join(msgs, "; "))
+	}
+
+	return nil
+}
+
+// attributes returns the saved object attributes of the visualization.
+func (v Visualization) attributes(instanceID string) (map[string]interface{}, error) {
+	var aggs []interface{}
+	visType := "metric"
+	params := map[string]interface{}{}
+
+	switch v.Kind {
+	case VisualizationCount:
+		aggs = []interface{}{countAgg()}
+	case VisualizationTimeline:
+		if v.Field == "" {
+			return nil, fmt.Errorf("visualization %q requires a field", v.Name)
+		}
+		visType = "histogram"
+		aggs = []interface{}{countAgg(), map[string]interface{}{
+			"id": "2", "enabled": true, "type": "date_histogram", "schema": "segment",
+			"params": map[string]interface{}{"field": v.Field, "interval": "auto", "min_doc_count": 1},
+		}}
+	case VisualizationTerms:
+		if v.Field == "" {
+			return nil, fmt.Errorf("visualization %q requires a field", v.Name)
+		}
+		visType = "table"
+		params["perPage"] = 10
+		aggs = []interface{}{countAgg(), map[string]interface{}{
+			"id": "2", "enabled": true, "type": "terms", "schema": "bucket",
+			"params": map[string]interface{}{"field": v.Field, "size": 10, "order": "desc", "orderBy": "1"},
+		}}
+	default:
+		return nil, fmt.Errorf("visualization %q has an unknown kind", v.Name)
+	}
+
+	title := fmt.Sprintf("%s (%s)", v.Title, instanceID)
+
+	visState, err := json.Marshal(map[string]interface{}{
+		"title":  title,
+		"type":   visType,
+		"params": params,
+		"aggs":   aggs,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal visualization state %v", err)
+	}
+
+	searchSource, err := json.Marshal(map[string]interface{}{
+		"indexRefName": indexPatternRef,
+		"query":        map[string]interface{}{"query": "", "language": "kuery"},
+		"filter":       []interface{}{},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal visualization search source %v", err)
+	}
+
+	return map[string]interface{}{
+		"title":       title,
+		"visState":    string(visState),
+		"uiStateJSON": "{}",
+		"description": "",
+		"kibanaSavedObjectMeta": map[string]interface{}{
+			"searchSourceJSON": string(searchSource),
+		},
+	}, nil
+}
+
+// countAgg is the document count metric of a visualization.
+func countAgg() map[string]interface{} {
+	return map[string]interface{}{"id": "1", "enabled": true, "type": "count", "schema": "metric", "params": map[string]interface{}{}}
+}
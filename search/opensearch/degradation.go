@@ -0,0 +1,152 @@
+package opensearch
+
+import (
+	"encoding/json"
+	"errors"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/joshilesanmi/open-search-dev/search"
+)
+
+// DegradationConfig configures how searches degrade while the cluster is slow. The engine tracks the latency
+// of recent searches; while the configured percentile exceeds the budget, searches are served from the cache
+// when possible and otherwise sent with a reduced result size and without highlights or aggregations.
+type DegradationConfig struct {
+	Window       int           // Number of recent searches the percentile is computed over. Defaults to 100.
+	Percentile   float64       // The tracked latency percentile, between 0 and 1. Defaults to 0.95.
+	Budget       time.Duration // The latency budget for the tracked percentile.
+	DegradedSize int           // The result size used while degraded. Defaults to 10.
+	CacheTTL     time.Duration // How long results may be served from the cache while degraded. Zero disables it.
+	CacheSize    int           // Maximum number of cached results. Defaults to 1000.
+}
+
+// WithDegradation enables latency-budget aware degradation of searches.
+func WithDegradation(config DegradationConfig) OpenSearchOption {
+	return func(os *OpenSearch) error {
+		if config.Budget <= 0 {
+			return errors.New("degradation budget must be positive")
+		}
+		if config.Window <= 0 {
+			config.Window = 100
+		}
+		if config.Percentile <= 0 || config.Percentile > 1 {
+			config.Percentile = 0.95
+		}
+		if config.DegradedSize <= 0 {
+			config.DegradedSize = 10
+		}
+		if config.CacheSize <= 0 {
+			config.CacheSize = 1000
+		}
+		os.degradation = &degradation{
+			config:  config,
+			samples: make([]time.Duration, 0, config.Window),
+			cache:   make(map[string]cachedResult),
+		}
+		return nil
+	}
+}
+
+// degradation tracks search latency and caches results for use while the cluster is slow.
+type degradation struct {
+	config DegradationConfig
+
+	mu      sync.Mutex
+	samples []time.Duration // Ring buffer of recent latencies.
+	next    int             // Position of the next sample once the buffer is full.
+	cache   map[string]cachedResult
+}
+
+type cachedResult struct {
+	result search.SearchResult
+	stored time.Time
+}
+
+// observe records the latency of a search.
+func (d *degradation) observe(took time.Duration) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if len(d.samples) < d.config.Window {
+		d.samples = append(d.samples, took)
+		return
+	}
+	d.samples[d.next] = took
+	d.next = (d.next + 1) % d.config.Window
+}
+
+// degraded reports whether the tracked latency percentile exceeds the budget.
+func (d *degradation) degraded() bool {
+	d.mu.Lock()
+	samples := make([]time.Duration, len(d.samples))
+	copy(samples, d.samples)
+	d.mu.Unlock()
+
+	if len(samples) == 0 {
+		return false
+	}
+
+	sort.Slice(samples, func(i, j int) bool { return samples[i] < samples[j] })
+	idx := int(float64(len(samples)-1) * d.config.Percentile)
+
+	return samples[idx] > d.config.Budget
+}
+
+// degrade reduces the cost of a search body: it caps the result size and drops highlights and aggregations.
+func (d *degradation) degrade(body map[string]interface{}) {
+	if size, ok := body["size"].(int); !ok || size > d.config.DegradedSize {
+		body["size"] = d.config.DegradedSize
+	}
+	delete(body, "highlight")
+	delete(body, "aggs")
+	delete(body, "aggregations")
+}
+
+// cached returns the cached result for a search, if it is younger than the cache TTL.
+func (d *degradation) cached(key string, now time.Time) (search.SearchResult, bool) {
+	if d.config.CacheTTL <= 0 {
+		return search.SearchResult{}, false
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	r, ok := d.cache[key]
+	if !ok || now.Sub(r.stored) > d.config.CacheTTL {
+		return search.SearchResult{}, false
+	}
+	return r.result, true
+}
+
+// store caches the result of a search, evicting the oldest entry when the cache is full.
+func (d *degradation) store(key string, result search.SearchResult, now time.Time) {
+	if d.config.CacheTTL <= 0 {
+		return
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if _, ok := d.cache[key]; !ok && len(d.cache) >= d.config.CacheSize {
+		var oldest string
+		for k, r := range d.cache {
+			if oldest == "" || r.stored.Before(d.cache[oldest].stored) {
+				oldest = k
+			}
+		}
+		delete(d.cache, oldest)
+	}
+
+	d.cache[key] = cachedResult{result: result, stored: now}
+}
+
+// degradationCacheKey identifies a search by instance and query body.
+func degradationCacheKey(instanceID string, body map[string]interface{}) string {
+	b, err := json.Marshal(body)
+	if err != nil {
+		return ""
+	}
+	return instanceID + "\x00" + string(b)
+}
@@ -0,0 +1,27 @@
+package opensearch
+
+import (
+	"os/exec"
+	"strings"
+	"testing"
+)
+
+// TestNoxrayDependencies checks that the package does not depend on the AWS SDK when built with the noxray tag,
+// as SQS and SigV4 are provided by the sqsqueue and awssigv4 packages.
+func TestNoxrayDependencies(t *testing.T) {
+	goTool, err := exec.LookPath("go")
+	if err != nil {
+		t.Skip("go tool not found")
+	}
+
+	out, err := exec.Command(goTool, "list", "-tags", "noxray", "-deps", ".").CombinedOutput()
+	if err != nil {
+		t.Fatalf("go list: %v\n%s", err, out)
+	}
+
+	for _, pkg := range strings.Fields(string(out)) {
+		if strings.HasPrefix(pkg, "github.com/aws/") {
+			t.Errorf("depends on %s when built with the noxray tag", pkg)
+		}
+	}
+}
@@ -0,0 +1,52 @@
+package opensearch
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+
+	"github.com/joshilesanmi/open-search-dev/search"
+	"github.com/rs/zerolog"
+)
+
+// DriverName is the name the OpenSearch driver is registered under, see search.Open.
+const DriverName = "opensearch"
+
+func init() {
+	search.Register(DriverName, openDriver)
+}
+
+// openDriver opens an engine for search.Open. The DSN is the endpoint of the primary cluster; a "secondary" query
+// parameter sets the endpoint of the secondary cluster, e.g.
+// "https://primary:9200?secondary=https://secondary:9200". The "logger" setting is the zerolog.Logger of the
+// engine, which defaults to a disabled logger, and the "options" setting holds further []OpenSearchOption.
+func openDriver(_ context.Context, dsn string, options search.OpenOptions) (search.SearchEngine, error) {
+	u, err := url.Parse(dsn)
+	if err != nil {
+		return nil, fmt.Errorf("invalid dsn: %w", err)
+	}
+
+	var opts []OpenSearchOption
+	if secondary := u.Query().Get("secondary"); secondary != "" {
+		opts = append(opts, WithSecondaryEndpoint(secondary))
+	}
+	u.RawQuery = ""
+
+	logger := zerolog.Nop()
+	if v, ok := options.Settings["logger"]; ok {
+		l, ok := v.(zerolog.Logger)
+		if !ok {
+			return nil, fmt.Errorf("setting \"logger\" must be a zerolog.Logger, got %T", v)
+		}
+		logger = l
+	}
+	if v, ok := options.Settings["options"]; ok {
+		o, ok := v.([]OpenSearchOption)
+		if !ok {
+			return nil, fmt.Errorf("setting \"options\" must be a []OpenSearchOption, got %T", v)
+		}
+		opts = append(opts, o...)
+	}
+
+	return NewOpenSearch(u.String(), logger, opts...)
+}
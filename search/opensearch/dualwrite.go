@@ -0,0 +1,147 @@
+package opensearch
+
+import (
+	"errors"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// WritePolicy governs what happens to a mutating operation when its write to the secondary cluster fails. A
+// failed primary write always fails the operation, and the secondary is not written.
+type WritePolicy int
+
+const (
+	// WriteStrict fails the operation with a *WriteError when the secondary write fails. This is the default.
+	WriteStrict WritePolicy = iota
+
+	// WriteBestEffort suppresses secondary write failures: the operation succeeds once the primary is written.
+	WriteBestEffort
+
+	// WritePrimaryOnlyOnSecondaryFailure suppresses secondary write failures like WriteBestEffort, and then
+	// writes only to the primary for the cooldown of the DualWriteConfig, so a failing secondary does not add
+	// its latency or timeouts to every write.
+	WritePrimaryOnlyOnSecondaryFailure
+)
+
+// DualWriteMetrics receives the secondary writes that did not fail the operation because of the WritePolicy.
+// Suppressed writes leave the secondary cluster behind the primary until it is reconciled.
+type DualWriteMetrics interface {
+	// SecondaryErrorSuppressed is called when a failed secondary write is suppressed.
+	SecondaryErrorSuppressed(cluster string, err error)
+
+	// SecondaryWriteSkipped is called when the secondary is not written because of a recent failure.
+	SecondaryWriteSkipped(cluster string)
+}
+
+// DualWriteConfig configures the handling of secondary write failures.
+type DualWriteConfig struct {
+	Policy   WritePolicy      // What happens when the secondary write fails.
+	Cooldown time.Duration    // How long only the primary is written after a failure. Defaults to 30 seconds.
+	Metrics  DualWriteMetrics // Receives the suppressed and skipped secondary writes, if set.
+}
+
+// WithDualWritePolicy configures the handling of secondary write failures of the mutating operations, including
+// bulk requests. Without it, a failed secondary write fails the operation.
+func WithDualWritePolicy(config DualWriteConfig) OpenSearchOption {
+	return func(os *OpenSearch) error {
+		d, err := newDualWrite(config)
+		if err != nil {
+			return err
+		}
+		os.dualWrite = d
+		return nil
+	}
+}
+
+// newDualWrite validates a DualWriteConfig and applies its defaults.
+func newDualWrite(config DualWriteConfig) (*dualWrite, error) {
+	switch config.Policy {
+	case WriteStrict, WriteBestEffort, WritePrimaryOnlyOnSecondaryFailure:
+	default:
+		return nil, errors.New("unknown write policy")
+	}
+	if config.Cooldown < 0 {
+		return nil, errors.New("dual-write cooldown must not be negative")
+	}
+	if config.Cooldown == 0 {
+		config.Cooldown = 30 * time.Second
+	}
+	return &dualWrite{config: config}, nil
+}
+
+// dualWrite applies the WritePolicy to secondary writes.
+type dualWrite struct {
+	config DualWriteConfig
+
+	suspendedUntil atomic.Int64 // Unix nanoseconds until which only the primary is written.
+}
+
+// skip reports whether the secondary write is skipped because it failed recently.
+func (d *dualWrite) skip(cluster string, now time.Time) bool {
+	if d == nil || d.config.Policy != WritePrimaryOnlyOnSecondaryFailure || now.UnixNano() >= d.suspendedUntil.Load() {
+		return false
+	}
+	if d.config.Metrics != nil {
+		d.config.Metrics.SecondaryWriteSkipped(cluster)
+	}
+	return true
+}
+
+// suppress reports whether a failed secondary write is suppressed rather than failing the operation.
+func (d *dualWrite) suppress(cluster string, err error, now time.Time) bool {
+	if d == nil || d.config.Policy == WriteStrict {
+		return false
+	}
+	if d.config.Policy == WritePrimaryOnlyOnSecondaryFailure {
+		d.suspendedUntil.Store(now.Add(d.config.Cooldown).UnixNano())
+	}
+	if d.config.Metrics != nil {
+		d.config.Metrics.SecondaryErrorSuppressed(cluster, err)
+	}
+	return true
+}
+
+// DualWriteCounter is an in-memory DualWriteMetrics implementation that counts suppressed and skipped secondary
+// writes per cluster. It is safe for concurrent use.
+type DualWriteCounter struct {
+	mu         sync.Mutex
+	suppressed map[string]int64
+	skipped    map[string]int64
+}
+
+// NewDualWriteCounter returns a DualWriteCounter with zero counts.
+func NewDualWriteCounter() *DualWriteCounter {
+	return &DualWriteCounter{
+		suppressed: make(map[string]int64),
+		skipped:    make(map[string]int64),
+	}
+}
+
+// SecondaryErrorSuppressed counts a suppressed secondary write failure.
+func (c *DualWriteCounter) SecondaryErrorSuppressed(cluster string, _ error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.suppressed[cluster]++
+}
+
+// SecondaryWriteSkipped counts a skipped secondary write.
+func (c *DualWriteCounter) SecondaryWriteSkipped(cluster string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.skipped[cluster]++
+}
+
+// Suppressed returns the number of suppressed secondary write failures of a cluster.
+func (c *DualWriteCounter) Suppressed(cluster string) int64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.suppressed[cluster]
+}
+
+// Skipped returns the number of skipped secondary writes of a cluster.
+func (c *DualWriteCounter) Skipped(cluster string) int64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.skipped[cluster]
+}
@@ -0,0 +1,118 @@
+package opensearch
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/joshilesanmi/open-search-dev/search"
+)
+
+// DefaultVectorField is the knn_vector field embeddings are stored in unless configured otherwise.
+const DefaultVectorField = "embedding"
+
+// EmbeddingConfig configures the enrichment of documents with vector embeddings during PutDocument.
+type EmbeddingConfig struct {
+	Embedder    search.Embedder
+	Fields      []string // Text fields whose values are embedded, concatenated in this order.
+	VectorField string   // The knn_vector field receiving the embedding. Defaults to DefaultVectorField.
+	Dimension   int      // The number of dimensions produced by the Embedder.
+}
+
+// WithEmbedding enriches every document passed to PutDocument with an embedding of its configured text fields,
+// so callers do not need to manage embeddings themselves. The index must map the vector field, see ApplyTo.
+func WithEmbedding(config EmbeddingConfig) OpenSearchOption {
+	return func(os *OpenSearch) error {
+		if config.Embedder == nil {
+			return errors.New("embedder must not be nil")
+		}
+		if len(config.Fields) == 0 {
+			return errors.New("at least one field to embed is required")
+		}
+		if config.Dimension <= 0 {
+			return errors.New("embedding dimension must be positive")
+		}
+		if config.VectorField == "" {
+			config.VectorField = DefaultVectorField
+		}
+		os.embedding = &config
+		return nil
+	}
+}
+
+// ApplyTo enables k-NN on an index configuration and maps the vector field as a knn_vector, creating the
+// "settings" and "mappings" sections if needed.
+func (c EmbeddingConfig) ApplyTo(config map[string]interface{}) error {
+	vectorField := c.VectorField
+	if vectorField == "" {
+		vectorField = DefaultVectorField
+	}
+
+	settings, err := section(config, "settings")
+	if err != nil {
+		return err
+	}
+	index, err := section(settings, "index")
+	if err != nil {
+		return err
+	}
+	index["knn"] = true
+
+	mappings, err := section(config, "mappings")
+	if err != nil {
+		return err
+	}
+	properties, err := section(mappings, "properties")
+	if err != nil {
+		return err
+	}
+	properties[vectorField] = map[string]interface{}{
+		"type":      "knn_vector",
+		"dimension": c.Dimension,
+	}
+
+	return nil
+}
+
+// section returns the map stored under key in an index configuration, creating it if needed.
+func section(config map[string]interface{}, key string) (map[string]interface{}, error) {
+	switch s := config[key].(type) {
+	case map[string]interface{}:
+		return s, nil
+	case nil:
+		m := make(map[string]interface{})
+		config[key] = m
+		return m, nil
+	default:
+		return nil, fmt.Errorf("index config %q must be a map", key)
+	}
+}
+
+// embed stores the embedding of the configured text fields of a document in its vector field. Documents
+// without any text in those fields are left unchanged.
+func (os *OpenSearch) embed(ctx context.Context, d search.Document) error {
+	var parts []string
+	for _, field := range os.embedding.Fields {
+		if text, ok := d[field].(string); ok && text != "" {
+			parts = append(parts, text)
+		}
+	}
+	if len(parts) == 0 {
+		return nil
+	}
+
+	vectors, err := os.embedding.Embedder.Embed(ctx, []string{strings.Join(parts, "\n")})
+	if err != nil {
+		return fmt.Errorf("failed to embed document: %w", err)
+	}
+	if len(vectors) != 1 {
+		return fmt.Errorf("embedder returned %d vectors for 1 text", len(vectors))
+	}
+	if len(vectors[0]) != os.embedding.Dimension {
+		return fmt.Errorf("embedder returned %d dimensions, expected %d", len(vectors[0]), os.embedding.Dimension)
+	}
+
+	d[os.embedding.VectorField] = vectors[0]
+	return nil
+}
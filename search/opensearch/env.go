@@ -0,0 +1,126 @@
+package opensearch
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog"
+)
+
+// DefaultEndpoint is the endpoint of the primary cluster used by ConfigFromEnv if OPENSEARCH_ENDPOINT is not set.
+const DefaultEndpoint = "http://localhost:9200"
+
+// ConfigFromEnv returns a Config read from environment variables, for deployments configured by their
+// environment. If OPENSEARCH_CONFIG names a JSON or YAML file, it is loaded first and the variables that are set
+// override it. The variables are:
+//
+//	OPENSEARCH_ENDPOINT              endpoint of the primary cluster, defaults to DefaultEndpoint
+//	OPENSEARCH_PRIMARY_NAME          label of the primary cluster
+//	OPENSEARCH_SECONDARY_ENDPOINT    endpoint of the secondary cluster
+//	OPENSEARCH_SECONDARY_NAME        label of the secondary cluster
+//	OPENSEARCH_USERNAME              basic authentication username
+//	OPENSEARCH_PASSWORD              basic authentication password
+//	OPENSEARCH_CA_CERTIFICATE_FILE   PEM file of the CA certificates of the clusters
+//	OPENSEARCH_INSECURE_SKIP_VERIFY  "true" disables certificate verification
+//	OPENSEARCH_CONNECT_TIMEOUT       e.g. "5s"
+//	OPENSEARCH_REQUEST_TIMEOUT       e.g. "10s"
+//	OPENSEARCH_MAX_ATTEMPTS          attempts of retried operations, see OpenSearchRetryMiddleware
+//	OPENSEARCH_PROTECTED_INDICES     comma-separated glob patterns, see WithProtectedIndices
+func ConfigFromEnv() (Config, error) {
+	return configFromEnv(os.LookupEnv)
+}
+
+// configFromEnv reads a Config with the given lookup function.
+func configFromEnv(lookup func(key string) (string, bool)) (Config, error) {
+	config := Config{Endpoint: DefaultEndpoint}
+	if path, ok := lookup("OPENSEARCH_CONFIG"); ok && path != "" {
+		c, err := LoadConfig(path)
+		if err != nil {
+			return config, err
+		}
+		config = c
+	}
+
+	texts := map[string]*string{
+		"OPENSEARCH_ENDPOINT":            &config.Endpoint,
+		"OPENSEARCH_PRIMARY_NAME":        &config.PrimaryName,
+		"OPENSEARCH_SECONDARY_ENDPOINT":  &config.SecondaryEndpoint,
+		"OPENSEARCH_SECONDARY_NAME":      &config.SecondaryName,
+		"OPENSEARCH_USERNAME":            &config.Auth.Username,
+		"OPENSEARCH_PASSWORD":            &config.Auth.Password,
+		"OPENSEARCH_CA_CERTIFICATE_FILE": &config.Auth.CACertificateFile,
+	}
+	for key, field := range texts {
+		if v, ok := lookup(key); ok {
+			*field = v
+		}
+	}
+
+	if v, ok := lookup("OPENSEARCH_INSECURE_SKIP_VERIFY"); ok {
+		b, err := strconv.ParseBool(v)
+		if err != nil {
+			return config, fmt.Errorf("OPENSEARCH_INSECURE_SKIP_VERIFY: %w", err)
+		}
+		config.Auth.InsecureSkipVerify = b
+	}
+
+	durations := map[string]*Duration{
+		"OPENSEARCH_CONNECT_TIMEOUT": &config.Timeouts.Connect,
+		"OPENSEARCH_REQUEST_TIMEOUT": &config.Timeouts.Request,
+	}
+	for key, field := range durations {
+		if v, ok := lookup(key); ok {
+			d, err := time.ParseDuration(v)
+			if err != nil {
+				return config, fmt.Errorf("%s: %w", key, err)
+			}
+			if d <= 0 {
+				return config, fmt.Errorf("%s must be positive", key)
+			}
+			*field = Duration(d)
+		}
+	}
+
+	if v, ok := lookup("OPENSEARCH_MAX_ATTEMPTS"); ok {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 {
+			return config, fmt.Errorf("OPENSEARCH_MAX_ATTEMPTS must be a positive integer, got %q", v)
+		}
+		if config.Retry.Requests == nil {
+			config.Retry.Requests = &RequestRetrySettings{}
+		}
+		config.Retry.Requests.MaxAttempts = n
+	}
+
+	if v, ok := lookup("OPENSEARCH_PROTECTED_INDICES"); ok {
+		config.ProtectedIndices = splitList(v)
+	}
+
+	if err := config.Validate(); err != nil {
+		return config, fmt.Errorf("environment: %w", err)
+	}
+	return config, nil
+}
+
+// NewFromEnv creates an engine from environment variables, see ConfigFromEnv and Config.NewEngine.
+func NewFromEnv(logger zerolog.Logger, opts ...OpenSearchOption) (Engine, error) {
+	config, err := ConfigFromEnv()
+	if err != nil {
+		return nil, err
+	}
+	return config.NewEngine(logger, opts...)
+}
+
+// splitList splits a comma-separated list, dropping blank entries.
+func splitList(s string) []string {
+	var items []string
+	for _, item := range strings.Split(s, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
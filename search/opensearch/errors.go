@@ -0,0 +1,198 @@
+package opensearch
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/joshilesanmi/open-search-dev/search"
+	opensearchapi "github.com/opensearch-project/opensearch-go/v2/opensearchapi"
+)
+
+const (
+	// PrimaryCluster identifies the primary OpenSearch cluster in errors.
+	PrimaryCluster = "primary"
+
+	// SecondaryCluster identifies the secondary OpenSearch cluster in errors.
+	SecondaryCluster = "secondary"
+)
+
+// ClusterError records the failure of an operation against a single cluster.
+type ClusterError struct {
+	Cluster string // The role of the cluster the operation failed on, e.g. PrimaryCluster.
+	Name    string // The configured label of the cluster, e.g. "eu-prod".
+	Err     error  // The underlying error.
+}
+
+func (e *ClusterError) Error() string {
+	if e.Name == "" || e.Name == e.Cluster {
+		return fmt.Sprintf("%s client: %v", e.Cluster, e.Err)
+	}
+	return fmt.Sprintf("%s client (%s): %v", e.Cluster, e.Name, e.Err)
+}
+
+func (e *ClusterError) Unwrap() error {
+	return e.Err
+}
+
+// WriteError is returned by mutating operations when the write failed on at least one cluster. It identifies
+// which clusters failed and whether the primary write succeeded, so callers can tell if the primary cluster
+// is still consistent with the request.
+type WriteError struct {
+	PrimaryWritten bool            // True if the write was applied to the primary cluster.
+	Errors         []*ClusterError // One entry per failed cluster.
+}
+
+func (e *WriteError) Error() string {
+	msgs := make([]string, 0, len(e.Errors))
+	for _, err := range e.Errors {
+		msgs = append(msgs, err.Error())
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// Unwrap exposes the per-cluster errors to errors.Is and errors.As.
+func (e *WriteError) Unwrap() []error {
+	errs := make([]error, 0, len(e.Errors))
+	for _, err := range e.Errors {
+		errs = append(errs, err)
+	}
+	return errs
+}
+
+// Failed reports whether the write failed on the named cluster.
+func (e *WriteError) Failed(cluster string) bool {
+	for _, err := range e.Errors {
+		if err.Cluster == cluster {
+			return true
+		}
+	}
+	return false
+}
+
+// ErrConflict matches APIErrors for requests rejected because of a version conflict.
+var ErrConflict = errors.New("version conflict")
+
+// ErrTooManyRequests matches APIErrors for requests rejected because the cluster is overloaded.
+var ErrTooManyRequests = errors.New("too many requests")
+
+// ErrIndexNotFound matches APIErrors for requests targeting an index that does not exist.
+var ErrIndexNotFound = errors.New("index not found")
+
+// ErrQueryNotAllowed is returned when a query asks for a query mode or fields that WithQueryMode does not permit.
+var ErrQueryNotAllowed = errors.New("query not allowed")
+
+// APIError is an error response returned by a cluster. It matches ErrConflict, ErrTooManyRequests,
+// ErrIndexNotFound and ErrDocumentNotFound with errors.Is, according to its status and type.
+type APIError struct {
+	StatusCode int    // The HTTP status of the response.
+	Type       string // The error type reported by the cluster, e.g. "version_conflict_engine_exception".
+	Reason     string // The human-readable reason reported by the cluster.
+	Index      string // The index the error refers to, if any.
+
+	// RetryAfter is the delay the cluster asked for before retrying, parsed from the Retry-After header of
+	// throttled responses. Zero if the response carried no hint.
+	RetryAfter time.Duration
+}
+
+func (e *APIError) Error() string {
+	msg := fmt.Sprintf("request failed with status %d", e.StatusCode)
+	if e.Type != "" {
+		msg += ": " + e.Type
+	}
+	if e.Reason != "" {
+		msg += ": " + e.Reason
+	}
+	if e.Index != "" {
+		msg += fmt.Sprintf(" (index %s)", e.Index)
+	}
+	if e.RetryAfter > 0 {
+		msg += fmt.Sprintf(" (retry after %s)", e.RetryAfter)
+	}
+	return msg
+}
+
+// Is matches the sentinel errors corresponding to the status and type of the error.
+func (e *APIError) Is(target error) bool {
+	switch target {
+	case ErrConflict:
+		return e.StatusCode == http.StatusConflict
+	case ErrTooManyRequests:
+		return e.StatusCode == http.StatusTooManyRequests
+	case ErrIndexNotFound:
+		return e.Type == "index_not_found_exception"
+	case search.ErrDocumentNotFound:
+		// A missing index yields no documents either.
+		return e.StatusCode == http.StatusNotFound
+	}
+	return false
+}
+
+// newAPIError builds an APIError from an error response received at now, consuming its body.
+func newAPIError(resp *opensearchapi.Response, now time.Time) *APIError {
+	defer resp.Body.Close()
+
+	apiErr := &APIError{
+		StatusCode: resp.StatusCode,
+		RetryAfter: parseRetryAfter(resp.Header.Get("Retry-After"), now),
+	}
+
+	b, err := io.ReadAll(resp.Body)
+	if err != nil || len(b) == 0 {
+		return apiErr
+	}
+
+	var r struct {
+		Error json.RawMessage `json:"error"`
+	}
+	if err := json.Unmarshal(b, &r); err != nil || len(r.Error) == 0 {
+		return apiErr
+	}
+
+	var detail struct {
+		Type   string `json:"type"`
+		Reason string `json:"reason"`
+		Index  string `json:"index"`
+	}
+	if err := json.Unmarshal(r.Error, &detail); err != nil {
+		// Some endpoints report the error as a plain string.
+		var reason string
+		if json.Unmarshal(r.Error, &reason) == nil {
+			apiErr.Reason = reason
+		}
+		return apiErr
+	}
+
+	apiErr.Type = detail.Type
+	apiErr.Reason = detail.Reason
+	apiErr.Index = detail.Index
+
+	return apiErr
+}
+
+// parseRetryAfter parses the value of a Retry-After header, which is either a number of seconds or an HTTP date.
+// Invalid values and dates in the past yield zero.
+func parseRetryAfter(value string, now time.Time) time.Duration {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return 0
+	}
+
+	if seconds, err := strconv.Atoi(value); err == nil {
+		if seconds < 0 {
+			return 0
+		}
+		return time.Duration(seconds) * time.Second
+	}
+
+	if t, err := http.ParseTime(value); err == nil && t.After(now) {
+		return t.Sub(now)
+	}
+
+	return 0
+}
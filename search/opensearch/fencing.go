@@ -0,0 +1,127 @@
+package opensearch
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+
+	"github.com/joshilesanmi/open-search-dev/search"
+)
+
+// ErrReadOnly is returned by mutating operations while writes are fenced.
+var ErrReadOnly = search.ErrReadOnly
+
+// WithReadOnlyMode starts the OpenSearch instance with writes fenced. See SetReadOnlyMode.
+func WithReadOnlyMode() OpenSearchOption {
+	return func(os *OpenSearch) error {
+		os.readOnly.Store(true)
+		return nil
+	}
+}
+
+// SetReadOnlyMode enables or disables read-only mode. While enabled, every mutating operation fails fast with
+// ErrReadOnly without contacting the clusters. It is safe to call concurrently with other operations.
+func (os *OpenSearch) SetReadOnlyMode(enabled bool) {
+	os.readOnly.Store(enabled)
+}
+
+// ReadOnlyMode reports whether read-only mode is enabled.
+func (os *OpenSearch) ReadOnlyMode() bool {
+	return os.readOnly.Load()
+}
+
+// BlockWrites fences writes to a single index, so mutating operations on it fail fast with ErrReadOnly.
+func (os *OpenSearch) BlockWrites(indexName string) {
+	os.fenceMu.Lock()
+	defer os.fenceMu.Unlock()
+
+	if os.blockedIndices == nil {
+		os.blockedIndices = make(map[string]struct{})
+	}
+	os.blockedIndices[indexName] = struct{}{}
+}
+
+// UnblockWrites removes the write fence from a single index.
+func (os *OpenSearch) UnblockWrites(indexName string) {
+	os.fenceMu.Lock()
+	defer os.fenceMu.Unlock()
+
+	delete(os.blockedIndices, indexName)
+}
+
+// BlockedIndices returns the names of the indices whose writes are currently fenced, sorted by name.
+func (os *OpenSearch) BlockedIndices() []string {
+	os.fenceMu.RLock()
+	defer os.fenceMu.RUnlock()
+
+	names := make([]string, 0, len(os.blockedIndices))
+	for name := range os.blockedIndices {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return names
+}
+
+// checkWritable returns ErrReadOnly if writes to the index are fenced.
+func (os *OpenSearch) checkWritable(indexName string) error {
+	if os.readOnly.Load() {
+		return fmt.Errorf("index %q: %w", indexName, ErrReadOnly)
+	}
+
+	os.fenceMu.RLock()
+	defer os.fenceMu.RUnlock()
+
+	if _, ok := os.blockedIndices[indexName]; ok {
+		return fmt.Errorf("index %q: %w", indexName, ErrReadOnly)
+	}
+
+	return nil
+}
+
+// ReadOnlyHandler returns an admin http.Handler for toggling write fencing at runtime.
+//
+//	GET  /                          reports the current state
+//	POST /?enabled=true             enables read-only mode
+//	POST /?index=name&enabled=true  blocks writes to a single index
+//
+// Passing enabled=false disables the corresponding fence. The handler must only be mounted on an
+// internal admin listener.
+func ReadOnlyHandler(engine Engine) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+		case http.MethodPost:
+			enabled, err := strconv.ParseBool(r.URL.Query().Get("enabled"))
+			if err != nil {
+				http.Error(w, "enabled must be true or false", http.StatusBadRequest)
+				return
+			}
+
+			if index := r.URL.Query().Get("index"); index != "" {
+				if enabled {
+					engine.BlockWrites(index)
+				} else {
+					engine.UnblockWrites(index)
+				}
+			} else {
+				engine.SetReadOnlyMode(enabled)
+			}
+		default:
+			w.Header().Set("Allow", "GET, POST")
+			http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(struct {
+			ReadOnly       bool     `json:"read_only"`
+			BlockedIndices []string `json:"blocked_indices"`
+		}{
+			ReadOnly:       engine.ReadOnlyMode(),
+			BlockedIndices: engine.BlockedIndices(),
+		})
+	})
+}
@@ -0,0 +1,47 @@
+package opensearch
+
+import (
+	"strings"
+)
+
+// MapGeoPoint maps the given fields of an index configuration as geo_point, so they can be used with the
+// search.GeoDistance and search.GeoBoundingBox filters. Dotted names map fields nested in objects, e.g.
+// "address.location". The "mappings" section is created if needed.
+func MapGeoPoint(config map[string]interface{}, fields ...string) error {
+	for _, field := range fields {
+		properties, name, err := fieldProperties(config, field)
+		if err != nil {
+			return err
+		}
+		properties[name] = map[string]interface{}{"type": "geo_point"}
+	}
+
+	return nil
+}
+
+// fieldProperties returns the properties mapping that holds a possibly dotted field of an index configuration,
+// along with the last segment of the field name. Missing "mappings", object and "properties" sections are created.
+func fieldProperties(config map[string]interface{}, field string) (map[string]interface{}, string, error) {
+	mappings, err := section(config, "mappings")
+	if err != nil {
+		return nil, "", err
+	}
+
+	properties, err := section(mappings, "properties")
+	if err != nil {
+		return nil, "", err
+	}
+
+	names := strings.Split(field, ".")
+	for _, name := range names[:len(names)-1] {
+		object, err := section(properties, name)
+		if err != nil {
+			return nil, "", err
+		}
+		if properties, err = section(object, "properties"); err != nil {
+			return nil, "", err
+		}
+	}
+
+	return properties, names[len(names)-1], nil
+}
@@ -0,0 +1,255 @@
+package opensearch
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/joshilesanmi/open-search-dev/search"
+	opensearch "github.com/opensearch-project/opensearch-go/v2"
+	opensearchapi "github.com/opensearch-project/opensearch-go/v2/opensearchapi"
+)
+
+// Fusion selects how HybridSearch combines lexical and vector scores.
+type Fusion int
+
+const (
+	// FusionWeighted min-max normalizes the scores of each result list and combines them with a weighted sum.
+	FusionWeighted Fusion = iota
+	// FusionRRF combines the result lists by reciprocal rank, ignoring the raw scores.
+	FusionRRF
+)
+
+// rrfRankConstant dampens the influence of the top ranks in reciprocal rank fusion.
+const rrfRankConstant = 60
+
+// HybridOption is a function type that applies configuration options to a HybridOptions instance.
+type HybridOption func(*HybridOptions)
+
+// HybridOptions defines configuration options for hybrid searches.
+type HybridOptions struct {
+	Size          int     // Number of results to return. Defaults to 10.
+	LexicalWeight float64 // Weight of the lexical score in weighted fusion, between 0 and 1. Defaults to 0.5.
+	Fusion        Fusion  // How the result lists are combined. Defaults to FusionWeighted.
+}
+
+// WithHybridSize returns a HybridOption that sets the number of results to return.
+func WithHybridSize(size int) HybridOption {
+	return func(opts *HybridOptions) {
+		opts.Size = size
+	}
+}
+
+// WithLexicalWeight returns a HybridOption that sets the weight of the lexical score in weighted fusion. The
+// vector score receives the remaining weight.
+func WithLexicalWeight(weight float64) HybridOption {
+	return func(opts *HybridOptions) {
+		opts.LexicalWeight = weight
+	}
+}
+
+// WithFusion returns a HybridOption that selects how the lexical and vector result lists are combined.
+func WithFusion(fusion Fusion) HybridOption {
+	return func(opts *HybridOptions) {
+		opts.Fusion = fusion
+	}
+}
+
+// searchHit is a single hit of a search response.
+type searchHit struct {
+	ID     string          `json:"_id"`
+	Score  float64         `json:"_score"`
+	Source search.Document `json:"_source"`
+}
+
+// HybridSearch combines the lexical query used by Search with a k-NN query on the embedding of the query text,
+// so relevance benefits from both lexical and semantic signals. Both queries are restricted to the instance.
+// It requires the engine to be configured WithEmbedding, whose Embedder is used to embed the query text.
+func (os *OpenSearch) HybridSearch(ctx context.Context, instanceID string, query search.Query, opts ...HybridOption) ([]search.Document, error) {
+	if os.embedding == nil {
+		return nil, errors.New("hybrid search requires an embedding configuration")
+	}
+
+	options := &HybridOptions{Size: 10, LexicalWeight: 0.5}
+	for _, opt := range opts {
+		opt(options)
+	}
+	if options.LexicalWeight < 0 || options.LexicalWeight > 1 {
+		return nil, fmt.Errorf("lexical weight must be between 0 and 1, got %v", options.LexicalWeight)
+	}
+
+	vectors, err := os.embedding.Embedder.Embed(ctx, []string{query.Value})
+	if err != nil {
+		return nil, fmt.Errorf("failed to embed query: %w", err)
+	}
+	if len(vectors) != 1 {
+		return nil, fmt.Errorf("embedder returned %d vectors for 1 text", len(vectors))
+	}
+
+	ctx, release, err := os.acquire(ctx, query.Priority)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	read, preference, err := os.readCluster(ctx, ReadSearch)
+	if err != nil {
+		return nil, err
+	}
+
+	// Fetch more candidates than requested from each side, so documents ranked lower by one signal can still
+	// surface after fusion.
+	candidates := options.Size * 2
+
+	lexical, err := os.constructSearchQuery(instanceID, query)
+	if err != nil {
+		return nil, err
+	}
+	lexical["size"] = candidates
+
+	vector := map[string]interface{}{
+		"size": candidates,
+		"query": map[string]interface{}{
+			"bool": map[string]interface{}{
+				"must": map[string]interface{}{
+					"knn": map[string]interface{}{
+						os.embedding.VectorField: map[string]interface{}{
+							"vector": vectors[0],
+							"k":      candidates,
+						},
+					},
+				},
+				"filter": search.InstanceFilter(instanceID),
+			},
+		},
+	}
+
+	os.auditQuery(instanceID, vector)
+	recordSentQuery(ctx, map[string]interface{}{"lexical": lexical, "vector": vector})
+
+	lexicalHits, err := os.searchHits(ctx, read.client, lexical, preference)
+	if err != nil {
+		return nil, fmt.Errorf("lexical query: %w", err)
+	}
+
+	vectorHits, err := os.searchHits(ctx, read.client, vector, preference)
+	if err != nil {
+		return nil, fmt.Errorf("vector query: %w", err)
+	}
+
+	fused := fuse(lexicalHits, vectorHits, options)
+	if len(fused) > options.Size {
+		fused = fused[:options.Size]
+	}
+
+	documents := make([]search.Document, 0, len(fused))
+	for _, hit := range fused {
+		os.presentDocument(hit.Source)
+		documents = append(documents, hit.Source)
+	}
+
+	return documents, nil
+}
+
+// fuse combines two ranked hit lists into one, ordered by the fused score.
+func fuse(lexical, vector []searchHit, options *HybridOptions) []searchHit {
+	scores := make(map[string]float64)
+	hits := make(map[string]searchHit)
+
+	add := func(list []searchHit, weight float64) {
+		normalized := normalizeScores(list)
+		for rank, hit := range list {
+			switch options.Fusion {
+			case FusionRRF:
+				scores[hit.ID] += 1 / float64(rrfRankConstant+rank+1)
+			default:
+				scores[hit.ID] += weight * normalized[rank]
+			}
+			if _, ok := hits[hit.ID]; !ok {
+				hits[hit.ID] = hit
+			}
+		}
+	}
+
+	add(lexical, options.LexicalWeight)
+	add(vector, 1-options.LexicalWeight)
+
+	fused := make([]searchHit, 0, len(hits))
+	for id, hit := range hits {
+		hit.Score = scores[id]
+		fused = append(fused, hit)
+	}
+
+	sort.SliceStable(fused, func(i, j int) bool {
+		if fused[i].Score != fused[j].Score {
+			return fused[i].Score > fused[j].Score
+		}
+		return fused[i].ID < fused[j].ID
+	})
+
+	return fused
+}
+
+// normalizeScores min-max normalizes the scores of a hit list into the range [0, 1].
+func normalizeScores(hits []searchHit) []float64 {
+	normalized := make([]float64, len(hits))
+	if len(hits) == 0 {
+		return normalized
+	}
+
+	lo, hi := hits[0].Score, hits[0].Score
+	for _, h := range hits {
+		if h.Score < lo {
+			lo = h.Score
+		}
+		if h.Score > hi {
+			hi = h.Score
+		}
+	}
+
+	for i, h := range hits {
+		if hi == lo {
+			normalized[i] = 1
+			continue
+		}
+		normalized[i] = (h.Score - lo) / (hi - lo)
+	}
+
+	return normalized
+}
+
+// searchHits executes a search request body and returns its hits.
+func (os *OpenSearch) searchHits(ctx context.Context, client *opensearch.Client, body map[string]interface{}, preference string) ([]searchHit, error) {
+	b, err := json.Marshal(body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal search query: %v", err)
+	}
+
+	header, done := os.cancellable(ctx, client)
+	defer done()
+
+	req := opensearchapi.SearchRequest{
+		Body:       bytes.NewReader(b),
+		Preference: preference,
+		Header:     header,
+	}
+
+	resp, err := os.executeReadRequest(ctx, client, req)
+	if err != nil {
+		return nil, err
+	}
+
+	var r struct {
+		Hits struct {
+			Hits []searchHit `json:"hits"`
+		} `json:"hits"`
+	}
+	if err := os.decodeResponse(resp, &r); err != nil {
+		return nil, err
+	}
+
+	return r.Hits.Hits, nil
+}
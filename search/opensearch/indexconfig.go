@@ -0,0 +1,96 @@
+package opensearch
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ValidateIndexConfig checks the structure of an index configuration as passed to CreateIndex, so mistakes such
+// as a misspelt section or a property without a type are reported before any cluster is contacted rather than
+// as a mapper_parsing_exception. It does not check field types or settings values, which only the cluster
+// knows; a typed Analysis placed with Analysis.ApplyTo is validated too.
+func ValidateIndexConfig(config map[string]interface{}) error {
+	for key, value := range config {
+		switch key {
+		case "settings", "mappings", "aliases":
+			if _, ok := value.(map[string]interface{}); !ok {
+				return fmt.Errorf("%s must be an object", key)
+			}
+		default:
+			return fmt.Errorf("unknown section %q, expected settings, mappings or aliases", key)
+		}
+	}
+
+	if analysis := analysisFromConfig(config); analysis != nil {
+		if err := analysis.Validate(); err != nil {
+			return fmt.Errorf("invalid index analysis: %w", err)
+		}
+	}
+
+	mappings, _ := config["mappings"].(map[string]interface{})
+	if properties, ok := mappings["properties"]; ok {
+		p, ok := properties.(map[string]interface{})
+		if !ok {
+			return errors.New("mappings.properties must be an object")
+		}
+		if err := validateProperties(p, "mappings.properties."); err != nil {
+			return err
+		}
+	}
+
+	if templates, ok := mappings["dynamic_templates"]; ok {
+		list, ok := templates.([]interface{})
+		if !ok {
+			return errors.New("mappings.dynamic_templates must be an array")
+		}
+		for i, t := range list {
+			template, ok := t.(map[string]interface{})
+			if !ok || len(template) != 1 {
+				return fmt.Errorf("mappings.dynamic_templates[%d] must be an object with a single named template", i)
+			}
+			for name, body := range template {
+				b, ok := body.(map[string]interface{})
+				if !ok {
+					return fmt.Errorf("dynamic template %q must be an object", name)
+				}
+				if _, ok := b["mapping"].(map[string]interface{}); !ok {
+					return fmt.Errorf("dynamic template %q has no mapping", name)
+				}
+			}
+		}
+	}
+
+	return nil
+}
+
+// validateProperties checks that every property has a type or nested properties.
+func validateProperties(properties map[string]interface{}, path string) error {
+	for name, p := range properties {
+		mapping, ok := p.(map[string]interface{})
+		if !ok {
+			return fmt.Errorf("%s%s must be an object", path, name)
+		}
+
+		typ, hasType := mapping["type"]
+		if hasType {
+			if s, ok := typ.(string); !ok || s == "" {
+				return fmt.Errorf("%s%s.type must be a non-empty string", path, name)
+			}
+		}
+
+		nested, hasProperties := mapping["properties"]
+		if !hasType && !hasProperties {
+			return fmt.Errorf("%s%s has neither a type nor properties", path, name)
+		}
+		if hasProperties {
+			n, ok := nested.(map[string]interface{})
+			if !ok {
+				return fmt.Errorf("%s%s.properties must be an object", path, name)
+			}
+			if err := validateProperties(n, path+name+".properties."); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
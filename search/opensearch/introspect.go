@@ -0,0 +1,158 @@
+package opensearch
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+
+	opensearchapi "github.com/opensearch-project/opensearch-go/v2/opensearchapi"
+)
+
+// IndexInfo summarises an index as listed by ListIndices.
+type IndexInfo struct {
+	Name           string
+	Health         string // "green", "yellow" or "red".
+	Status         string // "open" or "close".
+	Primaries      int    // Number of primary shards.
+	Replicas       int    // Number of replicas of every primary shard.
+	DocsCount      int64  // Documents of the primary shards, counting nested objects as documents.
+	StoreSizeBytes int64  // Storage of all shards, including replicas.
+}
+
+// IndexDescription is the configuration and size of an index, as returned by DescribeIndex.
+type IndexDescription struct {
+	Name           string
+	Aliases        []string
+	Mappings       map[string]interface{}
+	Settings       map[string]interface{}
+	DocsCount      int64 // Documents of the primary shards.
+	DeletedDocs    int64 // Deleted documents of the primary shards not yet merged away.
+	StoreSizeBytes int64 // Storage of the primary shards.
+}
+
+// ListIndices returns the indices whose names match a pattern such as "people-*", sorted by name. An empty
+// pattern lists every index. The indices are read from the cluster serving reports, see WithReadRoute, unless
+// the context targets a cluster with search.WithCluster.
+func (os *OpenSearch) ListIndices(ctx context.Context, pattern string) ([]IndexInfo, error) {
+	if pattern == "" {
+		pattern = "*"
+	}
+
+	c, _, err := os.readCluster(ctx, ReadReport)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := os.executeReadRequest(ctx, c.client, opensearchapi.CatIndicesRequest{
+		Index:  []string{pattern},
+		Format: "json",
+		Bytes:  "b",
+	})
+	if err != nil {
+		return nil, &ClusterError{Cluster: c.role, Name: c.name, Err: err}
+	}
+
+	// The cat API reports every value as a string.
+	var rows []struct {
+		Index     string `json:"index"`
+		Health    string `json:"health"`
+		Status    string `json:"status"`
+		Pri       string `json:"pri"`
+		Rep       string `json:"rep"`
+		DocsCount string `json:"docs.count"`
+		StoreSize string `json:"store.size"`
+	}
+	if err := os.decodeResponse(resp, &rows); err != nil {
+		return nil, err
+	}
+
+	indices := make([]IndexInfo, 0, len(rows))
+	for _, r := range rows {
+		indices = append(indices, IndexInfo{
+			Name:           r.Index,
+			Health:         r.Health,
+			Status:         r.Status,
+			Primaries:      int(parseCount(r.Pri)),
+			Replicas:       int(parseCount(r.Rep)),
+			DocsCount:      parseCount(r.DocsCount),
+			StoreSizeBytes: parseCount(r.StoreSize),
+		})
+	}
+	sort.Slice(indices, func(i, j int) bool {
+		return indices[i].Name < indices[j].Name
+	})
+
+	return indices, nil
+}
+
+// DescribeIndex returns the mappings, settings, aliases and size of an index, or of the index an alias points
+// to. It is read from the same cluster as ListIndices.
+func (os *OpenSearch) DescribeIndex(ctx context.Context, indexName string) (IndexDescription, error) {
+	c, _, err := os.readCluster(ctx, ReadReport)
+	if err != nil {
+		return IndexDescription{}, err
+	}
+
+	resp, err := os.executeReadRequest(ctx, c.client, opensearchapi.IndicesGetRequest{Index: []string{indexName}})
+	if err != nil {
+		return IndexDescription{}, &ClusterError{Cluster: c.role, Name: c.name, Err: err}
+	}
+
+	var indices map[string]struct {
+		Aliases  map[string]interface{} `json:"aliases"`
+		Mappings map[string]interface{} `json:"mappings"`
+		Settings map[string]interface{} `json:"settings"`
+	}
+	if err := os.decodeResponse(resp, &indices); err != nil {
+		return IndexDescription{}, err
+	}
+	if len(indices) != 1 {
+		return IndexDescription{}, fmt.Errorf("%q resolves to %d indices, describe them one at a time", indexName, len(indices))
+	}
+
+	var d IndexDescription
+	for name, index := range indices {
+		d = IndexDescription{Name: name, Mappings: index.Mappings, Settings: index.Settings}
+		for alias := range index.Aliases {
+			d.Aliases = append(d.Aliases, alias)
+		}
+		sort.Strings(d.Aliases)
+	}
+
+	resp, err = os.executeReadRequest(ctx, c.client, opensearchapi.IndicesStatsRequest{
+		Index:  []string{d.Name},
+		Metric: []string{"docs", "store"},
+	})
+	if err != nil {
+		return d, &ClusterError{Cluster: c.role, Name: c.name, Err: err}
+	}
+
+	var stats struct {
+		All struct {
+			Primaries struct {
+				Docs struct {
+					Count   int64 `json:"count"`
+					Deleted int64 `json:"deleted"`
+				} `json:"docs"`
+				Store struct {
+					SizeInBytes int64 `json:"size_in_bytes"`
+				} `json:"store"`
+			} `json:"primaries"`
+		} `json:"_all"`
+	}
+	if err := os.decodeResponse(resp, &stats); err != nil {
+		return d, err
+	}
+	d.DocsCount = stats.All.Primaries.Docs.Count
+	d.DeletedDocs = stats.All.Primaries.Docs.Deleted
+	d.StoreSizeBytes = stats.All.Primaries.Store.SizeInBytes
+
+	return d, nil
+}
+
+// parseCount parses a number reported by the cat API, which reports closed indices without counts.
+func parseCount(s string) int64 {
+	n, _ := strconv.ParseInt(s, 10, 64)
+	return n
+}
@@ -0,0 +1,220 @@
+package opensearch
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Language is a natural language for which a text analyzer can be generated.
+type Language string
+
+const (
+	LanguageEnglish Language = "english"
+	LanguageGerman  Language = "german"
+	LanguageFrench  Language = "french"
+)
+
+// LanguageAnalyzer returns the name of the analyzer generated for a language by ApplyLanguage.
+func LanguageAnalyzer(lang Language) string {
+	return "text_" + string(lang)
+}
+
+// ApplyLanguage configures an index configuration for text in the given language. It declares a custom
+// analyzer (stop words, stemming and, where relevant, elision or normalization of umlauts) in the typed Analysis
+// of the configuration, creating it if needed, and sets it as the analyzer of every dynamic template and
+// property mapped as "text".
+func ApplyLanguage(config map[string]interface{}, lang Language) error {
+	components, err := languageComponents(lang)
+	if err != nil {
+		return err
+	}
+
+	analysis := analysisFromConfig(config)
+	if analysis == nil {
+		if settings, ok := config["settings"].(map[string]interface{}); ok && settings["analysis"] != nil {
+			return errors.New("index config already has untyped analysis settings")
+		}
+		analysis = NewAnalysis()
+		if err := analysis.ApplyTo(config); err != nil {
+			return err
+		}
+	}
+
+	var filters []string
+	for _, c := range components {
+		if !c.builtin {
+			analysis.Filter(c.name, c.component)
+		}
+		filters = append(filters, c.name)
+	}
+
+	name := LanguageAnalyzer(lang)
+	analysis.Analyzer(name, Analyzer{
+		Tokenizer: "standard",
+		Filters:   filters,
+	})
+
+	mappings, ok := config["mappings"].(map[string]interface{})
+	if !ok {
+		return nil
+	}
+
+	if templates, ok := mappings["dynamic_templates"].([]interface{}); ok {
+		for _, t := range templates {
+			template, ok := t.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			for _, def := range template {
+				def, ok := def.(map[string]interface{})
+				if !ok {
+					continue
+				}
+				if mapping, ok := def["mapping"].(map[string]interface{}); ok {
+					setTextAnalyzer(mapping, name)
+				}
+			}
+		}
+	}
+
+	if properties, ok := mappings["properties"].(map[string]interface{}); ok {
+		setPropertiesAnalyzer(properties, name)
+	}
+
+	return nil
+}
+
+// IndexConfigWithLanguage returns a copy of an index configuration with ApplyLanguage applied, leaving the
+// configuration, and the typed Analysis it may hold, unchanged.
+func IndexConfigWithLanguage(config map[string]interface{}, lang Language) (map[string]interface{}, error) {
+	out := copyConfigMap(config)
+	if analysis := analysisFromConfig(config); analysis != nil {
+		clone := NewAnalysis()
+		for name, c := range analysis.CharFilters {
+			clone.CharFilter(name, c)
+		}
+		for name, c := range analysis.Tokenizers {
+			clone.Tokenizer(name, c)
+		}
+		for name, c := range analysis.Filters {
+			clone.Filter(name, c)
+		}
+		for name, an := range analysis.Analyzers {
+			clone.Analyzer(name, an)
+		}
+		if err := clone.ApplyTo(out); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := ApplyLanguage(out, lang); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// copyConfigMap returns a deep copy of the objects and arrays of a configuration; other values are shared.
+func copyConfigMap(m map[string]interface{}) map[string]interface{} {
+	out := make(map[string]interface{}, len(m))
+	for k, v := range m {
+		out[k] = copyConfigValue(v)
+	}
+	return out
+}
+
+func copyConfigValue(v interface{}) interface{} {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		return copyConfigMap(v)
+	case []interface{}:
+		out := make([]interface{}, len(v))
+		for i, e := range v {
+			out[i] = copyConfigValue(e)
+		}
+		return out
+	default:
+		return v
+	}
+}
+
+// setPropertiesAnalyzer sets the analyzer of every text property, descending into object properties.
+func setPropertiesAnalyzer(properties map[string]interface{}, analyzer string) {
+	for _, p := range properties {
+		mapping, ok := p.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		setTextAnalyzer(mapping, analyzer)
+		if nested, ok := mapping["properties"].(map[string]interface{}); ok {
+			setPropertiesAnalyzer(nested, analyzer)
+		}
+	}
+}
+
+// setTextAnalyzer sets the analyzer of a field mapping if it is of type text.
+func setTextAnalyzer(mapping map[string]interface{}, analyzer string) {
+	if mapping["type"] == "text" {
+		mapping["analyzer"] = analyzer
+	}
+}
+
+// languageComponent is a token filter used by a language analyzer.
+type languageComponent struct {
+	name      string
+	builtin   bool // Built-in filters are referenced by name and not declared.
+	component Component
+}
+
+// languageComponents returns the token filters of the analyzer for a language, in order.
+func languageComponents(lang Language) ([]languageComponent, error) {
+	prefix := string(lang) + "_"
+
+	stop := languageComponent{
+		name:      prefix + "stop",
+		component: Component{Type: "stop", Params: map[string]interface{}{"stopwords": "_" + string(lang) + "_"}},
+	}
+	stemmer := func(language string) languageComponent {
+		return languageComponent{
+			name:      prefix + "stemmer",
+			component: Component{Type: "stemmer", Params: map[string]interface{}{"language": language}},
+		}
+	}
+	lowercase := languageComponent{name: "lowercase", builtin: true}
+
+	switch lang {
+	case LanguageEnglish:
+		return []languageComponent{
+			{
+				name:      prefix + "possessive_stemmer",
+				component: Component{Type: "stemmer", Params: map[string]interface{}{"language": "possessive_english"}},
+			},
+			lowercase,
+			stop,
+			stemmer("english"),
+		}, nil
+	case LanguageGerman:
+		return []languageComponent{
+			lowercase,
+			stop,
+			{name: "german_normalization", builtin: true},
+			stemmer("light_german"),
+		}, nil
+	case LanguageFrench:
+		return []languageComponent{
+			{
+				name: prefix + "elision",
+				component: Component{Type: "elision", Params: map[string]interface{}{
+					"articles_case": true,
+					"articles": []string{
+						"l", "m", "t", "qu", "n", "s", "j", "d", "c", "jusqu", "quoiqu", "lorsqu", "puisqu",
+					},
+				}},
+			},
+			lowercase,
+			stop,
+			stemmer("light_french"),
+		}, nil
+	default:
+		return nil, fmt.Errorf("unsupported language %q", lang)
+	}
+}
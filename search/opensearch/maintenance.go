@@ -0,0 +1,63 @@
+package opensearch
+
+import (
+	"context"
+
+	opensearch "github.com/opensearch-project/opensearch-go/v2"
+	opensearchapi "github.com/opensearch-project/opensearch-go/v2/opensearchapi"
+)
+
+// ForceMergeOption is a function type that applies configuration options to a ForceMergeOptions instance.
+type ForceMergeOption func(*ForceMergeOptions)
+
+// ForceMergeOptions defines configuration options for force merge operations.
+type ForceMergeOptions struct {
+	MaxNumSegments     int  // The number of segments to merge down to. Zero leaves the decision to the cluster.
+	OnlyExpungeDeletes bool // If true, only segments containing deleted documents are merged.
+}
+
+// WithMaxNumSegments returns a ForceMergeOption that sets the number of segments to merge down to.
+// Merging a read-only index down to a single segment gives the best search performance.
+func WithMaxNumSegments(n int) ForceMergeOption {
+	return func(opts *ForceMergeOptions) {
+		opts.MaxNumSegments = n
+	}
+}
+
+// WithOnlyExpungeDeletes returns a ForceMergeOption that restricts the merge to segments containing deleted
+// documents, reclaiming the space they occupy after large delete-by-query runs.
+func WithOnlyExpungeDeletes(expunge bool) ForceMergeOption {
+	return func(opts *ForceMergeOptions) {
+		opts.OnlyExpungeDeletes = expunge
+	}
+}
+
+// ForceMerge merges the segments of an index on both the primary and, if configured, the secondary OpenSearch
+// clients. It is intended for indices that no longer receive writes, since merging is expensive and segments
+// produced by a force merge are not merged again automatically.
+func (os *OpenSearch) ForceMerge(ctx context.Context, indexName string, opts ...ForceMergeOption) error {
+	options := &ForceMergeOptions{}
+	for _, opt := range opts {
+		opt(options)
+	}
+
+	return os.eachCluster(ctx, func(client *opensearch.Client) error {
+		return os.forceMerge(ctx, client, indexName, options)
+	})
+}
+
+// forceMerge sends a force merge request for an index using the specified client.
+func (os *OpenSearch) forceMerge(ctx context.Context, client *opensearch.Client, indexName string, options *ForceMergeOptions) error {
+	req := opensearchapi.IndicesForcemergeRequest{
+		Index: []string{indexName},
+	}
+
+	if options.MaxNumSegments > 0 {
+		req.MaxNumSegments = &options.MaxNumSegments
+	}
+	if options.OnlyExpungeDeletes {
+		req.OnlyExpungeDeletes = &options.OnlyExpungeDeletes
+	}
+
+	return os.executeRequest(ctx, client, &req)
+}
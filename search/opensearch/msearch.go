@@ -0,0 +1,117 @@
+package opensearch
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/joshilesanmi/open-search-dev/search"
+	opensearchapi "github.com/opensearch-project/opensearch-go/v2/opensearchapi"
+)
+
+// MSearchRequest is a single search of a multi-search.
+type MSearchRequest struct {
+	InstanceID string
+	Query      search.Query
+}
+
+// MSearchResponse is the outcome of a single search of a multi-search.
+type MSearchResponse struct {
+	Result search.SearchResult
+	Err    error // Set if this search failed; the other searches are unaffected.
+}
+
+// MSearch executes several searches in a single _msearch round trip, e.g. the queries of a dashboard page, and
+// returns their outcomes in the order of the requests. Each search is built and restricted to its instance
+// exactly as by Search. Failures of individual searches are reported in their response; the error is only set
+// if the round trip as a whole failed. The searches are limited by the interactive pool unless all of them have
+// batch priority.
+func (os *OpenSearch) MSearch(ctx context.Context, requests []MSearchRequest) ([]MSearchResponse, error) {
+	if len(requests) == 0 {
+		return nil, nil
+	}
+
+	priority := search.PriorityBatch
+	for _, req := range requests {
+		os.recordQuery(req.InstanceID)
+		if req.Query.Priority == search.PriorityInteractive {
+			priority = search.PriorityInteractive
+		}
+	}
+
+	ctx, release, err := os.acquire(ctx, priority)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	read, preference, err := os.readCluster(ctx, ReadSearch)
+	if err != nil {
+		return nil, err
+	}
+
+	var body bytes.Buffer
+	sent := make([]map[string]interface{}, 0, len(requests))
+	for _, req := range requests {
+		header := map[string]interface{}{}
+		if indices := os.searchIndices(ctx, req.InstanceID); indices != nil {
+			header["index"] = indices
+		}
+		if preference != "" {
+			header["preference"] = preference
+		}
+
+		searchQuery, err := os.constructSearchQuery(req.InstanceID, req.Query)
+		if err != nil {
+			return nil, err
+		}
+		sent = append(sent, searchQuery)
+
+		for _, line := range []interface{}{header, searchQuery} {
+			b, err := json.Marshal(line)
+			if err != nil {
+				return nil, fmt.Errorf("failed to marshal search query: %v", err)
+			}
+			body.Write(b)
+			body.WriteByte('\n')
+		}
+	}
+	recordSentQuery(ctx, sent)
+
+	header, done := os.cancellable(ctx, read.client)
+	defer done()
+
+	resp, err := os.executeReadRequest(ctx, read.client, opensearchapi.MsearchRequest{
+		Body:   &body,
+		Header: header,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var r struct {
+		Responses []struct {
+			searchResponse
+			Status int             `json:"status"`
+			Error  json.RawMessage `json:"error"`
+		} `json:"responses"`
+	}
+	if err := os.decodeResponse(resp, &r); err != nil {
+		return nil, err
+	}
+	if len(r.Responses) != len(requests) {
+		return nil, fmt.Errorf("msearch response has %d responses for %d searches", len(r.Responses), len(requests))
+	}
+
+	responses := make([]MSearchResponse, len(requests))
+	for i, res := range r.Responses {
+		if len(res.Error) > 0 {
+			responses[i].Err = fmt.Errorf("search failed with status %d: %s", res.Status, res.Error)
+			continue
+		}
+		responses[i].Result = os.searchResult(res.searchResponse)
+	}
+
+	return responses, nil
+}
@@ -0,0 +1,27 @@
+package opensearch
+
+import (
+	"errors"
+)
+
+// MapNested maps a field of an index configuration as nested, with the given sub-field mappings. Each object of
+// a nested array is indexed as a separate document, so search.Nested filters can match conditions on the same
+// object, e.g. a phone number of type "mobile" with a given value, rather than on the flattened array. Dotted
+// names map fields nested in objects. The "mappings" section is created if needed.
+func MapNested(config map[string]interface{}, field string, properties map[string]interface{}) error {
+	if len(properties) == 0 {
+		return errors.New("nested field requires at least one property")
+	}
+
+	parent, name, err := fieldProperties(config, field)
+	if err != nil {
+		return err
+	}
+
+	parent[name] = map[string]interface{}{
+		"type":       "nested",
+		"properties": properties,
+	}
+
+	return nil
+}
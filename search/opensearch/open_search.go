@@ -8,12 +8,17 @@ import (
 	"errors"
 	"fmt"
 	"net/http"
+	"net/url"
 	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
 
-	"github.com/aws/aws-xray-sdk-go/xray"
 	"github.com/joshilesanmi/open-search-dev/search"
 	opensearch "github.com/opensearch-project/opensearch-go/v2"
 	opensearchapi "github.com/opensearch-project/opensearch-go/v2/opensearchapi"
+	"github.com/opensearch-project/opensearch-go/v2/signer"
 	"github.com/rs/zerolog"
 )
 
@@ -21,88 +26,523 @@ import (
 // It holds references to primary and secondary OpenSearch clients, allowing operations to
 // be performed against two separate clusters
 type OpenSearch struct {
-	primaryClient   *opensearch.Client
-	secondaryClient *opensearch.Client
+	topology atomic.Pointer[topology] // The primary and secondary cluster; swapped by PromoteSecondary.
+
+	primaryName       string // Label identifying the primary cluster in logs, spans and errors.
+	secondaryName     string // Label identifying the secondary cluster in logs, spans and errors.
+	secondaryEndpoint string
+
+	searchClient   *opensearch.Client // Optional search-only cluster; never written to.
+	searchName     string             // Label identifying the search-only cluster.
+	searchEndpoint string
+	readRoutes     map[ReadOperation]ReadRoute // Where each type of read operation is sent.
+	strictReads    bool                        // Verifies every lookup across the primary and secondary cluster.
+	ignoredFields  []string                    // Dotted paths of fields ignored when comparing documents across clusters.
+
+	clock search.Clock       // Source of the current time.
+	ids   search.IDGenerator // Source of generated identifiers.
+
+	tracer Tracer // Instruments the requests sent to the clusters.
+
+	queryMetrics QueryMetrics // Receives per-shape query observations, if configured.
+
+	pools map[search.Priority]*priorityPool // Concurrency and timeout limits per query priority class.
+
+	degradation *degradation // Degrades searches while the cluster is slow, if configured.
+
+	shadow *shadowReads // Compares searches with the secondary cluster, if configured.
+
+	relevance *search.RelevanceConfig // Scoring of queries that do not configure their own, if set.
+
+	queryMode        search.QueryMode // Mode of queries that do not set their own.
+	searchableFields []string         // Allow-list of the fields queries may match against, if set.
+	defaultOperator  search.Operator  // Operator of queries that do not set their own.
+
+	bulkRetry *BulkRetryConfig // Retries of transiently failed bulk items; defaults apply if nil.
+
+	warmupQueries []WarmupQuery  // Executed against new indices before they serve searches.
+	indexDefaults *IndexDefaults // Applied to the settings of new indices, if set.
+
+	readTransforms []search.TransformRule // Applied to documents returned by reads.
+	readMetadata   search.MetadataMode    // How metadata is presented in documents returned by reads.
+
+	relationships []Relationship // Parent-child relationships cascaded by DeleteDocument.
+
+	dedup *search.DedupConfig // Deduplicates hits found in several indices, if set.
+
+	dualWrite   *dualWrite       // Handles secondary write failures; strict if nil.
+	replicas    []replicaTarget  // Additional clusters writes are replicated to.
+	writeBehind *writeBehind     // Queues failed document writes to the other clusters, if configured.
+	replication replicationStats // Tracks the writes to the secondary cluster.
+
+	dashboards     *Dashboards     // Provisions the Dashboards objects of instances, if set.
+	visualizations []Visualization // Provisioned for every instance.
+
+	transport http.RoundTripper // Replaces the default HTTP transport, if set.
+	tlsConfig *tls.Config       // TLS configuration of the default transport.
+	signer    signer.Signer     // Signs every request, if set.
+
+	proxy    func(*http.Request) (*url.URL, error) // Selects the proxy of the default transport, if set.
+	compress bool                                  // Gzip-compresses request bodies.
+
+	connectionPool *ConnectionPoolConfig // Tunes the pool of the default transport; defaults apply if nil.
+
+	throttle *ThrottleConfig // Retries of throttled requests; defaults apply if nil.
+
+	addresses         map[string][]string // Additional node addresses by cluster role.
+	discoveryInterval time.Duration       // Interval of node discovery; disabled if zero.
+
+	authorize func(*http.Request) error // Authenticates every request, if set.
+
+	connectTimeout time.Duration            // Bounds connection establishment; defaultConnectTimeout if zero.
+	requestTimeout time.Duration            // Deadline of every operation, if set.
+	methodTimeouts map[string]time.Duration // Deadlines of individual operations by method name.
+
+	slowThreshold time.Duration   // Operations taking longer are logged at warn level, if set.
+	logging       []LoggingOption // Options of the logging middleware wrapping the engine.
+
+	queryAudit    *zerolog.Logger  // Logs search queries with their injected filters marked, if set.
+	queryRedactor *search.Redactor // Redacts the queries logged by queryAudit, if set.
+
+	usageMu     sync.Mutex
+	queryVolume map[string]int64 // Number of searches per instance ID.
+
+	protectedPatterns []string // Glob patterns of index names that may only be deleted by force.
+
+	instanceAliasIndex string // Index whose per-instance aliases are searched, if configured.
+
+	embedding *EmbeddingConfig // Enriches documents with vector embeddings, if configured.
+
+	policies    []DestructivePolicy // Evaluated before destructive operations.
+	environment string              // Environment label passed to policies.
+
+	readOnly       atomic.Bool
+	fenceMu        sync.RWMutex
+	blockedIndices map[string]struct{}
+}
+
+// Engine is the search.SearchEngine returned by NewOpenSearch. It extends the generic interface with
+// operations that are specific to OpenSearch clusters.
+type Engine interface {
+	search.SearchEngine
+
+	// ClusterHealth returns the health of every configured cluster, primary first.
+	ClusterHealth(ctx context.Context) ([]ClusterHealth, error)
+
+	// NodesInfo returns the nodes of every configured cluster, primary first.
+	NodesInfo(ctx context.Context) ([]NodesInfo, error)
+
+	// DiskUsage returns the disk usage of the data nodes of every configured cluster, primary first.
+	DiskUsage(ctx context.Context) ([]DiskUsage, error)
+
+	// SetReadOnlyMode enables or disables fencing of all mutating operations.
+	SetReadOnlyMode(enabled bool)
+
+	// ReadOnlyMode reports whether all mutating operations are fenced.
+	ReadOnlyMode() bool
+
+	// BlockWrites fences mutating operations on a single index.
+	BlockWrites(indexName string)
+
+	// UnblockWrites removes the write fence from a single index.
+	UnblockWrites(indexName string)
+
+	// BlockedIndices returns the indices whose writes are fenced.
+	BlockedIndices() []string
+
+	// ForceMerge merges the segments of an index on every configured cluster.
+	ForceMerge(ctx context.Context, indexName string, opts ...ForceMergeOption) error
+
+	// AnalyzeText returns the tokens an analyzer produces for text.
+	AnalyzeText(ctx context.Context, indexName, analyzer, text string) ([]Token, error)
+
+	// UsageReport returns per-instance document counts, storage and query volume for an index.
+	UsageReport(ctx context.Context, indexName string) ([]TenantUsage, error)
+
+	// UpdateSynonyms replaces the inline rules of a synonym filter of an index.
+	UpdateSynonyms(ctx context.Context, indexName, filterName string, synonyms []string) error
+
+	// ReloadSearchAnalyzers reloads file based synonyms used by the search analyzers of an index.
+	ReloadSearchAnalyzers(ctx context.Context, indexName string) error
+
+	// SelfCheck verifies the configured clusters and reports actionable findings.
+	SelfCheck(ctx context.Context, opts ...SelfCheckOption) ([]Finding, error)
+
+	// HybridSearch combines lexical and k-NN vector scoring within a specific instance.
+	HybridSearch(ctx context.Context, instanceID string, query search.Query, opts ...HybridOption) ([]search.Document, error)
+
+	// SimilarDocuments returns the documents of an instance most similar to a given document.
+	SimilarDocuments(ctx context.Context, instanceID, indexName, entityName, entityID string, opts ...SimilarOption) ([]search.Document, error)
+
+	// ProvisionInstance creates the filtered alias of an instance on an index.
+	ProvisionInstance(ctx context.Context, indexName, instanceID string) error
+
+	// RegisterPercolator stores a query of an instance in a percolator index.
+	RegisterPercolator(ctx context.Context, instanceID, indexName, queryID string, query search.Query) error
+
+	// UnregisterPercolator removes a query from a percolator index.
+	UnregisterPercolator(ctx context.Context, instanceID, indexName, queryID string) error
+
+	// Percolate returns the IDs of the registered queries of an instance that match a document.
+	Percolate(ctx context.Context, instanceID, indexName, entityName string, document search.Document) ([]string, error)
+
+	// BulkPutDocuments adds or updates many documents, reporting per-document failures.
+	BulkPutDocuments(ctx context.Context, indexName string, documents []search.SourceDocument, opts ...search.IndexOption) (search.BulkReport, error)
+
+	// BulkDeleteDocuments removes many documents, reporting per-document failures.
+	BulkDeleteDocuments(ctx context.Context, indexName string, keys []search.DocumentKey) (search.BulkReport, error)
+
+	// Warmup executes the configured warm-up queries against an index.
+	Warmup(ctx context.Context, indexName string) error
+
+	// SimulateIndex reports how a document would be mapped by an index without indexing it.
+	SimulateIndex(ctx context.Context, indexName string, document search.Document) (SimulationReport, error)
+
+	// ConsistencyToken returns a token for the latest writes of the given documents.
+	ConsistencyToken(ctx context.Context, indexName string, keys []search.DocumentKey) (ConsistencyToken, error)
+
+	// WaitForSearchable waits until the writes recorded in a token are visible to search.
+	WaitForSearchable(ctx context.Context, token ConsistencyToken) error
+
+	// MigrateDocuments applies transformation rules to the stored documents of an index.
+	MigrateDocuments(ctx context.Context, indexName string, rules ...search.TransformRule) (int64, error)
+
+	// MSearch executes several searches in a single round trip and returns their outcomes in order.
+	MSearch(ctx context.Context, requests []MSearchRequest) ([]MSearchResponse, error)
+
+	// SubmitAsyncSearch starts a search in the background and returns the job.
+	SubmitAsyncSearch(ctx context.Context, instanceID string, query search.Query, opts ...AsyncSearchOption) (AsyncSearch, error)
+
+	// GetAsyncSearch returns the state and result of a background search.
+	GetAsyncSearch(ctx context.Context, id string) (AsyncSearch, error)
+
+	// CancelAsyncSearch cancels a background search or discards its result.
+	CancelAsyncSearch(ctx context.Context, id string) error
+
+	// SampleDocuments returns randomly chosen documents of an index.
+	SampleDocuments(ctx context.Context, indexName string, size int) ([]search.Document, error)
+
+	// ListIndices returns the indices whose names match a pattern.
+	ListIndices(ctx context.Context, pattern string) ([]IndexInfo, error)
+
+	// DescribeIndex returns the mappings, settings, aliases and size of an index.
+	DescribeIndex(ctx context.Context, indexName string) (IndexDescription, error)
+
+	// DebugQuery renders the search body the engine sends for a query.
+	DebugQuery(instanceID string, query search.Query) ([]byte, error)
+
+	// ReplicationStats returns the replication statistics of the secondary cluster.
+	ReplicationStats() ReplicationStats
+
+	// CopyIndex streams the documents of an index from one configured cluster to another.
+	CopyIndex(ctx context.Context, indexName string, from, to search.ClusterTarget, opts ...CopyOption) (CopyReport, error)
+
+	// ReplayWrites replays the writes of the write-behind queue.
+	ReplayWrites(ctx context.Context) (int, error)
+
+	// PromoteSecondary atomically swaps the primary and the secondary cluster.
+	PromoteSecondary() error
+
+	// Topology returns the names of the current primary and secondary cluster.
+	Topology() (primary, secondary string)
+
+	// Reconcile compares an index between the primary and the secondary cluster and optionally repairs it.
+	Reconcile(ctx context.Context, indexName string, opts ...ReconcileOption) (ReconcileReport, error)
 }
 
 // OpenSearchOption defines a function signature for configuring options on an OpenSearch instance.
 type OpenSearchOption func(*OpenSearch) error
 
-// Ensures the OpenSearch struct correctly implements the SearchEngine interface.
-var _ search.SearchEngine = &OpenSearch{}
+// Ensures the OpenSearch struct correctly implements the Engine interface.
+var _ Engine = &OpenSearch{}
 
 // ErrDocumentNotFound is an error that indicates a requested document could not be found in the search index.
-var ErrDocumentNotFound = errors.New("document not found")
+var ErrDocumentNotFound = search.ErrDocumentNotFound
 
 // ErrDocumentMismatch is an error indicating that there is a mismatch between the expected and actual document.
-var ErrDocumentMismatch = errors.New("document mismatch")
+var ErrDocumentMismatch = search.ErrDocumentMismatch
 
 // NewOpenSearch initializes and returns a new OpenSearch instance configured with a primary client
 // and the option to add a secondary client. The initial configuration sets up the primary client as default.
-// Additional configurations can be applied through OpenSearchOption. It also incorporates AWS X-Ray for tracing
-// and logging for monitoring and debugging purposes.
-func NewOpenSearch(endpoint string, logger zerolog.Logger, opts ...OpenSearchOption) (search.SearchEngine, error) {
-	// Wrap the HTTP transport with X-Ray
-	xrayTransport := xray.RoundTripper(&http.Transport{
-		TLSClientConfig: &tls.Config{},
-	})
+// Additional configurations can be applied through OpenSearchOption. It also incorporates logging and, if
+// configured WithTracer, tracing for monitoring and debugging purposes.
+func NewOpenSearch(endpoint string, logger zerolog.Logger, opts ...OpenSearchOption) (Engine, error) {
+	os := &OpenSearch{
+		primaryName:   PrimaryCluster,
+		secondaryName: SecondaryCluster,
+		searchName:    SearchCluster,
+		clock:         search.SystemClock,
+		ids:           search.UUIDGenerator,
+		tracer:        defaultTracer,
+	}
 
-	client, err := opensearch.NewClient(opensearch.Config{
-		Transport: xrayTransport,
-		Addresses: []string{endpoint},
-	})
+	for _, opt := range opts {
+		err := opt(os)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// Both set the Authorization header of the request, as do credentials in the endpoints.
+	if os.signer != nil {
+		if os.authorize != nil {
+			return nil, errors.New("request signing cannot be combined with other authentication")
+		}
+		for _, e := range append([]string{endpoint, os.secondaryEndpoint}, os.replicaEndpoints()...) {
+			if u, err := url.Parse(e); err == nil && u.User != nil {
+				return nil, errors.New("request signing cannot be combined with credentials in the endpoint")
+			}
+		}
+	}
+
+	client, err := os.newClient(PrimaryCluster, endpoint, os.primaryName)
 	if err != nil {
 		return nil, err
 	}
+	t := &topology{primary: cluster{role: PrimaryCluster, name: os.primaryName, client: client}}
 
-	os := &OpenSearch{
-		primaryClient: client,
+	if os.secondaryEndpoint != "" {
+		client, err := os.newClient(SecondaryCluster, os.secondaryEndpoint, os.secondaryName)
+		if err != nil {
+			return nil, err
+		}
+		t.secondary = &cluster{role: SecondaryCluster, name: os.secondaryName, client: client, policy: os.dualWrite}
 	}
 
-	for _, opt := range opts {
-		err := opt(os)
+	if t.replicas, err = os.newReplicas(); err != nil {
+		return nil, err
+	}
+
+	if os.writeBehind != nil {
+		n, err := os.writeBehind.config.Queue.Len(context.Background())
+		if err != nil {
+			return nil, fmt.Errorf("failed to read write-behind queue: %w", err)
+		}
+		os.replication.queued.Store(int64(n))
+	}
+	os.topology.Store(t)
+
+	if os.searchEndpoint != "" {
+		client, err := os.newClient(SearchCluster, os.searchEndpoint, os.searchName)
 		if err != nil {
 			return nil, err
 		}
+		os.searchClient = client
+	}
+
+	if err := validateReadRoutes(os.readRoutes, os.searchClient); err != nil {
+		return nil, err
+	}
+
+	if os.shadow != nil && t.secondary == nil {
+		return nil, errors.New("shadow reads require a secondary endpoint")
+	}
+
+	logCtx := logger.With().Str("cluster.primary", os.primaryName)
+	if t.secondary != nil {
+		logCtx = logCtx.Str("cluster.secondary", os.secondaryName)
+	}
+	if len(t.replicas) > 0 {
+		names := make([]string, 0, len(t.replicas))
+		for _, r := range t.replicas {
+			names = append(names, r.name)
+		}
+		logCtx = logCtx.Strs("cluster.replicas", names)
+	}
+	if os.searchClient != nil {
+		logCtx = logCtx.Str("cluster.search", os.searchName)
+	}
+
+	if os.tlsConfig != nil && os.tlsConfig.InsecureSkipVerify {
+		logger.Warn().Msg("TLS certificate verification is disabled")
 	}
 
-	return OpenSearchLoggingMiddleware(logger)(os), nil
+	var engine Engine = os
+	if os.requestTimeout > 0 || len(os.methodTimeouts) > 0 {
+		engine = newTimeoutMiddleware(os.requestTimeout, os.methodTimeouts)(engine)
+	}
+
+	logOpts := append([]LoggingOption{WithSlowThreshold(os.slowThreshold)}, os.logging...)
+	return OpenSearchLoggingMiddleware(logCtx.Logger(), logOpts...)(engine), nil
 }
 
 // WithSecondaryEndpoint configures an OpenSearch instance to use a secondary endpoint.
 func WithSecondaryEndpoint(endpoint string) OpenSearchOption {
 	return func(os *OpenSearch) error {
-		xrayTransport := xray.RoundTripper(&http.Transport{
-			TLSClientConfig: &tls.Config{},
-		})
-		client, err := opensearch.NewClient(opensearch.Config{
-			Transport: xrayTransport,
-			Addresses: []string{endpoint},
-		})
-		if err != nil {
-			return err
+		os.secondaryEndpoint = endpoint
+		return nil
+	}
+}
+
+// WithPrimaryName sets the label identifying the primary cluster (e.g. "eu-prod") in logs, spans and errors.
+func WithPrimaryName(name string) OpenSearchOption {
+	return func(os *OpenSearch) error {
+		if name == "" {
+			return errors.New("primary cluster name must not be empty")
+		}
+		os.primaryName = name
+		return nil
+	}
+}
+
+// WithSecondaryName sets the label identifying the secondary cluster (e.g. "us-dr") in logs, spans and errors.
+func WithSecondaryName(name string) OpenSearchOption {
+	return func(os *OpenSearch) error {
+		if name == "" {
+			return errors.New("secondary cluster name must not be empty")
+		}
+		os.secondaryName = name
+		return nil
+	}
+}
+
+// WithStrictReads verifies every FindDocument across the primary and the secondary cluster, as if made
+// search.WithConsistencyCheck, e.g. for engines used by verification jobs. It doubles the latency of lookups.
+func WithStrictReads() OpenSearchOption {
+	return func(os *OpenSearch) error {
+		os.strictReads = true
+		return nil
+	}
+}
+
+// WithRelevance sets the relevance configuration used to score queries that do not set their own.
+func WithRelevance(config search.RelevanceConfig) OpenSearchOption {
+	return func(os *OpenSearch) error {
+		os.relevance = &config
+		return nil
+	}
+}
+
+// WithQueryMode sets how the value of queries that do not set their own mode is interpreted, and optionally
+// restricts the fields every query may match against. Passing raw user input to query_string fails on
+// unbalanced quotes and lets users target arbitrary fields; QueryModeSimple or QueryModeMultiMatch with an
+// allow-list of fields avoids both. An allow-list therefore requires one of these modes, and once a safe mode is
+// set, queries asking for QueryModeQueryString or for fields outside the allow-list fail with ErrQueryNotAllowed.
+func WithQueryMode(mode search.QueryMode, fields ...string) OpenSearchOption {
+	return func(os *OpenSearch) error {
+		if len(fields) > 0 && !safeQueryMode(mode) {
+			return errors.New("searchable fields require QueryModeSimple or QueryModeMultiMatch, as query_string can target any field")
 		}
-		os.secondaryClient = client
+		os.queryMode = mode
+		os.searchableFields = fields
+		return nil
+	}
+}
+
+// WithDefaultOperator sets how the terms of queries that do not set their own operator are combined. The
+// OpenSearch default, OperatorOr, matches documents containing any term, which often returns too many
+// irrelevant hits for multi-term searches.
+func WithDefaultOperator(op search.Operator) OpenSearchOption {
+	return func(os *OpenSearch) error {
+		os.defaultOperator = op
+		return nil
+	}
+}
+
+// WithReadMetadata selects how the metadata added by PutDocument (id, instance_id and entity_name) is presented
+// in documents returned by FindDocument, Search, HybridSearch and SimilarDocuments: inline as stored, stripped,
+// or nested under search.MetaKey. Stripping or nesting it keeps it from colliding with application fields of the
+// same name. FindDocument returns the stored document with search.WithRawDocument.
+func WithReadMetadata(mode search.MetadataMode) OpenSearchOption {
+	return func(os *OpenSearch) error {
+		os.readMetadata = mode
+		return nil
+	}
+}
+
+// WithDeduplication removes duplicated hits from search results, keeping one copy of every document. Searches
+// spanning overlapping indices or aliases otherwise return a copy of a document per index it is found in. The
+// total reported by the cluster still counts every copy.
+func WithDeduplication(config search.DedupConfig) OpenSearchOption {
+	return func(os *OpenSearch) error {
+		if config.Keep == search.KeepNewest && config.TimestampField == "" {
+			return errors.New("keeping the newest copy requires a timestamp field")
+		}
+		os.dedup = &config
+		return nil
+	}
+}
+
+// WithSlowQueryLog logs operations taking longer than the threshold at warn level, including the serialized query
+// of searches. See WithSlowThreshold.
+func WithSlowQueryLog(threshold time.Duration) OpenSearchOption {
+	return func(os *OpenSearch) error {
+		if threshold <= 0 {
+			return errors.New("slow query threshold must be positive")
+		}
+		os.slowThreshold = threshold
+		return nil
+	}
+}
+
+// WithLogging configures the logging middleware wrapping the engine, e.g. to sample successful calls of
+// high-volume methods or raise the level of a method with WithMethodLogLevel.
+func WithLogging(opts ...LoggingOption) OpenSearchOption {
+	return func(os *OpenSearch) error {
+		os.logging = append(os.logging, opts...)
+		return nil
+	}
+}
+
+// WithClock replaces the system clock used for timestamps and time-dependent behaviour, typically with a fake
+// clock in tests.
+func WithClock(clock search.Clock) OpenSearchOption {
+	return func(os *OpenSearch) error {
+		if clock == nil {
+			return errors.New("clock must not be nil")
+		}
+		os.clock = clock
+		return nil
+	}
+}
+
+// WithIDGenerator replaces the random UUID generator used for job identifiers and similar handles, typically
+// with a deterministic generator in tests.
+func WithIDGenerator(ids search.IDGenerator) OpenSearchOption {
+	return func(os *OpenSearch) error {
+		if ids == nil {
+			return errors.New("id generator must not be nil")
+		}
+		os.ids = ids
 		return nil
 	}
 }
 
 // CreateIndex creates an index with the specified name and configuration on both the primary and,
-// if configured, the secondary OpenSearch clients.
+// if configured, the secondary OpenSearch clients. A typed Analysis placed in the configuration with
+// Analysis.ApplyTo is validated before any cluster is contacted.
 func (os *OpenSearch) CreateIndex(ctx context.Context, indexName string, config map[string]interface{}) error {
-	configByte, err := json.Marshal(config)
+	if err := os.checkWritable(indexName); err != nil {
+		return err
+	}
+
+	if d := os.indexDefaults; d != nil && d.Language != "" {
+		var err error
+		if config, err = IndexConfigWithLanguage(config, d.Language); err != nil {
+			return fmt.Errorf("failed to apply the index language: %w", err)
+		}
+	}
+
+	if analysis := analysisFromConfig(config); analysis != nil {
+		if err := analysis.Validate(); err != nil {
+			return fmt.Errorf("invalid index analysis: %w", err)
+		}
+	}
+
+	configByte, err := json.Marshal(os.applyIndexDefaults(config))
 	if err != nil {
 		return fmt.Errorf("failed to marshal index config %v", err)
 	}
 
-	if err := os.ensureIndex(ctx, os.primaryClient, indexName, configByte); err != nil {
-		return fmt.Errorf("primary client: %v", err)
+	err = os.write(ctx, func(client *opensearch.Client) error {
+		return os.ensureIndex(ctx, client, indexName, configByte)
+	})
+	if err != nil {
+		return err
 	}
 
-	if os.secondaryClient != nil {
-		if err := os.ensureIndex(ctx, os.secondaryClient, indexName, configByte); err != nil {
-			return fmt.Errorf("secondary client: %v", err)
-		}
+	if err := os.Warmup(ctx, indexName); err != nil {
+		return fmt.Errorf("index created but warm-up failed: %w", err)
 	}
 
 	return nil
@@ -113,12 +553,23 @@ func (os *OpenSearch) CreateIndex(ctx context.Context, indexName string, config
 // allows extra index options like refresh. Initially stored in the primary OpenSearch cluster, the document
 // is also be stored to a secondary cluster, if it is configured.
 func (os *OpenSearch) PutDocument(ctx context.Context, instanceID, indexName, entityName, entityID string, document search.Document, opts ...search.IndexOption) error {
+	if err := os.checkWritable(indexName); err != nil {
+		return err
+	}
+
 	// Add necessary metadata to the document before insertion.
 	d, err := document.AddDocumentMetaData(instanceID, entityName, entityID)
 	if err != nil {
 		return fmt.Errorf("missing document meta data %v", err)
 	}
 
+	// Enrich the document with an embedding of its text fields, if configured.
+	if os.embedding != nil {
+		if err := os.embed(ctx, d); err != nil {
+			return err
+		}
+	}
+
 	docByte, err := json.Marshal(d)
 	if err != nil {
 		return fmt.Errorf("failed to marshal document %v", err)
@@ -134,100 +585,258 @@ func (os *OpenSearch) PutDocument(ctx context.Context, instanceID, indexName, en
 
 	refresh := strconv.FormatBool(options.Refresh)
 
-	// Store the document in the index on the primary client.
-	if err = os.putDocument(ctx, os.primaryClient, indexName, documentID, docByte, refresh); err != nil {
-		return fmt.Errorf("primary client: %v", err)
-	}
+	// Store the document on the primary client and, if configured, the secondary client.
+	return os.writeDocument(ctx, &documentRef{index: indexName, id: documentID}, func(client *opensearch.Client) error {
+		return os.putDocument(ctx, client, indexName, documentID, docByte, refresh)
+	})
+}
 
-	// If a secondary client is configured, store the document there as well.
-	if os.secondaryClient != nil {
-		if err := os.putDocument(ctx, os.secondaryClient, indexName, documentID, docByte, refresh); err != nil {
-			return fmt.Errorf("secondary client: %v", err)
-		}
+// FindDocument searches for a document within an index based on the provided documentID. It retrieves the
+// document from the cluster serving lookups and, if a secondary client is configured and the lookup is made
+// search.WithConsistencyCheck or the engine WithStrictReads, verifies the document's consistency across both
+// clients.
+func (os *OpenSearch) FindDocument(ctx context.Context, instanceID, indexName, entityName, entityID string, opts ...search.FindOption) (search.Document, error) {
+	options := &search.FindOptions{ConsistencyCheck: os.strictReads || search.TargetCluster(ctx) == search.Both}
+	for _, opt := range opts {
+		opt(options)
 	}
 
-	return nil
-}
-
-// FindDocument searches for a document within an index based on the provided documentID. It attempts to retrieve
-// the document from the primary OpenSearch client and, if a secondary client is configured, verifies the document's
-// consistency across both clients.
-func (os *OpenSearch) FindDocument(ctx context.Context, instanceID, indexName, entityName, entityID string) (search.Document, error) {
 	documentID := search.GenerateDocumentID(instanceID, entityName, entityID)
 
-	pryDoc, err := os.findDocument(ctx, os.primaryClient, indexName, documentID)
+	read, preference, err := os.readCluster(ctx, ReadFind)
+	if err != nil {
+		return nil, err
+	}
+	pryDoc, err := os.findDocument(ctx, read.client, indexName, documentID, preference)
 	if err != nil {
-		return nil, fmt.Errorf("primary client: %w", err)
+		return nil, &ClusterError{Cluster: read.role, Name: read.name, Err: err}
 	}
 
-	if os.secondaryClient != nil {
-		secDoc, err := os.findDocument(ctx, os.secondaryClient, indexName, documentID)
+	if secondary, ok := os.secondary(); ok && options.ConsistencyCheck && read.role != SecondaryCluster {
+		secDoc, err := os.findDocument(ctx, secondary.client, indexName, documentID, "")
 		if err != nil {
-			return nil, fmt.Errorf("secondary client: %w", err)
+			return nil, &ClusterError{Cluster: secondary.role, Name: secondary.name, Err: err}
 		}
 
-		if !compareDocuments(pryDoc, secDoc) {
+		if !compareDocuments(pryDoc, secDoc, os.ignoredFields...) {
+			os.replication.mismatches.Add(1)
 			return nil, fmt.Errorf("documents mismatch for id %q: %w", entityID, ErrDocumentMismatch)
 		}
 	}
 
+	if !options.Raw {
+		os.presentDocument(pryDoc)
+	}
+
 	return pryDoc, nil
 }
 
 // DeleteDocument removes a document from the specified index in both the primary and, if configured, the secondary
 // OpenSearch clients.
 func (os *OpenSearch) DeleteDocument(ctx context.Context, instanceID, indexName, entityName, entityID string) error {
+	if err := os.checkWritable(indexName); err != nil {
+		return err
+	}
+
+	// A cascade vetoed by a policy must not leave the children of a deleted parent behind.
+	if err := os.authorizeCascade(ctx, indexName, entityName); err != nil {
+		return err
+	}
+
 	documentID := search.GenerateDocumentID(instanceID, entityName, entityID)
 
-	if err := os.deleteDocument(ctx, os.primaryClient, indexName, documentID); err != nil {
-		return fmt.Errorf("primary client: %v", err)
+	err := os.writeDocument(ctx, &documentRef{index: indexName, id: documentID}, func(client *opensearch.Client) error {
+		return os.deleteDocument(ctx, client, indexName, documentID)
+	})
+	if err != nil {
+		return err
 	}
 
-	if os.secondaryClient != nil {
-		if err := os.deleteDocument(ctx, os.secondaryClient, indexName, documentID); err != nil {
-			return fmt.Errorf("secondary client: %v", err)
-		}
+	// The parent is deleted first, so a failed cascade leaves orphaned children rather than deleting the children
+	// of a parent that still exists.
+	if err := os.cascade(ctx, instanceID, indexName, entityName, entityID); err != nil {
+		return fmt.Errorf("document deleted but %w", err)
 	}
 
 	return nil
 }
 
 // DeleteIndex removes an entire index from both the primary and, if configured, the secondary OpenSearch clients.
-func (os *OpenSearch) DeleteIndex(ctx context.Context, indexName string) error {
-	if err := os.deleteIndex(ctx, os.primaryClient, indexName); err != nil {
-		return fmt.Errorf("primary client: %v", err)
+// Configured destructive operation policies are evaluated first and cannot be overridden. Unless WithForceDelete
+// is passed, indices matching a protected name pattern or serving as the write target of an alias on any cluster
+// are then refused with ErrIndexProtected before anything is deleted.
+func (os *OpenSearch) DeleteIndex(ctx context.Context, indexName string, opts ...search.DeleteIndexOption) error {
+	if err := os.checkWritable(indexName); err != nil {
+		return err
+	}
+
+	options := &search.DeleteIndexOptions{}
+	for _, opt := range opts {
+		opt(options)
 	}
 
-	if os.secondaryClient != nil {
-		if err := os.deleteIndex(ctx, os.secondaryClient, indexName); err != nil {
-			return fmt.Errorf("secondary client: %v", err)
+	if err := os.authorizeDestructive(ctx, OperationDeleteIndex, indexName, options.Confirmed); err != nil {
+		return err
+	}
+
+	if !options.Force {
+		if err := os.checkDeletable(ctx, indexName); err != nil {
+			return err
 		}
 	}
 
-	return nil
+	return os.write(ctx, func(client *opensearch.Client) error {
+		return os.deleteIndex(ctx, client, indexName)
+	})
+}
+
+// RefreshIndex refreshes an index on both the primary and, if configured, the secondary OpenSearch clients.
+// It is cheaper to refresh once after a batch of writes than to set the Refresh option on every PutDocument.
+func (os *OpenSearch) RefreshIndex(ctx context.Context, indexName string) error {
+	return os.eachCluster(ctx, func(client *opensearch.Client) error {
+		return os.refreshIndex(ctx, client, indexName)
+	})
 }
 
 // Search performs a search operation across documents in an index based on a given query and instance ID.
 // This method constructs a search query that includes both a search term and a filter for the instance ID,
 // ensuring that only documents relevant to the specified instance and matching the search criteria are returned.
-func (os *OpenSearch) Search(ctx context.Context, instanceID string, query search.Query) ([]search.Document, error) {
-	searchQuery := os.constructSearchQuery(instanceID, query)
+func (os *OpenSearch) Search(ctx context.Context, instanceID string, query search.Query) (_ search.SearchResult, err error) {
+	os.recordQuery(instanceID)
+
+	ctx, release, err := os.acquire(ctx, query.Priority)
+	if err != nil {
+		return search.SearchResult{}, err
+	}
+	defer release()
+
+	searchQuery, err := os.constructSearchQuery(instanceID, query)
+	if err != nil {
+		return search.SearchResult{}, err
+	}
+
+	// While the cluster exceeds its latency budget, serve a recent result for the same search if there is one,
+	// and otherwise send a cheaper query. Degraded results are not cached, as they may be incomplete.
+	var cacheKey string
+	degraded := false
+	if os.degradation != nil {
+		cacheKey = degradationCacheKey(instanceID, searchQuery)
+		if degraded = os.degradation.degraded(); degraded {
+			if result, ok := os.degradation.cached(cacheKey, os.clock.Now()); ok {
+				return result, nil
+			}
+			os.degradation.degrade(searchQuery)
+		}
+	}
 
 	q, err := json.Marshal(searchQuery)
 	if err != nil {
-		return nil, fmt.Errorf("failed to marshal search query: %v", err)
+		return search.SearchResult{}, fmt.Errorf("failed to marshal search query: %v", err)
+	}
+
+	read, preference, err := os.readCluster(ctx, ReadSearch)
+	if err != nil {
+		return search.SearchResult{}, err
 	}
+	recordSentQuery(ctx, json.RawMessage(q))
+
+	header, done := os.cancellable(ctx, read.client)
+	defer done()
 
 	searchReq := opensearchapi.SearchRequest{
-		Body: bytes.NewReader(q),
+		Index:      os.searchIndices(ctx, instanceID),
+		Body:       bytes.NewReader(q),
+		Preference: preference,
+		Header:     header,
+	}
+
+	if os.queryMetrics != nil {
+		defer func(begin time.Time) {
+			os.queryMetrics.ObserveQuery(read.name, QueryShape(searchQuery), os.clock.Now().Sub(begin), err)
+		}(os.clock.Now())
 	}
 
-	resp, err := os.executeReadRequest(ctx, os.primaryClient, searchReq)
+	begin := os.clock.Now()
+	resp, err := os.executeReadRequest(ctx, read.client, searchReq)
+	if os.degradation != nil {
+		os.degradation.observe(os.clock.Now().Sub(begin))
+	}
 	if err != nil {
-		return nil, err
+		return search.SearchResult{}, err
+	}
+
+	result, err := os.extractSearchResult(resp)
+	if err != nil {
+		return search.SearchResult{}, err
+	}
+
+	if os.degradation != nil && !degraded {
+		os.degradation.store(cacheKey, result, os.clock.Now())
+	}
+
+	if !degraded {
+		os.shadowSearch(ctx, read, instanceID, searchQuery, q, result)
+	}
+
+	return result, nil
+}
+
+// write applies a mutating operation to the primary client and, if configured, the secondary and replica clients.
+// The others are only written once the primary write has succeeded. Failures are reported as a *WriteError
+// recording which cluster failed and whether the primary was written, unless the failure of the secondary is
+// suppressed by its WritePolicy. A context targeting a single cluster with search.WithCluster writes only that
+// cluster, and the WritePolicy does not apply.
+func (os *OpenSearch) write(ctx context.Context, fn func(client *opensearch.Client) error) error {
+	return os.writeDocument(ctx, nil, fn)
+}
+
+// writeDocument is write for an operation writing a single document, whose writes to the other clusters are
+// queued WithWriteBehind if they fail or are skipped.
+func (os *OpenSearch) writeDocument(ctx context.Context, doc *documentRef, fn func(client *opensearch.Client) error) error {
+	clusters, err := os.targetClusters(ctx)
+	if err != nil {
+		return err
+	}
+	replicated := len(clusters) > 1
+
+	primaryWritten := false
+	for _, c := range clusters {
+		secondary := c.role != PrimaryCluster
+		queue := replicated && secondary && primaryWritten && doc != nil
+		if replicated && secondary && c.policy.skip(c.name, os.clock.Now()) {
+			os.replication.skipped.Add(1)
+			if queue {
+				os.enqueue(ctx, c, *doc)
+			}
+			continue
+		}
+		var err error
+		if secondary {
+			err = os.writeSecondary(func() (int, error) {
+				return 0, fn(c.client)
+			})
+		} else {
+			err = fn(c.client)
+		}
+		if err != nil {
+			if queue && os.enqueue(ctx, c, *doc) {
+				continue
+			}
+			if replicated && secondary && c.policy.suppress(c.name, err, os.clock.Now()) {
+				continue
+			}
+			return &WriteError{
+				PrimaryWritten: primaryWritten,
+				Errors:         []*ClusterError{{Cluster: c.role, Name: c.name, Err: err}},
+			}
+		}
+		if secondary {
+			os.replayInBackground()
+		}
+		primaryWritten = primaryWritten || !secondary
 	}
 
-	return os.extractDocumentsFromSearchResponse(resp)
+	return nil
 }
 
 // ensureIndex checks if an index exists, and creates it if not.
@@ -292,10 +901,11 @@ func (os *OpenSearch) putDocument(ctx context.Context, client *opensearch.Client
 }
 
 // findDocument retrieves a document by its ID from the specified index using the provided OpenSearch client.
-func (os *OpenSearch) findDocument(ctx context.Context, client *opensearch.Client, indexName, documentID string) (search.Document, error) {
+func (os *OpenSearch) findDocument(ctx context.Context, client *opensearch.Client, indexName, documentID, preference string) (search.Document, error) {
 	req := opensearchapi.GetRequest{
 		Index:      indexName,
 		DocumentID: documentID,
+		Preference: preference,
 	}
 
 	resp, err := os.executeReadRequest(ctx, client, req)
@@ -307,7 +917,7 @@ func (os *OpenSearch) findDocument(ctx context.Context, client *opensearch.Clien
 		Source search.Document `json:"_source"`
 	}
 
-	err = decodeResponse(resp, &r)
+	err = os.decodeResponse(resp, &r)
 	if err != nil {
 		return nil, err
 	}
@@ -333,17 +943,26 @@ func (os *OpenSearch) deleteIndex(ctx context.Context, client *opensearch.Client
 	return os.executeRequest(ctx, client, &req)
 }
 
+// refreshIndex sends a request to refresh an index on the OpenSearch cluster using the specified client.
+func (os *OpenSearch) refreshIndex(ctx context.Context, client *opensearch.Client, indexName string) error {
+	req := opensearchapi.IndicesRefreshRequest{
+		Index: []string{indexName},
+	}
+
+	return os.executeRequest(ctx, client, &req)
+}
+
 // executeRequest performs a generic OpenSearch API request using the provided client and request parameters.
 // It is a utility function designed to handle the execution of various OpenSearch requests.
 func (os *OpenSearch) executeRequest(ctx context.Context, client *opensearch.Client, req opensearchapi.Request) error {
 	resp, err := req.Do(ctx, client)
 	if err != nil {
-		return fmt.Errorf("error executing request: %v", err)
+		return fmt.Errorf("error executing request: %w", err)
 	}
 	defer resp.Body.Close()
 
 	if resp.IsError() {
-		return fmt.Errorf("request failed: %s", resp.String())
+		return newAPIError(resp, os.clock.Now())
 	}
 
 	return nil
@@ -354,85 +973,170 @@ func (os *OpenSearch) executeRequest(ctx context.Context, client *opensearch.Cli
 func (os *OpenSearch) executeReadRequest(ctx context.Context, client *opensearch.Client, req opensearchapi.Request) (*opensearchapi.Response, error) {
 	resp, err := req.Do(ctx, client)
 	if err != nil {
-		return nil, fmt.Errorf("error executing request: %v", err)
+		return nil, fmt.Errorf("error executing request: %w", err)
 	}
 
 	return resp, nil
 }
 
-// constructSearchQuery builds the search query.
-func (os *OpenSearch) constructSearchQuery(instanceID string, query search.Query) map[string]interface{} {
-	return map[string]interface{}{
-		"query": map[string]interface{}{
-			"bool": map[string]interface{}{
-				"must": map[string]interface{}{
-					"query_string": map[string]interface{}{
-						"query": query.Value,
-					},
-				},
-				"filter": map[string]interface{}{
-					"term": map[string]string{
-						"instance_id": instanceID,
-					},
-				},
-			},
-		},
-	}
-}
-
-// extractDocumentsFromSearchResponse processes the search response and extracts documents.
-func (os *OpenSearch) extractDocumentsFromSearchResponse(resp *opensearchapi.Response) ([]search.Document, error) {
-	var r struct {
-		Hits struct {
-			Hits []struct {
-				ID     string                 `json:"_id"`
-				Source map[string]interface{} `json:"_source"`
-			} `json:"hits"`
-		} `json:"hits"`
+// DebugQuery renders the exact body Search sends for a query, with the engine defaults for relevance, query
+// mode, operator and searchable fields applied, as indented JSON that can be pasted into the Dev Tools console
+// to reproduce the query. Degraded queries sent while the cluster exceeds its latency budget are not rendered.
+func (os *OpenSearch) DebugQuery(instanceID string, query search.Query) ([]byte, error) {
+	body, err := os.searchBody(instanceID, query)
+	if err != nil {
+		return nil, err
 	}
+	return json.MarshalIndent(body, "", "  ")
+}
 
-	if err := decodeResponse(resp, &r); err != nil {
+// constructSearchQuery builds the search query with the engine defaults, and records it in the audit log.
+func (os *OpenSearch) constructSearchQuery(instanceID string, query search.Query) (map[string]interface{}, error) {
+	body, err := os.searchBody(instanceID, query)
+	if err != nil {
 		return nil, err
 	}
+	os.auditQuery(instanceID, body)
 
-	documents := make([]search.Document, 0)
-	for _, hit := range r.Hits.Hits {
-		documents = append(documents, hit.Source)
+	return body, nil
+}
+
+// searchBody builds the search query with the engine defaults applied: its relevance, query mode, operator and
+// searchable fields. Queries the safe query mode or the allow-list do not permit fail with ErrQueryNotAllowed.
+func (os *OpenSearch) searchBody(instanceID string, query search.Query) (map[string]interface{}, error) {
+	if query.Relevance == nil && os.relevance != nil {
+		query = query.WithRelevance(*os.relevance)
+	}
+	switch {
+	case query.Mode == search.QueryModeDefault:
+		query.Mode = os.queryMode
+	case !safeQueryMode(query.Mode) && safeQueryMode(os.queryMode):
+		return nil, fmt.Errorf("query_string mode: %w", ErrQueryNotAllowed)
+	}
+	if query.Operator == search.OperatorDefault {
+		query.Operator = os.defaultOperator
+	}
+	if len(os.searchableFields) > 0 {
+		fields, err := allowedFields(query.Fields, os.searchableFields)
+		if err != nil {
+			return nil, err
+		}
+		query.Fields = fields
 	}
 
-	return documents, nil
+	return query.DSL(instanceID), nil
 }
 
-// decodeResponse takes an OpenSearch API response and decodes its body into a target.
-// This function is a utility for unmarshaling JSON responses from OpenSearch into defined type.
-// It checks HTTP error statuses in the response and specifically detecting a document not found condition.
-func decodeResponse(resp *opensearchapi.Response, target interface{}) error {
-	if resp.IsError() {
-		if resp.StatusCode == http.StatusNotFound {
-			return ErrDocumentNotFound
+// safeQueryMode reports whether a query mode is safe for raw user input, i.e. it never fails on invalid syntax
+// and cannot target fields.
+func safeQueryMode(mode search.QueryMode) bool {
+	return mode == search.QueryModeSimple || mode == search.QueryModeMultiMatch
+}
+
+// allowedFields checks the fields requested by a query against the allow-list. A query that requests no fields
+// searches all allowed fields; a query requesting any other field fails with ErrQueryNotAllowed.
+func allowedFields(requested, allowed []string) ([]string, error) {
+	if len(requested) == 0 {
+		return allowed, nil
+	}
+
+	var denied []string
+	for _, field := range requested {
+		ok := false
+		for _, a := range allowed {
+			if field == a {
+				ok = true
+				break
+			}
+		}
+		if !ok {
+			denied = append(denied, field)
 		}
-		return fmt.Errorf("error in response: %s", resp.String())
 	}
-	defer resp.Body.Close()
+	if len(denied) > 0 {
+		return nil, fmt.Errorf("fields %s: %w", strings.Join(denied, ", "), ErrQueryNotAllowed)
+	}
+	return requested, nil
+}
 
-	return json.NewDecoder(resp.Body).Decode(target)
+// searchResponse is the body of a search response.
+type searchResponse struct {
+	Took int64 `json:"took"`
+	Hits struct {
+		Total struct {
+			Value    int64  `json:"value"`
+			Relation string `json:"relation"`
+		} `json:"total"`
+		Hits []struct {
+			ID        string                 `json:"_id"`
+			Index     string                 `json:"_index"`
+			Score     float64                `json:"_score"`
+			Source    map[string]interface{} `json:"_source"`
+			InnerHits map[string]struct {
+				Hits struct {
+					Hits []struct {
+						Source search.Document `json:"_source"`
+					} `json:"hits"`
+				} `json:"hits"`
+			} `json:"inner_hits"`
+		} `json:"hits"`
+	} `json:"hits"`
 }
 
-// compareDocuments compares two search.Document maps for equality
-func compareDocuments(doc1, doc2 search.Document) bool {
-	if len(doc1) != len(doc2) {
-		return false
+// extractSearchResult processes the search response and extracts the hits and their metadata.
+func (os *OpenSearch) extractSearchResult(resp *opensearchapi.Response) (search.SearchResult, error) {
+	var r searchResponse
+	if err := os.decodeResponse(resp, &r); err != nil {
+		return search.SearchResult{}, err
 	}
 
-	for key, value1 := range doc1 {
-		if value2, ok := doc2[key]; ok {
-			if value1 != value2 {
-				return false
+	return os.searchResult(r), nil
+}
+
+// searchResult converts a decoded search response into a SearchResult.
+func (os *OpenSearch) searchResult(r searchResponse) search.SearchResult {
+	result := search.SearchResult{
+		Hits:          make([]search.Hit, 0, len(r.Hits.Hits)),
+		Total:         r.Hits.Total.Value,
+		TotalRelation: r.Hits.Total.Relation,
+		Took:          time.Duration(r.Took) * time.Millisecond,
+	}
+	for _, hit := range r.Hits.Hits {
+		if inner, ok := hit.InnerHits[search.CollapsedKey]; ok {
+			collapsed := make([]search.Document, 0, len(inner.Hits.Hits))
+			for _, h := range inner.Hits.Hits {
+				os.presentDocument(h.Source)
+				collapsed = append(collapsed, h.Source)
 			}
-		} else {
-			return false
+			if hit.Source == nil {
+				hit.Source = make(map[string]interface{})
+			}
+			hit.Source[search.CollapsedKey] = collapsed
+		}
+		os.presentDocument(hit.Source)
+		result.Hits = append(result.Hits, search.Hit{ID: hit.ID, Index: hit.Index, Score: hit.Score, Document: hit.Source})
+	}
+
+	if os.dedup != nil {
+		result.Hits = search.Deduplicate(result.Hits, *os.dedup)
+	}
+
+	return result
+}
+
+// decodeResponse takes an OpenSearch API response and decodes its body into a target.
+// This function is a utility for unmarshaling JSON responses from OpenSearch into defined type.
+// Error statuses are returned as an *APIError, except for missing documents, which yield ErrDocumentNotFound.
+func (os *OpenSearch) decodeResponse(resp *opensearchapi.Response, target interface{}) error {
+	if resp.IsError() {
+		apiErr := newAPIError(resp, os.clock.Now())
+		// A missing document is reported without an error body.
+		if apiErr.StatusCode == http.StatusNotFound && apiErr.Type == "" {
+			return ErrDocumentNotFound
 		}
+		return apiErr
 	}
+	defer resp.Body.Close()
 
-	return true
+	return json.NewDecoder(resp.Body).Decode(target)
 }
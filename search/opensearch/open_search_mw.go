@@ -2,108 +2,815 @@ package opensearch
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/joshilesanmi/open-search-dev/search"
 	"github.com/rs/zerolog"
 )
 
-// OpenSearchMiddleware describes a SearchEngine middleware.
-type OpenSearchMiddleware func(search.SearchEngine) search.SearchEngine
+// OpenSearchMiddleware describes an Engine middleware.
+type OpenSearchMiddleware func(Engine) Engine
 
-// OpenSearchLoggingMiddleware takes a logger as a dependency and returns a OpenSearchMiddleware.
-func OpenSearchLoggingMiddleware(logger zerolog.Logger) OpenSearchMiddleware {
-	return func(next search.SearchEngine) search.SearchEngine {
+// LoggingOption is a function type that applies configuration options to the logging middleware.
+type LoggingOption func(*loggingConfig)
+
+// loggingConfig configures the logging middleware.
+type loggingConfig struct {
+	level         zerolog.Level            // Level of successful calls.
+	levels        map[string]zerolog.Level // Level of successful calls by method name.
+	sampling      map[string]sampler       // Sampling of successful calls by method name.
+	slowThreshold time.Duration            // Operations taking longer are logged at warn level; disabled if zero.
+	redactor      *search.Redactor         // Redacts logged query bodies, if set.
+}
+
+// WithLogLevel sets the level successful calls are logged at. Defaults to debug.
+func WithLogLevel(level zerolog.Level) LoggingOption {
+	return func(c *loggingConfig) {
+		c.level = level
+	}
+}
+
+// WithMethodLogLevel sets the level successful calls of a method, e.g. "Search", are logged at, overriding
+// WithLogLevel. zerolog.Disabled silences the successful calls of the method.
+func WithMethodLogLevel(method string, level zerolog.Level) LoggingOption {
+	return func(c *loggingConfig) {
+		c.levels[method] = level
+	}
+}
+
+// WithLogSampling logs only one of every n successful calls of a high-volume method, e.g. "FindDocument". Failed
+// calls are always logged.
+func WithLogSampling(method string, n uint32) LoggingOption {
+	return func(c *loggingConfig) {
+		if n <= 1 {
+			delete(c.sampling, method)
+			return
+		}
+		c.sampling[method] = sampler{n: n, count: new(uint32)}
+	}
+}
+
+// WithSlowThreshold additionally logs operations taking longer than the threshold at warn level, including the
+// query body the engine sent for searches, so pathological queries can be found without logging every query.
+func WithSlowThreshold(threshold time.Duration) LoggingOption {
+	return func(c *loggingConfig) {
+		c.slowThreshold = threshold
+	}
+}
+
+// WithLogRedactor redacts the query bodies of slow operations before they are logged, and omits the values of
+// queries from the call logs, so that logs do not carry the values of PII fields searched for.
+func WithLogRedactor(r *search.Redactor) LoggingOption {
+	return func(c *loggingConfig) {
+		c.redactor = r
+	}
+}
+
+// OpenSearchLoggingMiddleware takes a logger as a dependency and returns a OpenSearchMiddleware. Successful calls
+// are logged at debug level and failed calls at error level with their error, unless configured otherwise.
+func OpenSearchLoggingMiddleware(logger zerolog.Logger, opts ...LoggingOption) OpenSearchMiddleware {
+	config := loggingConfig{
+		level:    zerolog.DebugLevel,
+		levels:   make(map[string]zerolog.Level),
+		sampling: make(map[string]sampler),
+	}
+	for _, opt := range opts {
+		opt(&config)
+	}
+
+	return func(next Engine) Engine {
 		return opensearchLoggingMiddleware{
 			logger: logger.With().Str("search", "OpenSearch").Logger(),
 			next:   next,
+			config: config,
 		}
 	}
 }
 
 type opensearchLoggingMiddleware struct {
 	logger zerolog.Logger
-	next   search.SearchEngine
+	next   Engine
+	config loggingConfig
 }
 
 var _ search.SearchEngine = &OpenSearch{}
 
+// warnSlow logs an operation at warn level if it took longer than the slow threshold. The query function, if not
+// nil, returns the body sent to the cluster; it is only called for slow operations.
+func (mw opensearchLoggingMiddleware) warnSlow(ctx context.Context, method string, took time.Duration, query func() interface{}) {
+	if mw.config.slowThreshold <= 0 || took <= mw.config.slowThreshold {
+		return
+	}
+
+	event := mw.logger.Warn().
+		Str("method", method).
+		Float64("took", float64(took)/1e6).
+		Float64("threshold", float64(mw.config.slowThreshold)/1e6)
+	if id := search.RequestID(ctx); id != "" {
+		event = event.Str("requestID", id)
+	}
+	if query != nil {
+		q := query()
+		var err error
+		if mw.config.redactor != nil {
+			q, err = mw.config.redactor.RedactQuery(q)
+		}
+		if err != nil {
+			// The query is not logged unredacted.
+			event = event.AnErr("redactionErr", err)
+		} else if b, err := json.Marshal(q); err == nil {
+			event = event.RawJSON("query", b)
+		}
+	}
+	event.Msg("slow operation")
+}
+
+// queryValue logs the value of a query, unless a redactor is set: values are free text that may carry the data
+// the redaction rules protect, e.g. "email:alice@example.com", so only the fields the query is restricted to are
+// logged then.
+func (mw opensearchLoggingMiddleware) queryValue(query search.Query) func(e *zerolog.Event) {
+	return func(e *zerolog.Event) {
+		if mw.config.redactor == nil {
+			e.Str("query.value", query.Value)
+			return
+		}
+		e.Bool("query.redacted", true)
+		if len(query.Fields) > 0 {
+			e.Strs("query.fields", query.Fields)
+		}
+	}
+}
+
+// sentQueryKey is the context key of the sentQuery of a call.
+type sentQueryKey struct{}
+
+// sentQuery holds the query body the engine sent for a call, with the engine defaults, relevance settings and
+// degradation applied, so the slow-operation log shows the query the cluster executed.
+type sentQuery struct {
+	mu   sync.Mutex
+	body interface{}
+}
+
+// withSentQuery returns a context in which the engine records the query body it sends.
+func withSentQuery(ctx context.Context) (context.Context, *sentQuery) {
+	sent := &sentQuery{}
+	return context.WithValue(ctx, sentQueryKey{}, sent), sent
+}
+
+// recordSentQuery records the query body sent for the call of the context, if it is logged.
+func recordSentQuery(ctx context.Context, body interface{}) {
+	if sent, ok := ctx.Value(sentQueryKey{}).(*sentQuery); ok {
+		sent.mu.Lock()
+		sent.body = body
+		sent.mu.Unlock()
+	}
+}
+
+// query returns the recorded query body for warnSlow, or the result of fallback if the engine did not record
+// one, e.g. because it failed before sending the query.
+func (s *sentQuery) query(fallback func() interface{}) func() interface{} {
+	return func() interface{} {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		if s.body != nil {
+			return s.body
+		}
+		return fallback()
+	}
+}
+
+// msearchDSL returns the query bodies of the searches of an MSearch, for logging.
+func msearchDSL(requests []MSearchRequest) []map[string]interface{} {
+	bodies := make([]map[string]interface{}, 0, len(requests))
+	for _, r := range requests {
+		bodies = append(bodies, r.Query.DSL(r.InstanceID))
+	}
+	return bodies
+}
+
+// log returns the log event of a call of a method, carrying the method name and the request ID of the context,
+// if any. Failed calls are logged at error level; successful calls at the level of the method, if the call is
+// sampled. A missing document counts as success. The event is nil, and logs nothing, if the call is not logged.
+func (mw opensearchLoggingMiddleware) log(ctx context.Context, method string, err error) *zerolog.Event {
+	var event *zerolog.Event
+	if err != nil && !errors.Is(err, search.ErrDocumentNotFound) {
+		event = mw.logger.Error()
+	} else {
+		if s, ok := mw.config.sampling[method]; ok && !s.sample() {
+			return nil
+		}
+		level, ok := mw.config.levels[method]
+		if !ok {
+			level = mw.config.level
+		}
+		event = mw.logger.WithLevel(level)
+	}
+
+	event = event.Str("method", method)
+	if id := search.RequestID(ctx); id != "" {
+		event = event.Str("requestID", id)
+	}
+	return event
+}
+
+// sampler selects one of every n calls.
+type sampler struct {
+	n     uint32
+	count *uint32
+}
+
+func (s sampler) sample() bool {
+	return atomic.AddUint32(s.count, 1)%s.n == 1
+}
+
 func (mw opensearchLoggingMiddleware) CreateIndex(ctx context.Context, indexName string, config map[string]interface{}) (err error) {
 	defer func(begin time.Time) {
-		mw.logger.Log().
-			Float64("took", float64(time.Since(begin))/1e6).
-			Str("method", "CreateIndex").
+		mw.log(ctx, "CreateIndex", err).
 			Str("params.indexName", indexName).
+			AnErr("err", err).
+			Float64("took", float64(time.Since(begin))/1e6).
 			Send()
+		mw.warnSlow(ctx, "CreateIndex", time.Since(begin), nil)
 	}(time.Now())
 	return mw.next.CreateIndex(ctx, indexName, config)
 }
 
-func (mw opensearchLoggingMiddleware) DeleteIndex(ctx context.Context, indexName string) (err error) {
+func (mw opensearchLoggingMiddleware) DeleteIndex(ctx context.Context, indexName string, opts ...search.DeleteIndexOption) (err error) {
 	defer func(begin time.Time) {
-		mw.logger.Log().
+		mw.log(ctx, "DeleteIndex", err).
+			Str("params.indexName", indexName).
+			AnErr("err", err).
 			Float64("took", float64(time.Since(begin))/1e6).
-			Str("method", "DeleteIndex").
+			Send()
+		mw.warnSlow(ctx, "DeleteIndex", time.Since(begin), nil)
+	}(time.Now())
+	return mw.next.DeleteIndex(ctx, indexName, opts...)
+}
+
+func (mw opensearchLoggingMiddleware) RefreshIndex(ctx context.Context, indexName string) (err error) {
+	defer func(begin time.Time) {
+		mw.log(ctx, "RefreshIndex", err).
 			Str("params.indexName", indexName).
+			AnErr("err", err).
+			Float64("took", float64(time.Since(begin))/1e6).
 			Send()
+		mw.warnSlow(ctx, "RefreshIndex", time.Since(begin), nil)
 	}(time.Now())
-	return mw.next.DeleteIndex(ctx, indexName)
+	return mw.next.RefreshIndex(ctx, indexName)
 }
 
 func (mw opensearchLoggingMiddleware) PutDocument(ctx context.Context, instanceID, indexName, entityName, entityID string, document search.Document, refresh ...search.IndexOption) (err error) {
 	defer func(begin time.Time) {
-		mw.logger.Log().
-			Str("method", "PutDocument").
+		mw.log(ctx, "PutDocument", err).
 			Str("params.indexName", indexName).
 			Str("params.instanceID", instanceID).
-			Str("params.indexName", indexName).
+			Str("params.entityName", entityName).
 			Str("params.entityID", entityID).
 			AnErr("err", err).
 			Float64("took", float64(time.Since(begin))/1e6).
 			Send()
+		mw.warnSlow(ctx, "PutDocument", time.Since(begin), nil)
 	}(time.Now())
 	return mw.next.PutDocument(ctx, instanceID, indexName, entityName, entityID, document, refresh...)
 }
 
-func (mw opensearchLoggingMiddleware) FindDocument(ctx context.Context, instanceID, indexName, entityName, entityID string) (_ search.Document, err error) {
+func (mw opensearchLoggingMiddleware) FindDocument(ctx context.Context, instanceID, indexName, entityName, entityID string, opts ...search.FindOption) (_ search.Document, err error) {
 	defer func(begin time.Time) {
-		mw.logger.Log().
-			Str("method", "FindDocument").
+		mw.log(ctx, "FindDocument", err).
 			Str("params.indexName", indexName).
 			Str("params.instanceID", instanceID).
-			Str("params.indexName", indexName).
+			Str("params.entityName", entityName).
 			Str("params.entityID", entityID).
 			AnErr("err", err).
 			Float64("took", float64(time.Since(begin))/1e6).
 			Send()
+		mw.warnSlow(ctx, "FindDocument", time.Since(begin), nil)
 	}(time.Now())
-	return mw.next.FindDocument(ctx, instanceID, indexName, entityName, entityID)
+	return mw.next.FindDocument(ctx, instanceID, indexName, entityName, entityID, opts...)
 }
 
 func (mw opensearchLoggingMiddleware) DeleteDocument(ctx context.Context, instanceID, indexName, entityName, entityID string) (err error) {
 	defer func(begin time.Time) {
-		mw.logger.Log().
-			Str("method", "DeleteDocument").
+		mw.log(ctx, "DeleteDocument", err).
 			Str("params.indexName", indexName).
 			Str("params.instanceID", instanceID).
-			Str("params.indexName", indexName).
+			Str("params.entityName", entityName).
 			Str("params.entityID", entityID).
 			AnErr("err", err).
 			Float64("took", float64(time.Since(begin))/1e6).
 			Send()
+		mw.warnSlow(ctx, "DeleteDocument", time.Since(begin), nil)
 	}(time.Now())
 	return mw.next.DeleteDocument(ctx, instanceID, indexName, entityName, entityID)
 }
 
-func (mw opensearchLoggingMiddleware) Search(ctx context.Context, instanceID string, query search.Query) (_ []search.Document, err error) {
+func (mw opensearchLoggingMiddleware) Search(ctx context.Context, instanceID string, query search.Query) (result search.SearchResult, err error) {
+	ctx, sent := withSentQuery(ctx)
 	defer func(begin time.Time) {
-		mw.logger.Log().
-			Str("method", "DeleteDocument").
+		mw.log(ctx, "Search", err).
 			Str("params.instanceID", instanceID).
-			Str("query.value", query.Value).
+			Func(mw.queryValue(query)).
+			Stringer("query.priority", query.Priority).
+			Int64("result.total", result.Total).
 			AnErr("err", err).
 			Float64("took", float64(time.Since(begin))/1e6).
 			Send()
+		mw.warnSlow(ctx, "Search", time.Since(begin), sent.query(func() interface{} { return query.DSL(instanceID) }))
 	}(time.Now())
 	return mw.next.Search(ctx, instanceID, query)
 }
+
+func (mw opensearchLoggingMiddleware) Ping(ctx context.Context) (err error) {
+	defer func(begin time.Time) {
+		mw.log(ctx, "Ping", err).
+			AnErr("err", err).
+			Float64("took", float64(time.Since(begin))/1e6).
+			Send()
+		mw.warnSlow(ctx, "Ping", time.Since(begin), nil)
+	}(time.Now())
+	return mw.next.Ping(ctx)
+}
+
+func (mw opensearchLoggingMiddleware) ClusterHealth(ctx context.Context) (_ []ClusterHealth, err error) {
+	defer func(begin time.Time) {
+		mw.log(ctx, "ClusterHealth", err).
+			AnErr("err", err).
+			Float64("took", float64(time.Since(begin))/1e6).
+			Send()
+		mw.warnSlow(ctx, "ClusterHealth", time.Since(begin), nil)
+	}(time.Now())
+	return mw.next.ClusterHealth(ctx)
+}
+
+func (mw opensearchLoggingMiddleware) NodesInfo(ctx context.Context) (_ []NodesInfo, err error) {
+	defer func(begin time.Time) {
+		mw.log(ctx, "NodesInfo", err).
+			AnErr("err", err).
+			Float64("took", float64(time.Since(begin))/1e6).
+			Send()
+		mw.warnSlow(ctx, "NodesInfo", time.Since(begin), nil)
+	}(time.Now())
+	return mw.next.NodesInfo(ctx)
+}
+
+func (mw opensearchLoggingMiddleware) DiskUsage(ctx context.Context) (_ []DiskUsage, err error) {
+	defer func(begin time.Time) {
+		mw.log(ctx, "DiskUsage", err).
+			AnErr("err", err).
+			Float64("took", float64(time.Since(begin))/1e6).
+			Send()
+		mw.warnSlow(ctx, "DiskUsage", time.Since(begin), nil)
+	}(time.Now())
+	return mw.next.DiskUsage(ctx)
+}
+
+func (mw opensearchLoggingMiddleware) SetReadOnlyMode(enabled bool) {
+	mw.log(context.Background(), "SetReadOnlyMode", nil).
+		Bool("params.enabled", enabled).
+		Send()
+	mw.next.SetReadOnlyMode(enabled)
+}
+
+func (mw opensearchLoggingMiddleware) ReadOnlyMode() bool {
+	return mw.next.ReadOnlyMode()
+}
+
+func (mw opensearchLoggingMiddleware) BlockWrites(indexName string) {
+	mw.log(context.Background(), "BlockWrites", nil).
+		Str("params.indexName", indexName).
+		Send()
+	mw.next.BlockWrites(indexName)
+}
+
+func (mw opensearchLoggingMiddleware) UnblockWrites(indexName string) {
+	mw.log(context.Background(), "UnblockWrites", nil).
+		Str("params.indexName", indexName).
+		Send()
+	mw.next.UnblockWrites(indexName)
+}
+
+func (mw opensearchLoggingMiddleware) BlockedIndices() []string {
+	return mw.next.BlockedIndices()
+}
+
+func (mw opensearchLoggingMiddleware) DebugQuery(instanceID string, query search.Query) ([]byte, error) {
+	return mw.next.DebugQuery(instanceID, query)
+}
+
+func (mw opensearchLoggingMiddleware) ReplicationStats() ReplicationStats {
+	return mw.next.ReplicationStats()
+}
+
+func (mw opensearchLoggingMiddleware) PromoteSecondary() (err error) {
+	defer func() {
+		primary, secondary := mw.next.Topology()
+		mw.log(context.Background(), "PromoteSecondary", err).
+			Str("result.primary", primary).
+			Str("result.secondary", secondary).
+			AnErr("err", err).
+			Send()
+	}()
+	return mw.next.PromoteSecondary()
+}
+
+func (mw opensearchLoggingMiddleware) Topology() (primary, secondary string) {
+	return mw.next.Topology()
+}
+
+func (mw opensearchLoggingMiddleware) ForceMerge(ctx context.Context, indexName string, opts ...ForceMergeOption) (err error) {
+	defer func(begin time.Time) {
+		mw.log(ctx, "ForceMerge", err).
+			Str("params.indexName", indexName).
+			AnErr("err", err).
+			Float64("took", float64(time.Since(begin))/1e6).
+			Send()
+		mw.warnSlow(ctx, "ForceMerge", time.Since(begin), nil)
+	}(time.Now())
+	return mw.next.ForceMerge(ctx, indexName, opts...)
+}
+
+func (mw opensearchLoggingMiddleware) AnalyzeText(ctx context.Context, indexName, analyzer, text string) (_ []Token, err error) {
+	defer func(begin time.Time) {
+		mw.log(ctx, "AnalyzeText", err).
+			Str("params.indexName", indexName).
+			Str("params.analyzer", analyzer).
+			AnErr("err", err).
+			Float64("took", float64(time.Since(begin))/1e6).
+			Send()
+		mw.warnSlow(ctx, "AnalyzeText", time.Since(begin), nil)
+	}(time.Now())
+	return mw.next.AnalyzeText(ctx, indexName, analyzer, text)
+}
+
+func (mw opensearchLoggingMiddleware) UsageReport(ctx context.Context, indexName string) (_ []TenantUsage, err error) {
+	defer func(begin time.Time) {
+		mw.log(ctx, "UsageReport", err).
+			Str("params.indexName", indexName).
+			AnErr("err", err).
+			Float64("took", float64(time.Since(begin))/1e6).
+			Send()
+		mw.warnSlow(ctx, "UsageReport", time.Since(begin), nil)
+	}(time.Now())
+	return mw.next.UsageReport(ctx, indexName)
+}
+
+func (mw opensearchLoggingMiddleware) UpdateSynonyms(ctx context.Context, indexName, filterName string, synonyms []string) (err error) {
+	defer func(begin time.Time) {
+		mw.log(ctx, "UpdateSynonyms", err).
+			Str("params.indexName", indexName).
+			Str("params.filterName", filterName).
+			Int("params.synonyms", len(synonyms)).
+			AnErr("err", err).
+			Float64("took", float64(time.Since(begin))/1e6).
+			Send()
+		mw.warnSlow(ctx, "UpdateSynonyms", time.Since(begin), nil)
+	}(time.Now())
+	return mw.next.UpdateSynonyms(ctx, indexName, filterName, synonyms)
+}
+
+func (mw opensearchLoggingMiddleware) ReloadSearchAnalyzers(ctx context.Context, indexName string) (err error) {
+	defer func(begin time.Time) {
+		mw.log(ctx, "ReloadSearchAnalyzers", err).
+			Str("params.indexName", indexName).
+			AnErr("err", err).
+			Float64("took", float64(time.Since(begin))/1e6).
+			Send()
+		mw.warnSlow(ctx, "ReloadSearchAnalyzers", time.Since(begin), nil)
+	}(time.Now())
+	return mw.next.ReloadSearchAnalyzers(ctx, indexName)
+}
+
+func (mw opensearchLoggingMiddleware) SelfCheck(ctx context.Context, opts ...SelfCheckOption) (_ []Finding, err error) {
+	defer func(begin time.Time) {
+		mw.log(ctx, "SelfCheck", err).
+			AnErr("err", err).
+			Float64("took", float64(time.Since(begin))/1e6).
+			Send()
+		mw.warnSlow(ctx, "SelfCheck", time.Since(begin), nil)
+	}(time.Now())
+	return mw.next.SelfCheck(ctx, opts...)
+}
+
+func (mw opensearchLoggingMiddleware) HybridSearch(ctx context.Context, instanceID string, query search.Query, opts ...HybridOption) (_ []search.Document, err error) {
+	ctx, sent := withSentQuery(ctx)
+	defer func(begin time.Time) {
+		mw.log(ctx, "HybridSearch", err).
+			Str("params.instanceID", instanceID).
+			Func(mw.queryValue(query)).
+			Stringer("query.priority", query.Priority).
+			AnErr("err", err).
+			Float64("took", float64(time.Since(begin))/1e6).
+			Send()
+		mw.warnSlow(ctx, "HybridSearch", time.Since(begin), sent.query(func() interface{} { return query.DSL(instanceID) }))
+	}(time.Now())
+	return mw.next.HybridSearch(ctx, instanceID, query, opts...)
+}
+
+func (mw opensearchLoggingMiddleware) SimilarDocuments(ctx context.Context, instanceID, indexName, entityName, entityID string, opts ...SimilarOption) (_ []search.Document, err error) {
+	defer func(begin time.Time) {
+		mw.log(ctx, "SimilarDocuments", err).
+			Str("params.instanceID", instanceID).
+			Str("params.indexName", indexName).
+			Str("params.entityName", entityName).
+			Str("params.entityID", entityID).
+			AnErr("err", err).
+			Float64("took", float64(time.Since(begin))/1e6).
+			Send()
+		mw.warnSlow(ctx, "SimilarDocuments", time.Since(begin), nil)
+	}(time.Now())
+	return mw.next.SimilarDocuments(ctx, instanceID, indexName, entityName, entityID, opts...)
+}
+
+func (mw opensearchLoggingMiddleware) ProvisionInstance(ctx context.Context, indexName, instanceID string) (err error) {
+	defer func(begin time.Time) {
+		mw.log(ctx, "ProvisionInstance", err).
+			Str("params.indexName", indexName).
+			Str("params.instanceID", instanceID).
+			AnErr("err", err).
+			Float64("took", float64(time.Since(begin))/1e6).
+			Send()
+		mw.warnSlow(ctx, "ProvisionInstance", time.Since(begin), nil)
+	}(time.Now())
+	return mw.next.ProvisionInstance(ctx, indexName, instanceID)
+}
+
+func (mw opensearchLoggingMiddleware) RegisterPercolator(ctx context.Context, instanceID, indexName, queryID string, query search.Query) (err error) {
+	ctx, sent := withSentQuery(ctx)
+	defer func(begin time.Time) {
+		mw.log(ctx, "RegisterPercolator", err).
+			Str("params.instanceID", instanceID).
+			Str("params.indexName", indexName).
+			Str("params.queryID", queryID).
+			Func(mw.queryValue(query)).
+			AnErr("err", err).
+			Float64("took", float64(time.Since(begin))/1e6).
+			Send()
+		mw.warnSlow(ctx, "RegisterPercolator", time.Since(begin), sent.query(func() interface{} { return query.DSL(instanceID) }))
+	}(time.Now())
+	return mw.next.RegisterPercolator(ctx, instanceID, indexName, queryID, query)
+}
+
+func (mw opensearchLoggingMiddleware) UnregisterPercolator(ctx context.Context, instanceID, indexName, queryID string) (err error) {
+	defer func(begin time.Time) {
+		mw.log(ctx, "UnregisterPercolator", err).
+			Str("params.instanceID", instanceID).
+			Str("params.indexName", indexName).
+			Str("params.queryID", queryID).
+			AnErr("err", err).
+			Float64("took", float64(time.Since(begin))/1e6).
+			Send()
+		mw.warnSlow(ctx, "UnregisterPercolator", time.Since(begin), nil)
+	}(time.Now())
+	return mw.next.UnregisterPercolator(ctx, instanceID, indexName, queryID)
+}
+
+func (mw opensearchLoggingMiddleware) Percolate(ctx context.Context, instanceID, indexName, entityName string, document search.Document) (_ []string, err error) {
+	defer func(begin time.Time) {
+		mw.log(ctx, "Percolate", err).
+			Str("params.instanceID", instanceID).
+			Str("params.indexName", indexName).
+			Str("params.entityName", entityName).
+			AnErr("err", err).
+			Float64("took", float64(time.Since(begin))/1e6).
+			Send()
+		mw.warnSlow(ctx, "Percolate", time.Since(begin), nil)
+	}(time.Now())
+	return mw.next.Percolate(ctx, instanceID, indexName, entityName, document)
+}
+
+func (mw opensearchLoggingMiddleware) BulkPutDocuments(ctx context.Context, indexName string, documents []search.SourceDocument, opts ...search.IndexOption) (report search.BulkReport, err error) {
+	defer func(begin time.Time) {
+		mw.log(ctx, "BulkPutDocuments", err).
+			Str("params.indexName", indexName).
+			Int("params.documents", len(documents)).
+			Int("report.succeeded", report.Succeeded).
+			Int("report.failed", len(report.Failed)).
+			AnErr("err", err).
+			Float64("took", float64(time.Since(begin))/1e6).
+			Send()
+		mw.warnSlow(ctx, "BulkPutDocuments", time.Since(begin), nil)
+	}(time.Now())
+	return mw.next.BulkPutDocuments(ctx, indexName, documents, opts...)
+}
+
+func (mw opensearchLoggingMiddleware) BulkDeleteDocuments(ctx context.Context, indexName string, keys []search.DocumentKey) (report search.BulkReport, err error) {
+	defer func(begin time.Time) {
+		mw.log(ctx, "BulkDeleteDocuments", err).
+			Str("params.indexName", indexName).
+			Int("params.keys", len(keys)).
+			Int("report.succeeded", report.Succeeded).
+			Int("report.failed", len(report.Failed)).
+			AnErr("err", err).
+			Float64("took", float64(time.Since(begin))/1e6).
+			Send()
+		mw.warnSlow(ctx, "BulkDeleteDocuments", time.Since(begin), nil)
+	}(time.Now())
+	return mw.next.BulkDeleteDocuments(ctx, indexName, keys)
+}
+
+func (mw opensearchLoggingMiddleware) Warmup(ctx context.Context, indexName string) (err error) {
+	defer func(begin time.Time) {
+		mw.log(ctx, "Warmup", err).
+			Str("params.indexName", indexName).
+			AnErr("err", err).
+			Float64("took", float64(time.Since(begin))/1e6).
+			Send()
+		mw.warnSlow(ctx, "Warmup", time.Since(begin), nil)
+	}(time.Now())
+	return mw.next.Warmup(ctx, indexName)
+}
+
+func (mw opensearchLoggingMiddleware) SimulateIndex(ctx context.Context, indexName string, document search.Document) (_ SimulationReport, err error) {
+	defer func(begin time.Time) {
+		mw.log(ctx, "SimulateIndex", err).
+			Str("params.indexName", indexName).
+			AnErr("err", err).
+			Float64("took", float64(time.Since(begin))/1e6).
+			Send()
+		mw.warnSlow(ctx, "SimulateIndex", time.Since(begin), nil)
+	}(time.Now())
+	return mw.next.SimulateIndex(ctx, indexName, document)
+}
+
+func (mw opensearchLoggingMiddleware) ConsistencyToken(ctx context.Context, indexName string, keys []search.DocumentKey) (_ ConsistencyToken, err error) {
+	defer func(begin time.Time) {
+		mw.log(ctx, "ConsistencyToken", err).
+			Str("params.indexName", indexName).
+			Int("params.keys", len(keys)).
+			AnErr("err", err).
+			Float64("took", float64(time.Since(begin))/1e6).
+			Send()
+		mw.warnSlow(ctx, "ConsistencyToken", time.Since(begin), nil)
+	}(time.Now())
+	return mw.next.ConsistencyToken(ctx, indexName, keys)
+}
+
+func (mw opensearchLoggingMiddleware) WaitForSearchable(ctx context.Context, token ConsistencyToken) (err error) {
+	defer func(begin time.Time) {
+		mw.log(ctx, "WaitForSearchable", err).
+			Str("params.index", token.Index).
+			Int("params.documents", len(token.SeqNos)).
+			AnErr("err", err).
+			Float64("took", float64(time.Since(begin))/1e6).
+			Send()
+		mw.warnSlow(ctx, "WaitForSearchable", time.Since(begin), nil)
+	}(time.Now())
+	return mw.next.WaitForSearchable(ctx, token)
+}
+
+func (mw opensearchLoggingMiddleware) MigrateDocuments(ctx context.Context, indexName string, rules ...search.TransformRule) (updated int64, err error) {
+	defer func(begin time.Time) {
+		mw.log(ctx, "MigrateDocuments", err).
+			Str("params.indexName", indexName).
+			Int("params.rules", len(rules)).
+			Int64("result.updated", updated).
+			AnErr("err", err).
+			Float64("took", float64(time.Since(begin))/1e6).
+			Send()
+		mw.warnSlow(ctx, "MigrateDocuments", time.Since(begin), nil)
+	}(time.Now())
+	return mw.next.MigrateDocuments(ctx, indexName, rules...)
+}
+
+func (mw opensearchLoggingMiddleware) MSearch(ctx context.Context, requests []MSearchRequest) (_ []MSearchResponse, err error) {
+	ctx, sent := withSentQuery(ctx)
+	defer func(begin time.Time) {
+		mw.log(ctx, "MSearch", err).
+			Int("params.requests", len(requests)).
+			AnErr("err", err).
+			Float64("took", float64(time.Since(begin))/1e6).
+			Send()
+		mw.warnSlow(ctx, "MSearch", time.Since(begin), sent.query(func() interface{} { return msearchDSL(requests) }))
+	}(time.Now())
+	return mw.next.MSearch(ctx, requests)
+}
+
+func (mw opensearchLoggingMiddleware) SubmitAsyncSearch(ctx context.Context, instanceID string, query search.Query, opts ...AsyncSearchOption) (job AsyncSearch, err error) {
+	ctx, sent := withSentQuery(ctx)
+	defer func(begin time.Time) {
+		mw.log(ctx, "SubmitAsyncSearch", err).
+			Str("params.instanceID", instanceID).
+			Func(mw.queryValue(query)).
+			Str("job.id", job.ID).
+			AnErr("err", err).
+			Float64("took", float64(time.Since(begin))/1e6).
+			Send()
+		mw.warnSlow(ctx, "SubmitAsyncSearch", time.Since(begin), sent.query(func() interface{} { return query.DSL(instanceID) }))
+	}(time.Now())
+	return mw.next.SubmitAsyncSearch(ctx, instanceID, query, opts...)
+}
+
+func (mw opensearchLoggingMiddleware) GetAsyncSearch(ctx context.Context, id string) (job AsyncSearch, err error) {
+	defer func(begin time.Time) {
+		mw.log(ctx, "GetAsyncSearch", err).
+			Str("params.id", id).
+			Str("job.state", string(job.State)).
+			AnErr("err", err).
+			Float64("took", float64(time.Since(begin))/1e6).
+			Send()
+		mw.warnSlow(ctx, "GetAsyncSearch", time.Since(begin), nil)
+	}(time.Now())
+	return mw.next.GetAsyncSearch(ctx, id)
+}
+
+func (mw opensearchLoggingMiddleware) CancelAsyncSearch(ctx context.Context, id string) (err error) {
+	defer func(begin time.Time) {
+		mw.log(ctx, "CancelAsyncSearch", err).
+			Str("params.id", id).
+			AnErr("err", err).
+			Float64("took", float64(time.Since(begin))/1e6).
+			Send()
+		mw.warnSlow(ctx, "CancelAsyncSearch", time.Since(begin), nil)
+	}(time.Now())
+	return mw.next.CancelAsyncSearch(ctx, id)
+}
+
+func (mw opensearchLoggingMiddleware) SampleDocuments(ctx context.Context, indexName string, size int) (documents []search.Document, err error) {
+	defer func(begin time.Time) {
+		mw.log(ctx, "SampleDocuments", err).
+			Str("params.indexName", indexName).
+			Int("params.size", size).
+			Int("result.documents", len(documents)).
+			AnErr("err", err).
+			Float64("took", float64(time.Since(begin))/1e6).
+			Send()
+		mw.warnSlow(ctx, "SampleDocuments", time.Since(begin), nil)
+	}(time.Now())
+	return mw.next.SampleDocuments(ctx, indexName, size)
+}
+
+func (mw opensearchLoggingMiddleware) Reconcile(ctx context.Context, indexName string, opts ...ReconcileOption) (report ReconcileReport, err error) {
+	defer func(begin time.Time) {
+		mw.log(ctx, "Reconcile", err).
+			Str("params.indexName", indexName).
+			Int64("result.scanned", report.Scanned).
+			Int64("result.missing", report.Missing).
+			Int64("result.divergent", report.Divergent).
+			Int64("result.extra", report.Extra).
+			Int64("result.repaired", report.Repaired).
+			Int("result.failed", len(report.Failed)).
+			AnErr("err", err).
+			Float64("took", float64(time.Since(begin))/1e6).
+			Send()
+		mw.warnSlow(ctx, "Reconcile", time.Since(begin), nil)
+	}(time.Now())
+	return mw.next.Reconcile(ctx, indexName, opts...)
+}
+
+func (mw opensearchLoggingMiddleware) ReplayWrites(ctx context.Context) (replayed int, err error) {
+	defer func(begin time.Time) {
+		mw.log(ctx, "ReplayWrites", err).
+			Int("result.replayed", replayed).
+			AnErr("err", err).
+			Float64("took", float64(time.Since(begin))/1e6).
+			Send()
+		mw.warnSlow(ctx, "ReplayWrites", time.Since(begin), nil)
+	}(time.Now())
+	return mw.next.ReplayWrites(ctx)
+}
+
+func (mw opensearchLoggingMiddleware) CopyIndex(ctx context.Context, indexName string, from, to search.ClusterTarget, opts ...CopyOption) (report CopyReport, err error) {
+	defer func(begin time.Time) {
+		mw.log(ctx, "CopyIndex", err).
+			Str("params.indexName", indexName).
+			Int("params.from", int(from)).
+			Int("params.to", int(to)).
+			Int64("result.scanned", report.Scanned).
+			Int64("result.copied", report.Copied).
+			Int("result.failed", len(report.Failed)).
+			AnErr("err", err).
+			Float64("took", float64(time.Since(begin))/1e6).
+			Send()
+		mw.warnSlow(ctx, "CopyIndex", time.Since(begin), nil)
+	}(time.Now())
+	return mw.next.CopyIndex(ctx, indexName, from, to, opts...)
+}
+
+func (mw opensearchLoggingMiddleware) ListIndices(ctx context.Context, pattern string) (indices []IndexInfo, err error) {
+	defer func(begin time.Time) {
+		mw.log(ctx, "ListIndices", err).
+			Str("params.pattern", pattern).
+			Int("result.indices", len(indices)).
+			AnErr("err", err).
+			Float64("took", float64(time.Since(begin))/1e6).
+			Send()
+		mw.warnSlow(ctx, "ListIndices", time.Since(begin), nil)
+	}(time.Now())
+	return mw.next.ListIndices(ctx, pattern)
+}
+
+func (mw opensearchLoggingMiddleware) DescribeIndex(ctx context.Context, indexName string) (_ IndexDescription, err error) {
+	defer func(begin time.Time) {
+		mw.log(ctx, "DescribeIndex", err).
+			Str("params.indexName", indexName).
+			AnErr("err", err).
+			Float64("took", float64(time.Since(begin))/1e6).
+			Send()
+		mw.warnSlow(ctx, "DescribeIndex", time.Since(begin), nil)
+	}(time.Now())
+	return mw.next.DescribeIndex(ctx, indexName)
+}
@@ -0,0 +1,158 @@
+package opensearch
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/joshilesanmi/open-search-dev/search"
+	opensearch "github.com/opensearch-project/opensearch-go/v2"
+	opensearchapi "github.com/opensearch-project/opensearch-go/v2/opensearchapi"
+)
+
+// percolatorEntity is the entity name under which registered queries are stored.
+const percolatorEntity = "percolator"
+
+// MapPercolator maps the fields of an index configuration needed to store percolator queries registered with
+// RegisterPercolator. The index must also map every field the registered queries refer to, as the percolated
+// documents are analyzed with these mappings; typically the properties of the searched index are copied into it.
+func MapPercolator(config map[string]interface{}) error {
+	for field, typ := range map[string]string{
+		"query":       "percolator",
+		"query_id":    "keyword",
+		"instance_id": "keyword",
+		"entity_name": "keyword",
+	} {
+		properties, name, err := fieldProperties(config, field)
+		if err != nil {
+			return err
+		}
+		properties[name] = map[string]interface{}{"type": typ}
+	}
+
+	return nil
+}
+
+// RegisterPercolator stores a query of an instance in a percolator index, so that documents percolated with
+// Percolate report it when they match, e.g. to notify a user when a new person matching a saved search is
+// indexed. Registering a query ID again replaces the query.
+func (os *OpenSearch) RegisterPercolator(ctx context.Context, instanceID, indexName, queryID string, query search.Query) error {
+	if err := os.checkWritable(indexName); err != nil {
+		return err
+	}
+
+	if queryID == "" {
+		return errors.New("query ID is required")
+	}
+
+	searchQuery, err := os.constructSearchQuery(instanceID, query)
+	if err != nil {
+		return err
+	}
+	body, err := json.Marshal(map[string]interface{}{
+		"query":       searchQuery["query"],
+		"query_id":    queryID,
+		"instance_id": instanceID,
+		"entity_name": percolatorEntity,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to marshal percolator query %v", err)
+	}
+	recordSentQuery(ctx, json.RawMessage(body))
+
+	documentID := search.GenerateDocumentID(instanceID, percolatorEntity, queryID)
+
+	return os.write(ctx, func(client *opensearch.Client) error {
+		return os.putDocument(ctx, client, indexName, documentID, body, "false")
+	})
+}
+
+// UnregisterPercolator removes a query registered with RegisterPercolator.
+func (os *OpenSearch) UnregisterPercolator(ctx context.Context, instanceID, indexName, queryID string) error {
+	if err := os.checkWritable(indexName); err != nil {
+		return err
+	}
+
+	documentID := search.GenerateDocumentID(instanceID, percolatorEntity, queryID)
+
+	return os.write(ctx, func(client *opensearch.Client) error {
+		return os.deleteDocument(ctx, client, indexName, documentID)
+	})
+}
+
+// Percolate returns the IDs of the queries of an instance registered in a percolator index that match the given
+// document. The document is not indexed; it is given the same metadata PutDocument would add, so queries
+// restricted to the instance or entity match as they would in a search.
+func (os *OpenSearch) Percolate(ctx context.Context, instanceID, indexName, entityName string, document search.Document) ([]string, error) {
+	if instanceID == "" {
+		return nil, errors.New("instanceID is required")
+	}
+	if entityName == "" {
+		return nil, errors.New("entityName is required")
+	}
+
+	d := make(search.Document, len(document)+2)
+	for k, v := range document {
+		d[k] = v
+	}
+	d["instance_id"] = instanceID
+	d["entity_name"] = entityName
+
+	body, err := json.Marshal(map[string]interface{}{
+		"_source": []string{"query_id"},
+		"query": map[string]interface{}{
+			"bool": map[string]interface{}{
+				"must": map[string]interface{}{
+					"percolate": map[string]interface{}{
+						"field":    "query",
+						"document": d,
+					},
+				},
+				"filter": map[string]interface{}{
+					"term": map[string]string{
+						"instance_id": instanceID,
+					},
+				},
+			},
+		},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal percolate query: %v", err)
+	}
+
+	read, preference, err := os.readCluster(ctx, ReadSearch)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := os.executeReadRequest(ctx, read.client, opensearchapi.SearchRequest{
+		Index:      []string{indexName},
+		Body:       bytes.NewReader(body),
+		Preference: preference,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var r struct {
+		Hits struct {
+			Hits []struct {
+				Source struct {
+					QueryID string `json:"query_id"`
+				} `json:"_source"`
+			} `json:"hits"`
+		} `json:"hits"`
+	}
+	if err := os.decodeResponse(resp, &r); err != nil {
+		return nil, err
+	}
+
+	ids := make([]string, 0, len(r.Hits.Hits))
+	for _, hit := range r.Hits.Hits {
+		ids = append(ids, hit.Source.QueryID)
+	}
+
+	return ids, nil
+}
@@ -0,0 +1,158 @@
+package opensearch
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path"
+
+	opensearchapi "github.com/opensearch-project/opensearch-go/v2/opensearchapi"
+)
+
+// ErrPolicyDenied is returned when a destructive operation is vetoed by a DestructivePolicy.
+var ErrPolicyDenied = errors.New("operation denied by policy")
+
+// ErrConfirmationRequired is returned when a DestructivePolicy requires the operation to be confirmed, e.g.
+// with search.WithConfirmation, and it was not.
+var ErrConfirmationRequired = errors.New("operation requires confirmation")
+
+// Destructive operations evaluated by policies.
+const (
+	OperationDeleteIndex   = "DeleteIndex"   // DeleteIndex.
+	OperationDeleteByQuery = "DeleteByQuery" // The cascade of DeleteDocument to children, see WithRelationships.
+)
+
+// DestructiveOperation describes a destructive operation about to be performed.
+type DestructiveOperation struct {
+	Operation     string // The operation, e.g. OperationDeleteIndex.
+	Index         string // The index the operation targets.
+	Environment   string // The environment label configured with WithEnvironment.
+	DocumentCount int64  // The number of documents in the index on the primary cluster.
+	Confirmed     bool   // True if the caller confirmed the operation.
+}
+
+// Verdict is the outcome of evaluating a DestructivePolicy.
+type Verdict int
+
+const (
+	Allow               Verdict = iota // The operation may proceed.
+	Deny                               // The operation is vetoed.
+	RequireConfirmation                // The operation may only proceed if it was confirmed.
+)
+
+// PolicyDecision is the verdict of a DestructivePolicy with a human-readable reason.
+type PolicyDecision struct {
+	Verdict Verdict
+	Reason  string
+}
+
+// DestructivePolicy is evaluated before every destructive operation. Policies are evaluated in the order they
+// were configured, and the first one that does not allow the operation decides.
+type DestructivePolicy func(ctx context.Context, op DestructiveOperation) PolicyDecision
+
+// WithDestructivePolicy adds a policy evaluated before destructive operations such as deleting an index.
+func WithDestructivePolicy(policy DestructivePolicy) OpenSearchOption {
+	return func(os *OpenSearch) error {
+		if policy == nil {
+			return errors.New("policy must not be nil")
+		}
+		os.policies = append(os.policies, policy)
+		return nil
+	}
+}
+
+// WithEnvironment sets the environment label (e.g. "production") passed to destructive operation policies.
+func WithEnvironment(environment string) OpenSearchOption {
+	return func(os *OpenSearch) error {
+		os.environment = environment
+		return nil
+	}
+}
+
+// DenyIndexPattern returns a policy vetoing destructive operations on indices matching a glob pattern.
+func DenyIndexPattern(pattern string) DestructivePolicy {
+	return func(_ context.Context, op DestructiveOperation) PolicyDecision {
+		if ok, _ := path.Match(pattern, op.Index); ok {
+			return PolicyDecision{Verdict: Deny, Reason: fmt.Sprintf("index matches protected pattern %q", pattern)}
+		}
+		return PolicyDecision{Verdict: Allow}
+	}
+}
+
+// ConfirmAboveDocumentCount returns a policy requiring confirmation for destructive operations on indices
+// holding more than limit documents.
+func ConfirmAboveDocumentCount(limit int64) DestructivePolicy {
+	return func(_ context.Context, op DestructiveOperation) PolicyDecision {
+		if op.DocumentCount > limit {
+			return PolicyDecision{
+				Verdict: RequireConfirmation,
+				Reason:  fmt.Sprintf("index holds %d documents, more than %d", op.DocumentCount, limit),
+			}
+		}
+		return PolicyDecision{Verdict: Allow}
+	}
+}
+
+// ConfirmInEnvironments returns a policy requiring confirmation for every destructive operation in the given
+// environments.
+func ConfirmInEnvironments(environments ...string) DestructivePolicy {
+	return func(_ context.Context, op DestructiveOperation) PolicyDecision {
+		for _, env := range environments {
+			if op.Environment == env {
+				return PolicyDecision{Verdict: RequireConfirmation, Reason: fmt.Sprintf("environment is %q", env)}
+			}
+		}
+		return PolicyDecision{Verdict: Allow}
+	}
+}
+
+// authorizeDestructive evaluates the configured policies for a destructive operation on an index.
+func (os *OpenSearch) authorizeDestructive(ctx context.Context, operation, indexName string, confirmed bool) error {
+	if len(os.policies) == 0 {
+		return nil
+	}
+
+	count, err := os.countDocuments(ctx, indexName)
+	if err != nil {
+		return fmt.Errorf("failed to count documents for policy evaluation: %v", err)
+	}
+
+	op := DestructiveOperation{
+		Operation:     operation,
+		Index:         indexName,
+		Environment:   os.environment,
+		DocumentCount: count,
+		Confirmed:     confirmed,
+	}
+
+	for _, policy := range os.policies {
+		decision := policy(ctx, op)
+		switch decision.Verdict {
+		case Deny:
+			return fmt.Errorf("%s on index %q: %s: %w", operation, indexName, decision.Reason, ErrPolicyDenied)
+		case RequireConfirmation:
+			if !confirmed {
+				return fmt.Errorf("%s on index %q: %s: %w", operation, indexName, decision.Reason, ErrConfirmationRequired)
+			}
+		}
+	}
+
+	return nil
+}
+
+// countDocuments returns the number of documents in an index on the primary cluster.
+func (os *OpenSearch) countDocuments(ctx context.Context, indexName string) (int64, error) {
+	resp, err := os.executeReadRequest(ctx, os.primary().client, opensearchapi.CountRequest{Index: []string{indexName}})
+	if err != nil {
+		return 0, err
+	}
+
+	var r struct {
+		Count int64 `json:"count"`
+	}
+	if err := os.decodeResponse(resp, &r); err != nil {
+		return 0, err
+	}
+
+	return r.Count, nil
+}
@@ -0,0 +1,75 @@
+package opensearch
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/joshilesanmi/open-search-dev/search"
+)
+
+// PoolConfig configures the pool serving one priority class of queries.
+type PoolConfig struct {
+	MaxConcurrent int           // Maximum number of queries of the class in flight. Zero means unlimited.
+	Timeout       time.Duration // Deadline applied to each query of the class. Zero means no deadline.
+}
+
+// WithPriorityPool configures the pool serving a priority class. Giving batch queries a small pool with a long
+// timeout and interactive queries a larger pool with a short timeout keeps background exports from starving
+// user-facing searches. Priority classes without a configured pool are not limited.
+func WithPriorityPool(priority search.Priority, config PoolConfig) OpenSearchOption {
+	return func(os *OpenSearch) error {
+		if config.MaxConcurrent < 0 {
+			return errors.New("pool concurrency must not be negative")
+		}
+		if os.pools == nil {
+			os.pools = make(map[search.Priority]*priorityPool)
+		}
+		os.pools[priority] = newPriorityPool(config)
+		return nil
+	}
+}
+
+// priorityPool limits the concurrency and duration of the queries of one priority class.
+type priorityPool struct {
+	slots   chan struct{}
+	timeout time.Duration
+}
+
+func newPriorityPool(config PoolConfig) *priorityPool {
+	p := &priorityPool{timeout: config.Timeout}
+	if config.MaxConcurrent > 0 {
+		p.slots = make(chan struct{}, config.MaxConcurrent)
+	}
+	return p
+}
+
+// acquire waits for a free slot in the pool serving the priority class and applies its timeout to the context.
+// The returned function must be called once the query has finished.
+func (os *OpenSearch) acquire(ctx context.Context, priority search.Priority) (context.Context, func(), error) {
+	p, ok := os.pools[priority]
+	if !ok {
+		return ctx, func() {}, nil
+	}
+
+	if p.slots != nil {
+		select {
+		case p.slots <- struct{}{}:
+		case <-ctx.Done():
+			return ctx, nil, fmt.Errorf("waiting for %s query slot: %w", priority, ctx.Err())
+		}
+	}
+
+	cancel := func() {}
+	if p.timeout > 0 {
+		ctx, cancel = context.WithTimeout(ctx, p.timeout)
+	}
+
+	return ctx, func() {
+		cancel()
+		if p.slots != nil {
+			<-p.slots
+		}
+	}, nil
+}
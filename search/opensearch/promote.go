@@ -0,0 +1,203 @@
+package opensearch
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ErrNoSecondary is returned by PromoteSecondary and PromoteSecondaryConfig if no secondary cluster is configured.
+var ErrNoSecondary = errors.New("no secondary cluster configured")
+
+// topology assigns the clusters to the primary and secondary roles. It is replaced as a whole, so every
+// operation sees a consistent assignment even while PromoteSecondary runs.
+type topology struct {
+	primary   cluster
+	secondary *cluster  // Nil if no secondary cluster is configured.
+	replicas  []cluster // Configured WithReplica; not affected by PromoteSecondary.
+}
+
+// primary returns the current primary cluster.
+func (os *OpenSearch) primary() cluster {
+	return os.topology.Load().primary
+}
+
+// secondary returns the current secondary cluster, if one is configured.
+func (os *OpenSearch) secondary() (cluster, bool) {
+	t := os.topology.Load()
+	if t.secondary == nil {
+		return cluster{}, false
+	}
+	return *t.secondary, true
+}
+
+// PromoteSecondary atomically swaps the primary and the secondary cluster, so a cluster migration can be cut
+// over without recreating the engine. Writes started before the swap complete against the previous assignment.
+// The clusters keep their names, while errors and reports use the roles they hold after the swap. A suspension
+// of secondary writes by WritePrimaryOnlyOnSecondaryFailure is lifted, as it applied to the other cluster.
+func (os *OpenSearch) PromoteSecondary() error {
+	for {
+		current := os.topology.Load()
+		if current.secondary == nil {
+			return ErrNoSecondary
+		}
+
+		// The write policy stays with the secondary role.
+		primary := *current.secondary
+		primary.role, primary.policy = PrimaryCluster, nil
+		secondary := current.primary
+		secondary.role, secondary.policy = SecondaryCluster, current.secondary.policy
+
+		next := &topology{primary: primary, secondary: &secondary, replicas: current.replicas}
+		if os.topology.CompareAndSwap(current, next) {
+			break
+		}
+	}
+
+	if os.dualWrite != nil {
+		os.dualWrite.suspendedUntil.Store(0)
+	}
+	return nil
+}
+
+// Topology returns the names of the current primary and secondary cluster, see WithPrimaryName and
+// WithSecondaryName. The secondary name is empty if no secondary cluster is configured.
+func (os *OpenSearch) Topology() (primary, secondary string) {
+	t := os.topology.Load()
+	if t.secondary != nil {
+		secondary = t.secondary.name
+	}
+	return t.primary.name, secondary
+}
+
+// promotedKeys are the pairs of configuration keys swapped by PromoteSecondaryConfig.
+var promotedKeys = [][2]string{
+	{"endpoint", "secondary_endpoint"},
+	{"primary_name", "secondary_name"},
+}
+
+// PromoteSecondaryConfig swaps the primary and the secondary cluster of a configuration file, see LoadConfig, so
+// the engines created from it afterwards use the promoted cluster as primary. It is the counterpart of
+// PromoteSecondary for services that create their engine with NewFromConfig: running engines keep their
+// assignment until they are recreated. Only the endpoints and names change; YAML files keep their comments and
+// layout. The file is replaced atomically.
+func PromoteSecondaryConfig(path string) error {
+	config, err := LoadConfig(path)
+	if err != nil {
+		return err
+	}
+	if config.SecondaryEndpoint == "" {
+		return ErrNoSecondary
+	}
+
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		b, err = promoteYAML(b)
+	default:
+		b, err = promoteJSON(b)
+	}
+	if err != nil {
+		return fmt.Errorf("config %s: %w", path, err)
+	}
+
+	return replaceFile(path, b)
+}
+
+// promoteJSON swaps the promoted keys of a JSON configuration.
+func promoteJSON(b []byte) ([]byte, error) {
+	var doc map[string]interface{}
+	if err := json.Unmarshal(b, &doc); err != nil {
+		return nil, err
+	}
+	for _, keys := range promotedKeys {
+		a, aok := doc[keys[0]]
+		c, cok := doc[keys[1]]
+		delete(doc, keys[0])
+		delete(doc, keys[1])
+		if aok {
+			doc[keys[1]] = a
+		}
+		if cok {
+			doc[keys[0]] = c
+		}
+	}
+
+	out, err := json.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append(out, '\n'), nil
+}
+
+// promoteYAML swaps the promoted keys of a YAML configuration by renaming them in place, so the rest of the
+// document is kept as written.
+func promoteYAML(b []byte) ([]byte, error) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(b, &doc); err != nil {
+		return nil, err
+	}
+	if doc.Kind != yaml.DocumentNode || len(doc.Content) != 1 || doc.Content[0].Kind != yaml.MappingNode {
+		return nil, errors.New("expected a mapping")
+	}
+
+	m := doc.Content[0]
+	for _, keys := range promotedKeys {
+		for i := 0; i < len(m.Content); i += 2 {
+			switch key := m.Content[i]; key.Value {
+			case keys[0]:
+				key.Value = keys[1]
+			case keys[1]:
+				key.Value = keys[0]
+			}
+		}
+	}
+
+	var out bytes.Buffer
+	enc := yaml.NewEncoder(&out)
+	enc.SetIndent(2)
+	if err := enc.Encode(&doc); err != nil {
+		return nil, err
+	}
+	if err := enc.Close(); err != nil {
+		return nil, err
+	}
+	return out.Bytes(), nil
+}
+
+// replaceFile atomically replaces the contents of a file, keeping its permissions, by renaming a temporary file
+// of the same directory over it.
+func replaceFile(path string, b []byte) error {
+	info, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(b); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Chmod(info.Mode().Perm()); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
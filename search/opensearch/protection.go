@@ -0,0 +1,111 @@
+package opensearch
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"path"
+	"sort"
+
+	"github.com/joshilesanmi/open-search-dev/search"
+	opensearch "github.com/opensearch-project/opensearch-go/v2"
+	opensearchapi "github.com/opensearch-project/opensearch-go/v2/opensearchapi"
+)
+
+// ErrIndexProtected is returned by DeleteIndex for protected indices unless the deletion is forced.
+var ErrIndexProtected = search.ErrIndexProtected
+
+// WithProtectedIndices protects indices whose names match any of the given glob patterns (e.g. "*-prod")
+// from deletion unless the deletion is forced.
+func WithProtectedIndices(patterns ...string) OpenSearchOption {
+	return func(os *OpenSearch) error {
+		for _, p := range patterns {
+			if _, err := path.Match(p, ""); err != nil {
+				return fmt.Errorf("invalid protected index pattern %q: %v", p, err)
+			}
+		}
+		os.protectedPatterns = append(os.protectedPatterns, patterns...)
+		return nil
+	}
+}
+
+// checkDeletable returns ErrIndexProtected if the index matches a protected pattern or is the write target
+// of an alias on any configured cluster.
+func (os *OpenSearch) checkDeletable(ctx context.Context, indexName string) error {
+	for _, p := range os.protectedPatterns {
+		if ok, _ := path.Match(p, indexName); ok {
+			return fmt.Errorf("index %q matches protected pattern %q: %w", indexName, p, ErrIndexProtected)
+		}
+	}
+
+	return os.eachCluster(ctx, func(client *opensearch.Client) error {
+		aliases, err := os.writeAliases(ctx, client, indexName)
+		if err != nil {
+			return fmt.Errorf("failed to check aliases: %v", err)
+		}
+		if len(aliases) > 0 {
+			return fmt.Errorf("index %q is the write target of aliases %v: %w", indexName, aliases, ErrIndexProtected)
+		}
+		return nil
+	})
+}
+
+// writeAliases returns the aliases that write to the index: aliases marking it as their write index, and
+// aliases pointing only at this index, which makes it their implicit write index.
+func (os *OpenSearch) writeAliases(ctx context.Context, client *opensearch.Client, indexName string) ([]string, error) {
+	aliases, err := os.getAliases(ctx, client, opensearchapi.IndicesGetAliasRequest{Index: []string{indexName}})
+	if err != nil {
+		return nil, err
+	}
+
+	var writers []string
+	for _, index := range aliases {
+		for alias, def := range index.Aliases {
+			if def.IsWriteIndex != nil {
+				if *def.IsWriteIndex {
+					writers = append(writers, alias)
+				}
+				continue
+			}
+
+			targets, err := os.getAliases(ctx, client, opensearchapi.IndicesGetAliasRequest{Name: []string{alias}})
+			if err != nil {
+				return nil, err
+			}
+			if len(targets) == 1 {
+				writers = append(writers, alias)
+			}
+		}
+	}
+
+	sort.Strings(writers)
+	return writers, nil
+}
+
+// indexAliases is the response of the get alias API for a single index.
+type indexAliases struct {
+	Aliases map[string]struct {
+		IsWriteIndex *bool `json:"is_write_index"`
+	} `json:"aliases"`
+}
+
+// getAliases executes a get alias request, returning the aliases keyed by index. A missing index or alias
+// yields an empty result.
+func (os *OpenSearch) getAliases(ctx context.Context, client *opensearch.Client, req opensearchapi.IndicesGetAliasRequest) (map[string]indexAliases, error) {
+	resp, err := os.executeReadRequest(ctx, client, req)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode == http.StatusNotFound {
+		resp.Body.Close()
+		return nil, nil
+	}
+
+	var r map[string]indexAliases
+	if err := os.decodeResponse(resp, &r); err != nil {
+		return nil, err
+	}
+
+	return r, nil
+}
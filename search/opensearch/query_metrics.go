@@ -0,0 +1,146 @@
+package opensearch
+
+import (
+	"encoding/json"
+	"errors"
+	"sort"
+	"sync"
+	"time"
+)
+
+// QueryMetrics receives one observation per executed query. The shape identifies the structure of the query
+// with all literal values removed, so metrics can be grouped by query pattern without recording user input.
+type QueryMetrics interface {
+	ObserveQuery(cluster, shape string, took time.Duration, err error)
+}
+
+// WithQueryMetrics configures the QueryMetrics that receives an observation for every search.
+func WithQueryMetrics(metrics QueryMetrics) OpenSearchOption {
+	return func(os *OpenSearch) error {
+		if metrics == nil {
+			return errors.New("query metrics must not be nil")
+		}
+		os.queryMetrics = metrics
+		return nil
+	}
+}
+
+// QueryShape normalises a query body into its shape: the same structure with every literal value replaced by "?".
+// Map keys are kept, since they name query clauses and fields rather than user input. The result is canonical
+// JSON and can be used directly as a metrics label.
+func QueryShape(body interface{}) string {
+	b, err := json.Marshal(shapeOf(body))
+	if err != nil {
+		return "?"
+	}
+	return string(b)
+}
+
+// shapeOf replaces every leaf value in v with "?".
+func shapeOf(v interface{}) interface{} {
+	switch t := v.(type) {
+	case map[string]interface{}:
+		m := make(map[string]interface{}, len(t))
+		for k, v := range t {
+			m[k] = shapeOf(v)
+		}
+		return m
+	case map[string]string:
+		m := make(map[string]interface{}, len(t))
+		for k := range t {
+			m[k] = "?"
+		}
+		return m
+	case []interface{}:
+		s := make([]interface{}, 0, len(t))
+		for _, v := range t {
+			s = append(s, shapeOf(v))
+		}
+		return s
+	default:
+		// Fall back to a JSON round-trip for any other composite type, so typed maps and slices are handled
+		// the same way as their generic equivalents.
+		b, err := json.Marshal(t)
+		if err != nil {
+			return "?"
+		}
+		var generic interface{}
+		if err := json.Unmarshal(b, &generic); err != nil {
+			return "?"
+		}
+		switch generic.(type) {
+		case map[string]interface{}, []interface{}:
+			return shapeOf(generic)
+		}
+		return "?"
+	}
+}
+
+// ShapeStats holds the aggregated observations for a single cluster and query shape.
+type ShapeStats struct {
+	Cluster string
+	Shape   string
+	Count   int
+	Errors  int
+	Total   time.Duration
+	Max     time.Duration
+}
+
+// Mean returns the mean latency of the observed queries.
+func (s ShapeStats) Mean() time.Duration {
+	if s.Count == 0 {
+		return 0
+	}
+	return s.Total / time.Duration(s.Count)
+}
+
+// ShapeRecorder is an in-memory QueryMetrics implementation that aggregates latency and error counts per
+// cluster and query shape. It is safe for concurrent use.
+type ShapeRecorder struct {
+	mu    sync.Mutex
+	stats map[[2]string]*ShapeStats
+}
+
+// NewShapeRecorder returns an empty ShapeRecorder.
+func NewShapeRecorder() *ShapeRecorder {
+	return &ShapeRecorder{stats: make(map[[2]string]*ShapeStats)}
+}
+
+// ObserveQuery records a single query execution.
+func (r *ShapeRecorder) ObserveQuery(cluster, shape string, took time.Duration, err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	key := [2]string{cluster, shape}
+	s, ok := r.stats[key]
+	if !ok {
+		s = &ShapeStats{Cluster: cluster, Shape: shape}
+		r.stats[key] = s
+	}
+
+	s.Count++
+	s.Total += took
+	if took > s.Max {
+		s.Max = took
+	}
+	if err != nil {
+		s.Errors++
+	}
+}
+
+// Snapshot returns the aggregated statistics, slowest mean latency first.
+func (r *ShapeRecorder) Snapshot() []ShapeStats {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stats := make([]ShapeStats, 0, len(r.stats))
+	for _, s := range r.stats {
+		stats = append(stats, *s)
+	}
+
+	sort.Slice(stats, func(i, j int) bool {
+		return stats[i].Mean() > stats[j].Mean()
+	})
+
+	return stats
+}
@@ -0,0 +1,237 @@
+package opensearch
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/joshilesanmi/open-search-dev/search"
+)
+
+// Operation classifies engine operations for rate limiting.
+type Operation int
+
+const (
+	OperationRead  Operation = iota // Reads and searches, e.g. FindDocument and Search.
+	OperationWrite                  // Single-document writes, e.g. PutDocument and DeleteDocument.
+	OperationBulk                   // Bulk writes, charged one token per document, e.g. BulkPutDocuments.
+)
+
+// String returns the name of the operation type.
+func (o Operation) String() string {
+	switch o {
+	case OperationRead:
+		return "read"
+	case OperationWrite:
+		return "write"
+	case OperationBulk:
+		return "bulk"
+	default:
+		return "unknown"
+	}
+}
+
+// RateLimit configures the token bucket limiting one operation type.
+type RateLimit struct {
+	Rate  float64 // Sustained number of operations per second.
+	Burst int     // Maximum number of operations admitted at once. Defaults to 1.
+}
+
+// RateLimitOption is a function type that applies configuration options to the rate limiting middleware.
+type RateLimitOption func(*rateLimitConfig)
+
+// rateLimitConfig configures the rate limiting middleware.
+type rateLimitConfig struct {
+	clock search.Clock // Source of the time tokens are refilled by and waited on.
+}
+
+// WithRateLimitClock replaces the system clock the buckets are refilled by, typically with the clock given to
+// the engine with WithClock, so the limits can be tested deterministically.
+func WithRateLimitClock(clock search.Clock) RateLimitOption {
+	return func(c *rateLimitConfig) {
+		if clock != nil {
+			c.clock = clock
+		}
+	}
+}
+
+// OpenSearchRateLimitMiddleware returns an OpenSearchMiddleware that limits the rate of operations per operation
+// type with a token bucket, so a backfill cannot overwhelm a shared cluster. Callers wait for a token rather than
+// failing, unless their context is done first. Operation types without a limit, and administrative operations
+// such as CreateIndex, are not limited. Every engine wrapped by the middleware gets its own buckets.
+func OpenSearchRateLimitMiddleware(limits map[Operation]RateLimit, opts ...RateLimitOption) OpenSearchMiddleware {
+	config := rateLimitConfig{clock: search.SystemClock}
+	for _, opt := range opts {
+		opt(&config)
+	}
+
+	return func(next Engine) Engine {
+		buckets := make(map[Operation]*tokenBucket, len(limits))
+		for op, limit := range limits {
+			if limit.Rate <= 0 {
+				continue
+			}
+			buckets[op] = newTokenBucket(limit, config.clock)
+		}
+		return opensearchRateLimitMiddleware{
+			Engine:  next,
+			buckets: buckets,
+		}
+	}
+}
+
+// tokenBucket admits operations at a sustained rate with bursts of up to its capacity.
+type tokenBucket struct {
+	rate  float64
+	burst float64
+	clock search.Clock
+
+	mu     sync.Mutex
+	tokens float64
+	last   time.Time
+}
+
+func newTokenBucket(limit RateLimit, clock search.Clock) *tokenBucket {
+	burst := float64(limit.Burst)
+	if burst < 1 {
+		burst = 1
+	}
+	return &tokenBucket{
+		rate:   limit.Rate,
+		burst:  burst,
+		clock:  clock,
+		tokens: burst,
+		last:   clock.Now(),
+	}
+}
+
+// wait blocks until n tokens were taken. Costs larger than the burst are taken in chunks of the burst, each
+// waiting for the bucket to refill, so a bulk request of n documents is admitted at the rate of n single writes.
+// Chunks taken before the context is done are not returned to the bucket.
+func (b *tokenBucket) wait(ctx context.Context, n int) error {
+	for remaining := float64(n); remaining > 0; {
+		chunk := math.Min(remaining, b.burst)
+		if err := b.take(ctx, chunk); err != nil {
+			return err
+		}
+		remaining -= chunk
+	}
+	return nil
+}
+
+// take blocks until cost tokens, at most the burst, are available and takes them.
+func (b *tokenBucket) take(ctx context.Context, cost float64) error {
+	for {
+		b.mu.Lock()
+		now := b.clock.Now()
+		b.tokens += now.Sub(b.last).Seconds() * b.rate
+		if b.tokens > b.burst {
+			b.tokens = b.burst
+		}
+		b.last = now
+
+		if b.tokens >= cost {
+			b.tokens -= cost
+			b.mu.Unlock()
+			return nil
+		}
+		delay := time.Duration((cost - b.tokens) / b.rate * float64(time.Second))
+		b.mu.Unlock()
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-search.After(b.clock, delay):
+		}
+	}
+}
+
+// opensearchRateLimitMiddleware limits the rate of the operations of the embedded Engine.
+type opensearchRateLimitMiddleware struct {
+	Engine
+	buckets map[Operation]*tokenBucket
+}
+
+// wait takes n tokens from the bucket of the operation type, if it is limited.
+func (mw opensearchRateLimitMiddleware) wait(ctx context.Context, op Operation, n int) error {
+	b, ok := mw.buckets[op]
+	if !ok {
+		return nil
+	}
+	if err := b.wait(ctx, n); err != nil {
+		return fmt.Errorf("waiting for %s rate limit: %w", op, err)
+	}
+	return nil
+}
+
+func (mw opensearchRateLimitMiddleware) PutDocument(ctx context.Context, instanceID, indexName, entityName, entityID string, document search.Document, opts ...search.IndexOption) error {
+	if err := mw.wait(ctx, OperationWrite, 1); err != nil {
+		return err
+	}
+	return mw.Engine.PutDocument(ctx, instanceID, indexName, entityName, entityID, document, opts...)
+}
+
+func (mw opensearchRateLimitMiddleware) DeleteDocument(ctx context.Context, instanceID, indexName, entityName, entityID string) error {
+	if err := mw.wait(ctx, OperationWrite, 1); err != nil {
+		return err
+	}
+	return mw.Engine.DeleteDocument(ctx, instanceID, indexName, entityName, entityID)
+}
+
+func (mw opensearchRateLimitMiddleware) FindDocument(ctx context.Context, instanceID, indexName, entityName, entityID string, opts ...search.FindOption) (search.Document, error) {
+	if err := mw.wait(ctx, OperationRead, 1); err != nil {
+		return nil, err
+	}
+	return mw.Engine.FindDocument(ctx, instanceID, indexName, entityName, entityID, opts...)
+}
+
+func (mw opensearchRateLimitMiddleware) Search(ctx context.Context, instanceID string, query search.Query) (search.SearchResult, error) {
+	if err := mw.wait(ctx, OperationRead, 1); err != nil {
+		return search.SearchResult{}, err
+	}
+	return mw.Engine.Search(ctx, instanceID, query)
+}
+
+func (mw opensearchRateLimitMiddleware) HybridSearch(ctx context.Context, instanceID string, query search.Query, opts ...HybridOption) ([]search.Document, error) {
+	if err := mw.wait(ctx, OperationRead, 1); err != nil {
+		return nil, err
+	}
+	return mw.Engine.HybridSearch(ctx, instanceID, query, opts...)
+}
+
+func (mw opensearchRateLimitMiddleware) SimilarDocuments(ctx context.Context, instanceID, indexName, entityName, entityID string, opts ...SimilarOption) ([]search.Document, error) {
+	if err := mw.wait(ctx, OperationRead, 1); err != nil {
+		return nil, err
+	}
+	return mw.Engine.SimilarDocuments(ctx, instanceID, indexName, entityName, entityID, opts...)
+}
+
+func (mw opensearchRateLimitMiddleware) MSearch(ctx context.Context, requests []MSearchRequest) ([]MSearchResponse, error) {
+	if err := mw.wait(ctx, OperationRead, len(requests)); err != nil {
+		return nil, err
+	}
+	return mw.Engine.MSearch(ctx, requests)
+}
+
+func (mw opensearchRateLimitMiddleware) SubmitAsyncSearch(ctx context.Context, instanceID string, query search.Query, opts ...AsyncSearchOption) (AsyncSearch, error) {
+	if err := mw.wait(ctx, OperationRead, 1); err != nil {
+		return AsyncSearch{}, err
+	}
+	return mw.Engine.SubmitAsyncSearch(ctx, instanceID, query, opts...)
+}
+
+func (mw opensearchRateLimitMiddleware) BulkPutDocuments(ctx context.Context, indexName string, documents []search.SourceDocument, opts ...search.IndexOption) (search.BulkReport, error) {
+	if err := mw.wait(ctx, OperationBulk, len(documents)); err != nil {
+		return search.BulkReport{}, err
+	}
+	return mw.Engine.BulkPutDocuments(ctx, indexName, documents, opts...)
+}
+
+func (mw opensearchRateLimitMiddleware) BulkDeleteDocuments(ctx context.Context, indexName string, keys []search.DocumentKey) (search.BulkReport, error) {
+	if err := mw.wait(ctx, OperationBulk, len(keys)); err != nil {
+		return search.BulkReport{}, err
+	}
+	return mw.Engine.BulkDeleteDocuments(ctx, indexName, keys)
+}
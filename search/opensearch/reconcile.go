@@ -0,0 +1,324 @@
+package opensearch
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/joshilesanmi/open-search-dev/search"
+	opensearch "github.com/opensearch-project/opensearch-go/v2"
+	opensearchapi "github.com/opensearch-project/opensearch-go/v2/opensearchapi"
+)
+
+// RepairDirection selects the cluster whose documents are authoritative when Reconcile repairs divergence.
+type RepairDirection int
+
+const (
+	RepairNone               RepairDirection = iota // Only report the divergence.
+	RepairPrimaryToSecondary                        // Copy documents from the primary to the secondary.
+	RepairSecondaryToPrimary                        // Copy documents from the secondary to the primary.
+)
+
+// ReconcileOption is a function type that applies configuration options to a ReconcileOptions instance.
+type ReconcileOption func(*ReconcileOptions)
+
+// ReconcileOptions defines configuration options for Reconcile.
+type ReconcileOptions struct {
+	Direction RepairDirection     // Which cluster is repaired, if any. Defaults to RepairNone.
+	BatchSize int                 // Number of documents compared per round trip. Defaults to 500.
+	KeepAlive time.Duration       // How long the scroll is kept between batches. Defaults to one minute.
+	OnDiff    func(ReconcileDiff) // Called for every document that differs between the clusters, if set.
+}
+
+// WithRepair returns a ReconcileOption that repairs the divergent, missing and extra documents of one cluster
+// from the other.
+func WithRepair(direction RepairDirection) ReconcileOption {
+	return func(opts *ReconcileOptions) {
+		opts.Direction = direction
+	}
+}
+
+// WithReconcileBatchSize returns a ReconcileOption that sets the number of documents compared per round trip.
+func WithReconcileBatchSize(size int) ReconcileOption {
+	return func(opts *ReconcileOptions) {
+		opts.BatchSize = size
+	}
+}
+
+// WithDiffs returns a ReconcileOption that passes every document found to differ between the clusters to fn, to
+// report the divergence beyond the counts of the ReconcileReport. fn is called sequentially while the index is
+// scanned.
+func WithDiffs(fn func(ReconcileDiff)) ReconcileOption {
+	return func(opts *ReconcileOptions) {
+		opts.OnDiff = fn
+	}
+}
+
+// DiffKind describes how a document differs between the clusters.
+type DiffKind string
+
+const (
+	DiffMissing   DiffKind = "missing"   // The document is missing from the other cluster.
+	DiffDivergent DiffKind = "divergent" // The source of the document differs between the clusters.
+	DiffExtra     DiffKind = "extra"     // The document is missing from the authoritative cluster.
+)
+
+// ReconcileDiff is a document that differs between the clusters.
+type ReconcileDiff struct {
+	ID     string   `json:"id"`               // The engine ID of the document.
+	Kind   DiffKind `json:"kind"`             // How the document differs.
+	Fields []string `json:"fields,omitempty"` // The dotted paths of the differing fields of a divergent document.
+}
+
+// ReconcileFailure is a document that could not be repaired.
+type ReconcileFailure struct {
+	ID     string // The engine ID of the document.
+	Reason string // Why the repair failed.
+}
+
+// ReconcileReport summarises a reconciliation of an index between the primary and the secondary cluster.
+type ReconcileReport struct {
+	Index     string             // The reconciled index.
+	Scanned   int64              // Documents of the authoritative cluster that were compared.
+	Missing   int64              // Documents missing from the other cluster.
+	Divergent int64              // Documents whose source differs between the clusters.
+	Extra     int64              // Documents of the other cluster missing from the authoritative cluster.
+	Repaired  int64              // Missing, divergent and extra documents that were repaired.
+	Failed    []ReconcileFailure // Documents that could not be repaired.
+}
+
+// Consistent reports whether the clusters held the same documents.
+func (r ReconcileReport) Consistent() bool {
+	return r.Missing == 0 && r.Divergent == 0 && r.Extra == 0
+}
+
+// Reconcile scans an index on the primary and the secondary cluster with scroll searches and compares their
+// documents. The primary is authoritative unless the repair direction is RepairSecondaryToPrimary. With a repair
+// direction, missing and divergent documents are copied from the authoritative cluster and extra documents are
+// deleted from the other; the writes are not refreshed. Documents written while the index is scanned may be
+// reported as divergent.
+func (os *OpenSearch) Reconcile(ctx context.Context, indexName string, opts ...ReconcileOption) (ReconcileReport, error) {
+	options := &ReconcileOptions{BatchSize: 500, KeepAlive: time.Minute}
+	for _, opt := range opts {
+		opt(options)
+	}
+
+	report := ReconcileReport{Index: indexName}
+	secondary, ok := os.secondary()
+	if !ok {
+		return report, ErrNoSecondary
+	}
+	if options.BatchSize <= 0 {
+		return report, errors.New("reconcile batch size must be positive")
+	}
+	if options.Direction != RepairNone {
+		if err := os.checkWritable(indexName); err != nil {
+			return report, err
+		}
+	}
+
+	source, target := os.primary(), secondary
+	if options.Direction == RepairSecondaryToPrimary {
+		source, target = target, source
+	}
+	repair := options.Direction != RepairNone
+
+	// Compare the documents of the authoritative cluster with the other cluster.
+	err := os.scroll(ctx, source, indexName, options.BatchSize, options.KeepAlive, true, func(hits []searchHit) error {
+		found, err := os.multiGet(ctx, target, indexName, hits, true)
+		if err != nil {
+			return err
+		}
+
+		for _, hit := range hits {
+			report.Scanned++
+			doc, ok := found[hit.ID]
+			switch {
+			case !ok:
+				report.Missing++
+				options.diff(ReconcileDiff{ID: hit.ID, Kind: DiffMissing})
+			case !compareDocuments(hit.Source, doc, os.ignoredFields...):
+				report.Divergent++
+				options.diff(ReconcileDiff{ID: hit.ID, Kind: DiffDivergent, Fields: diffFields(hit.Source, doc, os.ignoredFields...)})
+			default:
+				continue
+			}
+			os.replication.mismatches.Add(1)
+
+			if repair {
+				os.repair(ctx, &report, hit.ID, func() error {
+					body, err := json.Marshal(hit.Source)
+					if err != nil {
+						return err
+					}
+					return os.putDocument(ctx, target.client, indexName, hit.ID, body, "")
+				})
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return report, err
+	}
+
+	// Find the documents of the other cluster the authoritative cluster does not have.
+	err = os.scroll(ctx, target, indexName, options.BatchSize, options.KeepAlive, false, func(hits []searchHit) error {
+		found, err := os.multiGet(ctx, source, indexName, hits, false)
+		if err != nil {
+			return err
+		}
+
+		for _, hit := range hits {
+			if _, ok := found[hit.ID]; ok {
+				continue
+			}
+			report.Extra++
+			options.diff(ReconcileDiff{ID: hit.ID, Kind: DiffExtra})
+			os.replication.mismatches.Add(1)
+			if repair {
+				os.repair(ctx, &report, hit.ID, func() error {
+					return os.deleteDocument(ctx, target.client, indexName, hit.ID)
+				})
+			}
+		}
+		return nil
+	})
+
+	return report, err
+}
+
+func (opts *ReconcileOptions) diff(d ReconcileDiff) {
+	if opts.OnDiff != nil {
+		opts.OnDiff(d)
+	}
+}
+
+// repair applies the repair of a document and records its outcome in the report.
+func (os *OpenSearch) repair(ctx context.Context, report *ReconcileReport, id string, fn func() error) {
+	if err := fn(); err != nil {
+		report.Failed = append(report.Failed, ReconcileFailure{ID: id, Reason: err.Error()})
+		return
+	}
+	report.Repaired++
+}
+
+// scroll passes every document of an index on a cluster to fn, in batches of the given size, keeping the scroll
+// alive between batches for keepAlive. The source of the documents is only fetched if withSource is set. The
+// scroll is cleared when done.
+func (os *OpenSearch) scroll(ctx context.Context, c cluster, indexName string, batchSize int, keepAlive time.Duration, withSource bool, fn func(hits []searchHit) error) error {
+	body, err := json.Marshal(map[string]interface{}{
+		"size":    batchSize,
+		"_source": withSource,
+		"sort":    []interface{}{"_doc"},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to marshal scroll query %v", err)
+	}
+
+	resp, err := os.executeReadRequest(ctx, c.client, opensearchapi.SearchRequest{
+		Index:  []string{indexName},
+		Body:   bytes.NewReader(body),
+		Scroll: keepAlive,
+	})
+	if err != nil {
+		return &ClusterError{Cluster: c.role, Name: c.name, Err: err}
+	}
+
+	var scrollID string
+	defer func() {
+		if scrollID != "" {
+			os.clearScroll(c.client, scrollID)
+		}
+	}()
+
+	for {
+		var r struct {
+			ScrollID string `json:"_scroll_id"`
+			Hits     struct {
+				Hits []searchHit `json:"hits"`
+			} `json:"hits"`
+		}
+		if err := os.decodeResponse(resp, &r); err != nil {
+			return &ClusterError{Cluster: c.role, Name: c.name, Err: err}
+		}
+		scrollID = r.ScrollID
+
+		if len(r.Hits.Hits) == 0 {
+			return nil
+		}
+		if err := fn(r.Hits.Hits); err != nil {
+			return err
+		}
+
+		resp, err = os.executeReadRequest(ctx, c.client, opensearchapi.ScrollRequest{
+			ScrollID: scrollID,
+			Scroll:   keepAlive,
+		})
+		if err != nil {
+			return &ClusterError{Cluster: c.role, Name: c.name, Err: err}
+		}
+	}
+}
+
+// clearScroll releases the resources of a scroll. It is not bound to the context of the operation, which may
+// have been cancelled, and failures are ignored as the scroll expires anyway.
+func (os *OpenSearch) clearScroll(client *opensearch.Client, scrollID string) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	resp, err := opensearchapi.ClearScrollRequest{ScrollID: []string{scrollID}}.Do(ctx, client)
+	if err == nil {
+		resp.Body.Close()
+	}
+}
+
+// multiGet fetches the documents of the hits from a cluster in real time and returns those found, keyed by ID.
+// The source of the documents is only fetched if withSource is set.
+func (os *OpenSearch) multiGet(ctx context.Context, c cluster, indexName string, hits []searchHit, withSource bool) (map[string]search.Document, error) {
+	ids := make([]string, 0, len(hits))
+	for _, hit := range hits {
+		ids = append(ids, hit.ID)
+	}
+
+	body, err := json.Marshal(map[string]interface{}{"ids": ids})
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal document ids %v", err)
+	}
+
+	realtime := true
+	resp, err := os.executeReadRequest(ctx, c.client, opensearchapi.MgetRequest{
+		Index:    indexName,
+		Body:     bytes.NewReader(body),
+		Realtime: &realtime,
+		Source:   withSource,
+	})
+	if err != nil {
+		return nil, &ClusterError{Cluster: c.role, Name: c.name, Err: err}
+	}
+
+	var r struct {
+		Docs []struct {
+			ID     string          `json:"_id"`
+			Found  bool            `json:"found"`
+			Source search.Document `json:"_source"`
+		} `json:"docs"`
+	}
+	if err := os.decodeResponse(resp, &r); err != nil {
+		// The index is missing on the cluster, so none of the documents is found.
+		if errors.Is(err, ErrIndexNotFound) {
+			return map[string]search.Document{}, nil
+		}
+		return nil, &ClusterError{Cluster: c.role, Name: c.name, Err: err}
+	}
+
+	found := make(map[string]search.Document, len(r.Docs))
+	for _, d := range r.Docs {
+		if d.Found {
+			found[d.ID] = d.Source
+		}
+	}
+	return found, nil
+}
@@ -0,0 +1,71 @@
+package opensearch
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ReplicaCluster identifies the additional clusters configured WithReplica in errors.
+const ReplicaCluster = "replica"
+
+// replicaTarget is an additional cluster that writes are replicated to.
+type replicaTarget struct {
+	name     string
+	endpoint string
+	policy   *dualWrite
+}
+
+// WithReplica adds a cluster that every write is replicated to, after the primary and the secondary cluster,
+// e.g. to stage a migration across several clusters at once. The config sets how a failed write to the replica
+// is handled, independently of the other clusters; its zero value fails the operation like WriteStrict. The
+// name labels the replica in logs, spans, errors and metrics and must be unique. Replicas are never read from.
+func WithReplica(name, endpoint string, config DualWriteConfig) OpenSearchOption {
+	return func(os *OpenSearch) error {
+		if name == "" {
+			return errors.New("replica cluster name must not be empty")
+		}
+		if endpoint == "" {
+			return fmt.Errorf("replica %q: endpoint must not be empty", name)
+		}
+		for _, r := range os.replicas {
+			if r.name == name {
+				return fmt.Errorf("replica %q is already configured", name)
+			}
+		}
+
+		policy, err := newDualWrite(config)
+		if err != nil {
+			return fmt.Errorf("replica %q: %w", name, err)
+		}
+
+		os.replicas = append(os.replicas, replicaTarget{name: name, endpoint: endpoint, policy: policy})
+		return nil
+	}
+}
+
+// replicaEndpoints returns the endpoints of the replicas.
+func (os *OpenSearch) replicaEndpoints() []string {
+	endpoints := make([]string, 0, len(os.replicas))
+	for _, r := range os.replicas {
+		endpoints = append(endpoints, r.endpoint)
+	}
+	return endpoints
+}
+
+// newReplicas creates the clients of the replicas.
+func (os *OpenSearch) newReplicas() ([]cluster, error) {
+	replicas := make([]cluster, 0, len(os.replicas))
+	for _, r := range os.replicas {
+		client, err := os.newClient(ReplicaCluster, r.endpoint, r.name)
+		if err != nil {
+			return nil, fmt.Errorf("replica %q: %w", r.name, err)
+		}
+		replicas = append(replicas, cluster{
+			role:   ReplicaCluster,
+			name:   r.name,
+			client: client,
+			policy: r.policy,
+		})
+	}
+	return replicas, nil
+}
@@ -0,0 +1,78 @@
+package opensearch
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// ReplicationStats describes how far the secondary and replica clusters may have fallen behind the primary, to
+// judge when it is safe to cut over to them. Counters accumulate since the engine was created.
+type ReplicationStats struct {
+	SecondaryWrites   int64     // Writes to the secondary and replica clusters, including failed ones. Bulk requests count once.
+	SecondaryFailures int64     // Secondary writes that failed, including suppressed failures and failed bulk items.
+	SkippedWrites     int64     // Secondary writes skipped after a recent failure, see WritePrimaryOnlyOnSecondaryFailure.
+	Mismatches        int64     // Documents found to differ between the clusters by FindDocument and Reconcile.
+	PendingWrites     int64     // Secondary writes currently in flight.
+	QueuedWrites      int64     // Writes waiting in the write-behind queue, see WithWriteBehind.
+	LastWrite         time.Time // When a secondary write last succeeded, or the zero time.
+	LastFailure       time.Time // When a secondary write last failed, or the zero time.
+}
+
+// replicationStats tracks the ReplicationStats of an engine. It is safe for concurrent use.
+type replicationStats struct {
+	writes      atomic.Int64
+	failures    atomic.Int64
+	skipped     atomic.Int64
+	mismatches  atomic.Int64
+	pending     atomic.Int64
+	queued      atomic.Int64
+	lastWrite   atomic.Int64 // Unix nanoseconds, or zero.
+	lastFailure atomic.Int64 // Unix nanoseconds, or zero.
+}
+
+// ReplicationStats returns the replication statistics of the secondary cluster. They are all zero if no
+// secondary cluster is configured.
+func (os *OpenSearch) ReplicationStats() ReplicationStats {
+	r := &os.replication
+	return ReplicationStats{
+		SecondaryWrites:   r.writes.Load(),
+		SecondaryFailures: r.failures.Load(),
+		SkippedWrites:     r.skipped.Load(),
+		Mismatches:        r.mismatches.Load(),
+		PendingWrites:     r.pending.Load(),
+		QueuedWrites:      r.queued.Load(),
+		LastWrite:         unixTime(r.lastWrite.Load()),
+		LastFailure:       unixTime(r.lastFailure.Load()),
+	}
+}
+
+// writeSecondary applies a write to the secondary cluster and records it in the replication statistics. failed
+// is the number of items that failed without failing the write, e.g. rejected bulk items.
+func (os *OpenSearch) writeSecondary(fn func() (failed int, err error)) error {
+	r := &os.replication
+	r.writes.Add(1)
+	r.pending.Add(1)
+	defer r.pending.Add(-1)
+
+	failed, err := fn()
+	now := os.clock.Now().UnixNano()
+	if err != nil {
+		failed++
+	}
+	if failed > 0 {
+		r.failures.Add(int64(failed))
+		r.lastFailure.Store(now)
+	}
+	if err == nil {
+		r.lastWrite.Store(now)
+	}
+	return err
+}
+
+// unixTime converts Unix nanoseconds to a time, mapping zero to the zero time.
+func unixTime(nanos int64) time.Time {
+	if nanos == 0 {
+		return time.Time{}
+	}
+	return time.Unix(0, nanos)
+}
@@ -0,0 +1,53 @@
+package opensearch
+
+import (
+	"context"
+	"io"
+	"net/http"
+
+	opensearchapi "github.com/opensearch-project/opensearch-go/v2/opensearchapi"
+)
+
+// rawRequest is an opensearchapi.Request for endpoints that opensearchapi does not cover, such as the APIs
+// provided by OpenSearch plugins.
+type rawRequest struct {
+	Method string
+	Path   string
+	Params map[string]string
+	Body   io.Reader
+}
+
+// Do executes the request and returns the response or an error.
+func (r rawRequest) Do(ctx context.Context, transport opensearchapi.Transport) (*opensearchapi.Response, error) {
+	req, err := http.NewRequest(r.Method, r.Path, r.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(r.Params) > 0 {
+		q := req.URL.Query()
+		for k, v := range r.Params {
+			q.Set(k, v)
+		}
+		req.URL.RawQuery = q.Encode()
+	}
+
+	if r.Body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	if ctx != nil {
+		req = req.WithContext(ctx)
+	}
+
+	res, err := transport.Perform(req)
+	if err != nil {
+		return nil, err
+	}
+
+	return &opensearchapi.Response{
+		StatusCode: res.StatusCode,
+		Body:       res.Body,
+		Header:     res.Header,
+	}, nil
+}
@@ -0,0 +1,212 @@
+package opensearch
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/joshilesanmi/open-search-dev/search"
+)
+
+// RetryConfig configures how OpenSearchRetryMiddleware retries operations failing for transient reasons.
+type RetryConfig struct {
+	MaxAttempts int           // Maximum number of attempts, including the first one. Defaults to 3.
+	Backoff     time.Duration // Upper bound of the delay before the first retry, doubled for every following retry. Defaults to 100ms.
+	MaxBackoff  time.Duration // Upper bound of the delay between retries. Defaults to 5s.
+
+	// Clock is the source of the time deadlines are checked against and delays are waited on, typically the
+	// clock given to the engine with WithClock. Defaults to the system clock.
+	Clock search.Clock
+}
+
+// OpenSearchRetryMiddleware returns an OpenSearchMiddleware that retries idempotent operations failing with a
+// 429, a 5xx or a network error. Retries are delayed with exponential backoff and full jitter, so clients
+// failing at the same time do not retry in lockstep, unless the cluster asked for a delay with Retry-After; such
+// delays are capped to MaxBackoff. Operations that are not idempotent, such as CreateIndex or BulkPutDocuments,
+// are passed through unchanged.
+func OpenSearchRetryMiddleware(config RetryConfig) OpenSearchMiddleware {
+	if config.MaxAttempts <= 0 {
+		config.MaxAttempts = 3
+	}
+	if config.Backoff <= 0 {
+		config.Backoff = 100 * time.Millisecond
+	}
+	if config.MaxBackoff <= 0 {
+		config.MaxBackoff = 5 * time.Second
+	}
+	if config.MaxBackoff < config.Backoff {
+		config.MaxBackoff = config.Backoff
+	}
+	if config.Clock == nil {
+		config.Clock = search.SystemClock
+	}
+	return func(next Engine) Engine {
+		return opensearchRetryMiddleware{
+			Engine: next,
+			config: config,
+		}
+	}
+}
+
+// opensearchRetryMiddleware retries the idempotent operations of the embedded Engine.
+type opensearchRetryMiddleware struct {
+	Engine
+	config RetryConfig
+}
+
+// retry calls fn until it succeeds, fails with an error that is not transient, the attempts are exhausted or
+// the context is done.
+func retry[T any](ctx context.Context, config RetryConfig, fn func() (T, error)) (T, error) {
+	backoff := config.Backoff
+	for attempt := 1; ; attempt++ {
+		v, err := fn()
+		if err == nil || attempt >= config.MaxAttempts || !transient(err) {
+			return v, err
+		}
+
+		// A delay asked for by the cluster takes precedence over the backoff, but a single response must not
+		// stall the caller for longer than MaxBackoff.
+		delay := time.Duration(rand.Int63n(int64(backoff) + 1))
+		if hint := retryAfter(err); hint > 0 {
+			delay = hint
+			if delay > config.MaxBackoff {
+				delay = config.MaxBackoff
+			}
+		}
+		// There is no point in waiting for a retry the context does not leave time for.
+		if deadline, ok := ctx.Deadline(); ok && deadline.Sub(config.Clock.Now()) < delay {
+			return v, err
+		}
+
+		select {
+		case <-ctx.Done():
+			return v, err
+		case <-search.After(config.Clock, delay):
+		}
+
+		if backoff *= 2; backoff > config.MaxBackoff {
+			backoff = config.MaxBackoff
+		}
+	}
+}
+
+// retryErr is retry for operations that only return an error.
+func retryErr(ctx context.Context, config RetryConfig, fn func() error) error {
+	_, err := retry(ctx, config, func() (struct{}, error) {
+		return struct{}{}, fn()
+	})
+	return err
+}
+
+// retryAfter returns the delay an error asks for before retrying, if any.
+func retryAfter(err error) time.Duration {
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		return apiErr.RetryAfter
+	}
+	return 0
+}
+
+// transient reports whether an error is likely to go away when the operation is retried.
+func transient(err error) bool {
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		return apiErr.StatusCode == http.StatusTooManyRequests || apiErr.StatusCode >= http.StatusInternalServerError
+	}
+
+	var netErr net.Error
+	return errors.As(err, &netErr)
+}
+
+func (mw opensearchRetryMiddleware) RefreshIndex(ctx context.Context, indexName string) error {
+	return retryErr(ctx, mw.config, func() error {
+		return mw.Engine.RefreshIndex(ctx, indexName)
+	})
+}
+
+// PutDocument is retried because documents are written with an explicit ID, so repeating the write converges
+// to the same state.
+func (mw opensearchRetryMiddleware) PutDocument(ctx context.Context, instanceID, indexName, entityName, entityID string, document search.Document, opts ...search.IndexOption) error {
+	return retryErr(ctx, mw.config, func() error {
+		return mw.Engine.PutDocument(ctx, instanceID, indexName, entityName, entityID, document, opts...)
+	})
+}
+
+func (mw opensearchRetryMiddleware) FindDocument(ctx context.Context, instanceID, indexName, entityName, entityID string, opts ...search.FindOption) (search.Document, error) {
+	return retry(ctx, mw.config, func() (search.Document, error) {
+		return mw.Engine.FindDocument(ctx, instanceID, indexName, entityName, entityID, opts...)
+	})
+}
+
+func (mw opensearchRetryMiddleware) Search(ctx context.Context, instanceID string, query search.Query) (search.SearchResult, error) {
+	return retry(ctx, mw.config, func() (search.SearchResult, error) {
+		return mw.Engine.Search(ctx, instanceID, query)
+	})
+}
+
+func (mw opensearchRetryMiddleware) Ping(ctx context.Context) error {
+	return retryErr(ctx, mw.config, func() error {
+		return mw.Engine.Ping(ctx)
+	})
+}
+
+func (mw opensearchRetryMiddleware) ClusterHealth(ctx context.Context) ([]ClusterHealth, error) {
+	return retry(ctx, mw.config, func() ([]ClusterHealth, error) {
+		return mw.Engine.ClusterHealth(ctx)
+	})
+}
+
+func (mw opensearchRetryMiddleware) NodesInfo(ctx context.Context) ([]NodesInfo, error) {
+	return retry(ctx, mw.config, func() ([]NodesInfo, error) {
+		return mw.Engine.NodesInfo(ctx)
+	})
+}
+
+func (mw opensearchRetryMiddleware) DiskUsage(ctx context.Context) ([]DiskUsage, error) {
+	return retry(ctx, mw.config, func() ([]DiskUsage, error) {
+		return mw.Engine.DiskUsage(ctx)
+	})
+}
+
+func (mw opensearchRetryMiddleware) AnalyzeText(ctx context.Context, indexName, analyzer, text string) ([]Token, error) {
+	return retry(ctx, mw.config, func() ([]Token, error) {
+		return mw.Engine.AnalyzeText(ctx, indexName, analyzer, text)
+	})
+}
+
+func (mw opensearchRetryMiddleware) HybridSearch(ctx context.Context, instanceID string, query search.Query, opts ...HybridOption) ([]search.Document, error) {
+	return retry(ctx, mw.config, func() ([]search.Document, error) {
+		return mw.Engine.HybridSearch(ctx, instanceID, query, opts...)
+	})
+}
+
+func (mw opensearchRetryMiddleware) SimilarDocuments(ctx context.Context, instanceID, indexName, entityName, entityID string, opts ...SimilarOption) ([]search.Document, error) {
+	return retry(ctx, mw.config, func() ([]search.Document, error) {
+		return mw.Engine.SimilarDocuments(ctx, instanceID, indexName, entityName, entityID, opts...)
+	})
+}
+
+func (mw opensearchRetryMiddleware) Percolate(ctx context.Context, instanceID, indexName, entityName string, document search.Document) ([]string, error) {
+	return retry(ctx, mw.config, func() ([]string, error) {
+		return mw.Engine.Percolate(ctx, instanceID, indexName, entityName, document)
+	})
+}
+
+func (mw opensearchRetryMiddleware) MSearch(ctx context.Context, requests []MSearchRequest) ([]MSearchResponse, error) {
+	return retry(ctx, mw.config, func() ([]MSearchResponse, error) {
+		return mw.Engine.MSearch(ctx, requests)
+	})
+}
+
+func (mw opensearchRetryMiddleware) GetAsyncSearch(ctx context.Context, id string) (AsyncSearch, error) {
+	return retry(ctx, mw.config, func() (AsyncSearch, error) {
+		return mw.Engine.GetAsyncSearch(ctx, id)
+	})
+}
@@ -0,0 +1,107 @@
+package opensearch
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/joshilesanmi/open-search-dev/search"
+	opensearch "github.com/opensearch-project/opensearch-go/v2"
+)
+
+// SearchCluster identifies the optional search-only cluster in errors and metrics.
+const SearchCluster = "search"
+
+// ReadOperation identifies a type of read operation for routing purposes.
+type ReadOperation string
+
+const (
+	ReadSearch ReadOperation = "search" // Search requests.
+	ReadFind   ReadOperation = "find"   // Single document lookups by FindDocument.
+	ReadReport ReadOperation = "report" // Heavy aggregations such as UsageReport.
+)
+
+// ReadRoute describes where a read operation is sent. The zero value sends reads to the primary cluster
+// with the default shard selection.
+type ReadRoute struct {
+	SearchCluster bool   // Send the read to the search-only cluster configured with WithSearchEndpoint.
+	Preference    string // Shard preference passed to OpenSearch, e.g. "_prefer_nodes:node-1,node-2".
+}
+
+// WithSearchEndpoint configures a search-only cluster, typically a follower of the primary cluster. Writes are
+// never sent to it; reads are only sent to it for operations routed there with WithReadRoute.
+func WithSearchEndpoint(endpoint string) OpenSearchOption {
+	return func(os *OpenSearch) error {
+		os.searchEndpoint = endpoint
+		return nil
+	}
+}
+
+// WithSearchName sets the label identifying the search-only cluster in logs, spans and errors.
+func WithSearchName(name string) OpenSearchOption {
+	return func(os *OpenSearch) error {
+		if name == "" {
+			return errors.New("search cluster name must not be empty")
+		}
+		os.searchName = name
+		return nil
+	}
+}
+
+// WithReadRoute configures where a type of read operation is sent, so heavy analytics queries can be isolated
+// from ingestion by directing them to replica shards or to the search-only cluster.
+func WithReadRoute(op ReadOperation, route ReadRoute) OpenSearchOption {
+	return func(os *OpenSearch) error {
+		if os.readRoutes == nil {
+			os.readRoutes = make(map[ReadOperation]ReadRoute)
+		}
+		os.readRoutes[op] = route
+		return nil
+	}
+}
+
+// readCluster returns the cluster and shard preference a read operation is routed to. A context targeting the
+// primary or the secondary cluster with search.WithCluster overrides the route.
+func (os *OpenSearch) readCluster(ctx context.Context, op ReadOperation) (cluster, string, error) {
+	route := os.readRoutes[op]
+	switch search.TargetCluster(ctx) {
+	case search.Primary:
+		return os.primary(), route.Preference, nil
+	case search.Secondary:
+		secondary, ok := os.secondary()
+		if !ok {
+			return cluster{}, "", ErrNoSecondary
+		}
+		return secondary, route.Preference, nil
+	}
+	if route.SearchCluster && os.searchClient != nil {
+		return cluster{role: SearchCluster, name: os.searchName, client: os.searchClient}, route.Preference, nil
+	}
+	return os.primary(), route.Preference, nil
+}
+
+// targetClusters returns the clusters a write is sent to, primary first: every configured cluster, unless the
+// context targets the primary or the secondary cluster with search.WithCluster.
+func (os *OpenSearch) targetClusters(ctx context.Context) ([]cluster, error) {
+	switch search.TargetCluster(ctx) {
+	case search.Primary:
+		return []cluster{os.primary()}, nil
+	case search.Secondary:
+		secondary, ok := os.secondary()
+		if !ok {
+			return nil, ErrNoSecondary
+		}
+		return []cluster{secondary}, nil
+	}
+	return os.clusters(), nil
+}
+
+// validateReadRoutes checks that every route targeting the search-only cluster has one configured.
+func validateReadRoutes(routes map[ReadOperation]ReadRoute, searchClient *opensearch.Client) error {
+	for op, route := range routes {
+		if route.SearchCluster && searchClient == nil {
+			return fmt.Errorf("read route %q targets the search cluster, but no search endpoint is configured", op)
+		}
+	}
+	return nil
+}
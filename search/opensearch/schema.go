@@ -0,0 +1,116 @@
+package opensearch
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// SchemaFragment is the part of an index mapping owned by a single entity, e.g. the fields of a person. Fragments
+// are stored as JSON files holding "properties" and optionally "dynamic_templates", as they would appear under
+// "mappings", and composed into the mapping of the shared index with ComposeSchema.
+type SchemaFragment struct {
+	Name             string                 `json:"-"` // The name of the fragment, taken from its file name.
+	Properties       map[string]interface{} `json:"properties"`
+	DynamicTemplates []interface{}          `json:"dynamic_templates"`
+}
+
+// LoadSchemaFragments reads every .json file of a directory as a SchemaFragment, ordered by file name.
+func LoadSchemaFragments(dir string) ([]SchemaFragment, error) {
+	paths, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		return nil, err
+	}
+	if len(paths) == 0 {
+		return nil, fmt.Errorf("no schema fragments found in %s", dir)
+	}
+	sort.Strings(paths)
+
+	fragments := make([]SchemaFragment, 0, len(paths))
+	for _, path := range paths {
+		b, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+
+		var fragment SchemaFragment
+		if err := json.Unmarshal(b, &fragment); err != nil {
+			return nil, fmt.Errorf("failed to parse schema fragment %s: %v", path, err)
+		}
+		fragment.Name = strings.TrimSuffix(filepath.Base(path), ".json")
+
+		fragments = append(fragments, fragment)
+	}
+
+	return fragments, nil
+}
+
+// ComposeSchema merges schema fragments into an index configuration holding their combined mapping. Fields
+// declared by several fragments must be mapped identically, except for objects, whose properties are merged.
+// Conflicting declarations are reported with the names of the fragments involved.
+func ComposeSchema(fragments []SchemaFragment) (map[string]interface{}, error) {
+	properties := make(map[string]interface{})
+	owners := make(map[string]string)
+	var templates []interface{}
+
+	for _, fragment := range fragments {
+		if err := mergeProperties(properties, fragment.Properties, "", fragment.Name, owners); err != nil {
+			return nil, err
+		}
+		templates = append(templates, fragment.DynamicTemplates...)
+	}
+
+	mappings := map[string]interface{}{"properties": properties}
+	if len(templates) > 0 {
+		mappings["dynamic_templates"] = templates
+	}
+
+	return map[string]interface{}{"mappings": mappings}, nil
+}
+
+// mergeProperties merges the properties declared by a fragment into dst, recording which fragment declared each
+// field so that conflicts can name both sides.
+func mergeProperties(dst, src map[string]interface{}, prefix, fragment string, owners map[string]string) error {
+	for name, p := range src {
+		path := prefix + name
+
+		existing, ok := dst[name]
+		if !ok {
+			dst[name] = p
+			owners[path] = fragment
+			continue
+		}
+
+		a, aok := existing.(map[string]interface{})
+		b, bok := p.(map[string]interface{})
+		if aok && bok && isObjectMapping(a) && isObjectMapping(b) {
+			nested, _ := a["properties"].(map[string]interface{})
+			if nested == nil {
+				nested = make(map[string]interface{})
+				a["properties"] = nested
+			}
+			srcNested, _ := b["properties"].(map[string]interface{})
+			if err := mergeProperties(nested, srcNested, path+".", fragment, owners); err != nil {
+				return err
+			}
+			continue
+		}
+
+		if !reflect.DeepEqual(existing, p) {
+			return fmt.Errorf("field %q is mapped differently by fragments %q and %q", path, owners[path], fragment)
+		}
+	}
+
+	return nil
+}
+
+// isObjectMapping reports whether a field mapping declares an object.
+func isObjectMapping(mapping map[string]interface{}) bool {
+	typ, _ := mapping["type"].(string)
+	_, hasProperties := mapping["properties"]
+	return typ == "object" || (typ == "" && hasProperties)
+}
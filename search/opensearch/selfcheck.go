@@ -0,0 +1,330 @@
+package opensearch
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	opensearch "github.com/opensearch-project/opensearch-go/v2"
+	opensearchapi "github.com/opensearch-project/opensearch-go/v2/opensearchapi"
+)
+
+// Severity classifies a self-check finding.
+type Severity string
+
+const (
+	SeverityOK      Severity = "ok"
+	SeverityWarning Severity = "warning"
+	SeverityError   Severity = "error"
+)
+
+// Finding is the outcome of a single self-check on a single cluster.
+type Finding struct {
+	Cluster  string   // The configured label of the cluster.
+	Check    string   // The check that produced the finding, e.g. "connectivity".
+	Severity Severity // How serious the finding is.
+	Message  string   // An actionable description of the finding.
+}
+
+// SelfCheckOption is a function type that applies configuration options to a SelfCheckOptions instance.
+type SelfCheckOption func(*SelfCheckOptions)
+
+// SelfCheckOptions defines configuration options for SelfCheck.
+type SelfCheckOptions struct {
+	Indices map[string]map[string]interface{} // Expected indices and their configuration, keyed by name.
+}
+
+// WithExpectedIndex returns a SelfCheckOption that checks the index exists and its mapping matches the
+// properties of the given index configuration.
+func WithExpectedIndex(indexName string, config map[string]interface{}) SelfCheckOption {
+	return func(opts *SelfCheckOptions) {
+		if opts.Indices == nil {
+			opts.Indices = make(map[string]map[string]interface{})
+		}
+		opts.Indices[indexName] = config
+	}
+}
+
+// SelfCheck verifies every configured cluster: connectivity and authentication, version compatibility, the
+// presence and mapping of expected indices, and disk usage against the allocation watermarks. Problems are
+// reported as findings rather than errors, so that one run lists everything that needs attention; the error
+// is only set if the check itself could not be performed.
+func (os *OpenSearch) SelfCheck(ctx context.Context, opts ...SelfCheckOption) ([]Finding, error) {
+	options := &SelfCheckOptions{}
+	for _, opt := range opts {
+		opt(options)
+	}
+
+	expected := make(map[string]map[string]string, len(options.Indices))
+	for name, config := range options.Indices {
+		fields, err := expectedFieldTypes(config)
+		if err != nil {
+			return nil, fmt.Errorf("index %q: %w", name, err)
+		}
+		expected[name] = fields
+	}
+
+	var findings []Finding
+	for _, c := range os.clusters() {
+		findings = append(findings, os.selfCheckCluster(ctx, c, expected)...)
+	}
+
+	return findings, nil
+}
+
+// selfCheckCluster runs every check against a single cluster.
+func (os *OpenSearch) selfCheckCluster(ctx context.Context, c cluster, expected map[string]map[string]string) []Finding {
+	finding := func(check string, severity Severity, format string, args ...interface{}) Finding {
+		return Finding{Cluster: c.name, Check: check, Severity: severity, Message: fmt.Sprintf(format, args...)}
+	}
+
+	resp, err := os.executeReadRequest(ctx, c.client, opensearchapi.InfoRequest{})
+	if err != nil {
+		return []Finding{finding("connectivity", SeverityError, "cluster is unreachable: %v", err)}
+	}
+
+	switch resp.StatusCode {
+	case http.StatusUnauthorized, http.StatusForbidden:
+		resp.Body.Close()
+		return []Finding{finding("auth", SeverityError, "cluster rejected the credentials with status %d", resp.StatusCode)}
+	}
+
+	var info struct {
+		Version struct {
+			Distribution string `json:"distribution"`
+			Number       string `json:"number"`
+		} `json:"version"`
+	}
+	if err := os.decodeResponse(resp, &info); err != nil {
+		return []Finding{finding("connectivity", SeverityError, "unexpected response from cluster: %v", err)}
+	}
+
+	findings := []Finding{
+		finding("connectivity", SeverityOK, "cluster is reachable"),
+		checkVersion(finding, info.Version.Distribution, info.Version.Number),
+	}
+
+	names := make([]string, 0, len(expected))
+	for name := range expected {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		findings = append(findings, os.checkIndex(ctx, c.client, name, expected[name], finding)...)
+	}
+
+	return append(findings, os.checkDiskWatermarks(ctx, c.client, finding)...)
+}
+
+type findingFunc func(check string, severity Severity, format string, args ...interface{}) Finding
+
+// checkVersion reports whether the cluster runs a version of OpenSearch supported by this client.
+func checkVersion(finding findingFunc, distribution, number string) Finding {
+	if distribution != "opensearch" {
+		return finding("version", SeverityWarning, "cluster is not OpenSearch (version %s); behaviour may differ", number)
+	}
+
+	major, err := strconv.Atoi(strings.SplitN(number, ".", 2)[0])
+	if err != nil {
+		return finding("version", SeverityWarning, "cannot parse cluster version %q", number)
+	}
+
+	if major < 1 || major > 2 {
+		return finding("version", SeverityWarning, "OpenSearch %s is not tested with this client, which supports 1.x and 2.x", number)
+	}
+
+	return finding("version", SeverityOK, "OpenSearch %s is supported", number)
+}
+
+// checkIndex reports whether an expected index exists and whether its mapping matches the expected field types.
+func (os *OpenSearch) checkIndex(ctx context.Context, client *opensearch.Client, indexName string, fields map[string]string, finding findingFunc) []Finding {
+	check := "index " + indexName
+
+	exists, err := os.indexExists(ctx, client, indexName)
+	if err != nil {
+		return []Finding{finding(check, SeverityError, "cannot check index: %v", err)}
+	}
+	if !exists {
+		return []Finding{finding(check, SeverityError, "index is missing; create it before deploying")}
+	}
+
+	actual, err := os.actualFieldTypes(ctx, client, indexName)
+	if err != nil {
+		return []Finding{finding(check, SeverityError, "cannot read mapping: %v", err)}
+	}
+
+	paths := make([]string, 0, len(fields))
+	for path := range fields {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	var findings []Finding
+	for _, path := range paths {
+		want := fields[path]
+		got, ok := actual[path]
+		switch {
+		case !ok:
+			findings = append(findings, finding(check, SeverityWarning, "field %q is not mapped; expected type %q", path, want))
+		case got != want:
+			findings = append(findings, finding(check, SeverityError, "field %q is mapped as %q, expected %q; reindex to fix the drift", path, got, want))
+		}
+	}
+
+	if len(findings) == 0 {
+		findings = append(findings, finding(check, SeverityOK, "index exists and mapping matches"))
+	}
+
+	return findings
+}
+
+// actualFieldTypes returns the mapped type of every field of an index, keyed by dotted path.
+func (os *OpenSearch) actualFieldTypes(ctx context.Context, client *opensearch.Client, indexName string) (map[string]string, error) {
+	resp, err := os.executeReadRequest(ctx, client, opensearchapi.IndicesGetMappingRequest{Index: []string{indexName}})
+	if err != nil {
+		return nil, err
+	}
+
+	var r map[string]struct {
+		Mappings map[string]interface{} `json:"mappings"`
+	}
+	if err := os.decodeResponse(resp, &r); err != nil {
+		return nil, err
+	}
+
+	fields := make(map[string]string)
+	for _, index := range r {
+		if properties, ok := index.Mappings["properties"].(map[string]interface{}); ok {
+			collectFieldTypes(properties, "", fields)
+		}
+	}
+
+	return fields, nil
+}
+
+// expectedFieldTypes returns the type of every property declared in an index configuration, keyed by dotted path.
+func expectedFieldTypes(config map[string]interface{}) (map[string]string, error) {
+	// Round-trip through JSON so typed values in the configuration are handled like plain maps.
+	b, err := json.Marshal(config)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal index config %v", err)
+	}
+
+	var generic struct {
+		Mappings struct {
+			Properties map[string]interface{} `json:"properties"`
+		} `json:"mappings"`
+	}
+	if err := json.Unmarshal(b, &generic); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal index config %v", err)
+	}
+
+	fields := make(map[string]string)
+	collectFieldTypes(generic.Mappings.Properties, "", fields)
+
+	return fields, nil
+}
+
+// collectFieldTypes flattens a properties mapping into field types keyed by dotted path. Fields declaring
+// sub-properties without an explicit type are objects.
+func collectFieldTypes(properties map[string]interface{}, prefix string, fields map[string]string) {
+	for name, p := range properties {
+		mapping, ok := p.(map[string]interface{})
+		if !ok {
+			continue
+		}
+
+		path := prefix + name
+		typ, _ := mapping["type"].(string)
+		nested, hasProperties := mapping["properties"].(map[string]interface{})
+		if typ == "" && hasProperties {
+			typ = "object"
+		}
+		if typ != "" {
+			fields[path] = typ
+		}
+		if hasProperties {
+			collectFieldTypes(nested, path+".", fields)
+		}
+	}
+}
+
+// checkDiskWatermarks reports nodes whose disk usage exceeds the low or high allocation watermark.
+func (os *OpenSearch) checkDiskWatermarks(ctx context.Context, client *opensearch.Client, finding findingFunc) []Finding {
+	low, high := os.diskWatermarks(ctx, client)
+
+	resp, err := os.executeReadRequest(ctx, client, opensearchapi.CatAllocationRequest{Format: "json"})
+	if err != nil {
+		return []Finding{finding("disk", SeverityError, "cannot read disk allocation: %v", err)}
+	}
+
+	var nodes []struct {
+		Node        string `json:"node"`
+		DiskPercent string `json:"disk.percent"`
+	}
+	if err := os.decodeResponse(resp, &nodes); err != nil {
+		return []Finding{finding("disk", SeverityError, "cannot read disk allocation: %v", err)}
+	}
+
+	var findings []Finding
+	for _, n := range nodes {
+		// Unassigned shards are reported as a pseudo-node without disk usage.
+		percent, err := strconv.ParseFloat(n.DiskPercent, 64)
+		if err != nil {
+			continue
+		}
+		switch {
+		case percent >= high:
+			findings = append(findings, finding("disk", SeverityError, "node %s disk is %.0f%% full, above the high watermark of %.0f%%; shards are being moved away", n.Node, percent, high))
+		case percent >= low:
+			findings = append(findings, finding("disk", SeverityWarning, "node %s disk is %.0f%% full, above the low watermark of %.0f%%; no new shards will be allocated", n.Node, percent, low))
+		}
+	}
+
+	if len(findings) == 0 {
+		findings = append(findings, finding("disk", SeverityOK, "all nodes are below the low watermark of %.0f%%", low))
+	}
+
+	return findings
+}
+
+// diskWatermarks returns the low and high disk watermarks of the cluster as percentages. Watermarks configured
+// as absolute sizes cannot be compared with percentages, so the OpenSearch defaults are used instead.
+func (os *OpenSearch) diskWatermarks(ctx context.Context, client *opensearch.Client) (float64, float64) {
+	low, high := 85.0, 90.0
+
+	flat, defaults := true, true
+	resp, err := os.executeReadRequest(ctx, client, opensearchapi.ClusterGetSettingsRequest{
+		FlatSettings:    &flat,
+		IncludeDefaults: &defaults,
+	})
+	if err != nil {
+		return low, high
+	}
+
+	var r map[string]map[string]interface{}
+	if err := os.decodeResponse(resp, &r); err != nil {
+		return low, high
+	}
+
+	lookup := func(key string, fallback float64) float64 {
+		// Persistent and transient settings override the defaults.
+		for _, section := range []string{"transient", "persistent", "defaults"} {
+			if v, ok := r[section][key].(string); ok {
+				if p, err := strconv.ParseFloat(strings.TrimSuffix(v, "%"), 64); err == nil && strings.HasSuffix(v, "%") {
+					return p
+				}
+				return fallback
+			}
+		}
+		return fallback
+	}
+
+	return lookup("cluster.routing.allocation.disk.watermark.low", low),
+		lookup("cluster.routing.allocation.disk.watermark.high", high)
+}
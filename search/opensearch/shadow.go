@@ -0,0 +1,135 @@
+package opensearch
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"math/rand"
+	"time"
+
+	"github.com/joshilesanmi/open-search-dev/search"
+	opensearchapi "github.com/opensearch-project/opensearch-go/v2/opensearchapi"
+)
+
+// ShadowDiff compares the result of a search on the cluster serving it with the result of the same search on
+// the secondary cluster.
+type ShadowDiff struct {
+	InstanceID string // The instance the search was made in.
+	Shape      string // The shape of the query, see QueryShape.
+
+	Total          int64         // The number of matches on the serving cluster.
+	SecondaryTotal int64         // The number of matches on the secondary cluster.
+	Took           time.Duration // The time the serving cluster spent executing the search.
+	SecondaryTook  time.Duration // The time the secondary cluster spent executing the search.
+
+	Missing      []string // IDs of the returned page that the secondary did not return.
+	Extra        []string // IDs returned by the secondary that are not in the returned page.
+	OrderChanged bool     // True if the IDs returned by both are in a different order.
+
+	Err error // The error of the secondary search, if it failed; the other fields are then unset.
+}
+
+// Identical reports whether the secondary returned the same page of results in the same order, with the same
+// total.
+func (d ShadowDiff) Identical() bool {
+	return d.Err == nil && d.Total == d.SecondaryTotal && len(d.Missing) == 0 && len(d.Extra) == 0 && !d.OrderChanged
+}
+
+// ShadowRecorder receives the comparison of every shadowed search. It is called from a background goroutine.
+type ShadowRecorder interface {
+	RecordShadow(diff ShadowDiff)
+}
+
+// ShadowConfig configures shadow reads.
+type ShadowConfig struct {
+	Recorder      ShadowRecorder // Receives the comparisons.
+	SampleRate    float64        // Fraction of searches that are shadowed, between 0 and 1. Defaults to 1.
+	Timeout       time.Duration  // Deadline of a shadow search. Defaults to 5 seconds.
+	MaxConcurrent int            // Shadow searches in flight at once; further searches are not shadowed. Defaults to 8.
+}
+
+// WithShadowReads also runs a sample of the searches against the secondary cluster in the background and
+// records how its results differ, without affecting the returned results, to validate the relevance of a new
+// cluster before switching to it. Only searches served by the primary cluster are shadowed. The secondary cluster
+// must be configured.
+func WithShadowReads(config ShadowConfig) OpenSearchOption {
+	return func(os *OpenSearch) error {
+		if config.Recorder == nil {
+			return errors.New("shadow recorder must not be nil")
+		}
+		if config.SampleRate < 0 || config.SampleRate > 1 {
+			return errors.New("shadow sample rate must be between 0 and 1")
+		}
+		if config.SampleRate == 0 {
+			config.SampleRate = 1
+		}
+		if config.Timeout <= 0 {
+			config.Timeout = 5 * time.Second
+		}
+		if config.MaxConcurrent <= 0 {
+			config.MaxConcurrent = 8
+		}
+		os.shadow = &shadowReads{config: config, slots: make(chan struct{}, config.MaxConcurrent)}
+		return nil
+	}
+}
+
+// shadowReads runs shadow searches with bounded concurrency.
+type shadowReads struct {
+	config ShadowConfig
+	slots  chan struct{}
+}
+
+// shadowSearch runs a search that was served by the primary cluster with the given result against the secondary
+// cluster in the background, if it is sampled and a slot is free, and records the comparison. Searches served by
+// another cluster, e.g. routed to the secondary with search.WithCluster, are not shadowed. The shadow search is
+// detached from the context of the search, except for its request ID and indices.
+func (os *OpenSearch) shadowSearch(ctx context.Context, served cluster, instanceID string, searchQuery map[string]interface{}, body []byte, result search.SearchResult) {
+	if os.shadow == nil || served.role != PrimaryCluster || rand.Float64() >= os.shadow.config.SampleRate {
+		return
+	}
+	shadowed, ok := os.secondary()
+	if !ok {
+		return
+	}
+	select {
+	case os.shadow.slots <- struct{}{}:
+	default:
+		return
+	}
+
+	requestID := search.RequestID(ctx)
+	indices := os.searchIndices(ctx, instanceID)
+	diff := ShadowDiff{
+		InstanceID: instanceID,
+		Shape:      QueryShape(searchQuery),
+		Total:      result.Total,
+		Took:       result.Took,
+	}
+
+	go func() {
+		defer func() { <-os.shadow.slots }()
+
+		ctx, cancel := context.WithTimeout(search.WithRequestID(context.Background(), requestID), os.shadow.config.Timeout)
+		defer cancel()
+
+		resp, err := os.executeReadRequest(ctx, shadowed.client, opensearchapi.SearchRequest{
+			Index: indices,
+			Body:  bytes.NewReader(body),
+		})
+		var secondary search.SearchResult
+		if err == nil {
+			secondary, err = os.extractSearchResult(resp)
+		}
+		if err != nil {
+			diff.Err = &ClusterError{Cluster: shadowed.role, Name: shadowed.name, Err: err}
+			os.shadow.config.Recorder.RecordShadow(diff)
+			return
+		}
+
+		diff.SecondaryTotal = secondary.Total
+		diff.SecondaryTook = secondary.Took
+		diff.Missing, diff.Extra, diff.OrderChanged = search.DiffHits(result.Hits, secondary.Hits)
+		os.shadow.config.Recorder.RecordShadow(diff)
+	}()
+}
@@ -0,0 +1,41 @@
+package opensearch
+
+import (
+	"errors"
+	"net/http"
+	"time"
+)
+
+// RequestSigner signs requests to the clusters, e.g. with AWS Signature Version 4 as implemented by the awssigv4
+// package.
+type RequestSigner interface {
+	// SignRequest signs the request in place, as of now.
+	SignRequest(req *http.Request, now time.Time) error
+}
+
+// WithRequestSigner signs every request to the clusters with the signer, at the time of the clock of the engine.
+// Signing replaces any other authentication: NewOpenSearch fails if it is combined with WithBasicAuth, another
+// credentials or token option, or credentials in an endpoint.
+func WithRequestSigner(s RequestSigner) OpenSearchOption {
+	return func(os *OpenSearch) error {
+		if s == nil {
+			return errors.New("request signer must not be nil")
+		}
+		os.signer = &clockSigner{
+			signer: s,
+			now:    func() time.Time { return os.clock.Now() },
+		}
+		return nil
+	}
+}
+
+// clockSigner adapts a RequestSigner to the signer of the opensearch-go transport.
+type clockSigner struct {
+	signer RequestSigner
+	now    func() time.Time // The time of the signature, from the clock of the engine.
+}
+
+// SignRequest signs the request in place.
+func (s *clockSigner) SignRequest(req *http.Request) error {
+	return s.signer.SignRequest(req, s.now())
+}
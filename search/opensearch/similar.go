@@ -0,0 +1,109 @@
+package opensearch
+
+import (
+	"context"
+
+	"github.com/joshilesanmi/open-search-dev/search"
+)
+
+// SimilarOption is a function type that applies configuration options to a SimilarOptions instance.
+type SimilarOption func(*SimilarOptions)
+
+// SimilarOptions defines configuration options for similar document lookups.
+type SimilarOptions struct {
+	Size        int      // Number of results to return. Defaults to 10.
+	Fields      []string // Fields compared for similarity. Defaults to all text fields of the index.
+	MinTermFreq int      // Minimum frequency of a term in the source document to be considered. Defaults to 1.
+	MinDocFreq  int      // Minimum number of documents a term must occur in to be considered. Defaults to 1.
+}
+
+// WithSimilarSize returns a SimilarOption that sets the number of results to return.
+func WithSimilarSize(size int) SimilarOption {
+	return func(opts *SimilarOptions) {
+		opts.Size = size
+	}
+}
+
+// WithSimilarFields returns a SimilarOption that restricts the comparison to the given fields.
+func WithSimilarFields(fields ...string) SimilarOption {
+	return func(opts *SimilarOptions) {
+		opts.Fields = fields
+	}
+}
+
+// WithMinTermFreq returns a SimilarOption that sets how often a term must occur in the source document to be
+// considered.
+func WithMinTermFreq(freq int) SimilarOption {
+	return func(opts *SimilarOptions) {
+		opts.MinTermFreq = freq
+	}
+}
+
+// WithMinDocFreq returns a SimilarOption that sets in how many documents a term must occur to be considered.
+func WithMinDocFreq(freq int) SimilarOption {
+	return func(opts *SimilarOptions) {
+		opts.MinDocFreq = freq
+	}
+}
+
+// SimilarDocuments returns the documents of an instance that are most similar to the given document, using a
+// more_like_this query. The source document itself is not part of the results. The defaults are tuned for
+// short documents such as contacts and companies, where most terms occur only once.
+func (os *OpenSearch) SimilarDocuments(ctx context.Context, instanceID, indexName, entityName, entityID string, opts ...SimilarOption) ([]search.Document, error) {
+	options := &SimilarOptions{Size: 10, MinTermFreq: 1, MinDocFreq: 1}
+	for _, opt := range opts {
+		opt(options)
+	}
+
+	ctx, release, err := os.acquire(ctx, search.PriorityInteractive)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	moreLikeThis := map[string]interface{}{
+		"like": []interface{}{
+			map[string]string{
+				"_index": indexName,
+				"_id":    search.GenerateDocumentID(instanceID, entityName, entityID),
+			},
+		},
+		"min_term_freq": options.MinTermFreq,
+		"min_doc_freq":  options.MinDocFreq,
+	}
+	if len(options.Fields) > 0 {
+		moreLikeThis["fields"] = options.Fields
+	}
+
+	body := map[string]interface{}{
+		"size": options.Size,
+		"query": map[string]interface{}{
+			"bool": map[string]interface{}{
+				"must": map[string]interface{}{
+					"more_like_this": moreLikeThis,
+				},
+				"filter": search.InstanceFilter(instanceID),
+			},
+		},
+	}
+
+	os.auditQuery(instanceID, body)
+
+	read, preference, err := os.readCluster(ctx, ReadSearch)
+	if err != nil {
+		return nil, err
+	}
+
+	hits, err := os.searchHits(ctx, read.client, body, preference)
+	if err != nil {
+		return nil, err
+	}
+
+	documents := make([]search.Document, 0, len(hits))
+	for _, hit := range hits {
+		os.presentDocument(hit.Source)
+		documents = append(documents, hit.Source)
+	}
+
+	return documents, nil
+}
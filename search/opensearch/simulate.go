@@ -0,0 +1,158 @@
+package opensearch
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/joshilesanmi/open-search-dev/search"
+	opensearchapi "github.com/opensearch-project/opensearch-go/v2/opensearchapi"
+)
+
+// FieldMapping is how a field of a simulated document is mapped.
+type FieldMapping struct {
+	Field    string // The dotted path of the field.
+	Type     string // The mapped type, e.g. "keyword".
+	Existing bool   // Whether the field was already mapped in the index, rather than added by the document.
+}
+
+// SimulationReport is the outcome of SimulateIndex.
+type SimulationReport struct {
+	Fields   []FieldMapping // The mapping of every field of the document, ordered by path.
+	Rejected bool           // Whether the index would reject the document.
+	Reason   string         // Why the document would be rejected.
+}
+
+// SimulateIndex reports how each field of a document would be mapped if it were indexed into an index, and
+// whether the document would be rejected, e.g. because a value does not match an existing mapping or the mapping
+// is strict. The index itself is not modified: the document is indexed into a temporary copy of its mappings
+// and analysis on the primary cluster, which is deleted afterwards. This makes it suitable for validating custom
+// fields before they are created.
+func (os *OpenSearch) SimulateIndex(ctx context.Context, indexName string, document search.Document) (SimulationReport, error) {
+	var report SimulationReport
+
+	resp, err := os.executeReadRequest(ctx, os.primary().client, opensearchapi.IndicesGetRequest{Index: []string{indexName}})
+	if err != nil {
+		return report, err
+	}
+
+	var indices map[string]struct {
+		Mappings map[string]interface{} `json:"mappings"`
+		Settings struct {
+			Index struct {
+				Analysis map[string]interface{} `json:"analysis"`
+			} `json:"index"`
+		} `json:"settings"`
+	}
+	if err := os.decodeResponse(resp, &indices); err != nil {
+		return report, err
+	}
+	if len(indices) != 1 {
+		return report, fmt.Errorf("%q must name a single index, found %d", indexName, len(indices))
+	}
+
+	existing := make(map[string]string)
+	config := map[string]interface{}{
+		"settings": map[string]interface{}{
+			"number_of_shards":   1,
+			"number_of_replicas": 0,
+		},
+	}
+	for _, index := range indices {
+		config["mappings"] = index.Mappings
+		if index.Settings.Index.Analysis != nil {
+			config["settings"].(map[string]interface{})["analysis"] = index.Settings.Index.Analysis
+		}
+		if properties, ok := index.Mappings["properties"].(map[string]interface{}); ok {
+			collectFieldTypes(properties, "", existing)
+		}
+	}
+
+	body, err := json.Marshal(config)
+	if err != nil {
+		return report, fmt.Errorf("failed to marshal index config %v", err)
+	}
+
+	tempIndex := fmt.Sprintf("%s-simulate-%s", indexName, strings.ToLower(os.ids.NewID()))
+	if err := os.createIndex(ctx, os.primary().client, tempIndex, body); err != nil {
+		return report, fmt.Errorf("failed to create simulation index: %w", err)
+	}
+	defer os.deleteIndex(context.Background(), os.primary().client, tempIndex)
+
+	doc, err := json.Marshal(document)
+	if err != nil {
+		return report, fmt.Errorf("failed to marshal document %v", err)
+	}
+
+	resp, err = os.executeReadRequest(ctx, os.primary().client, opensearchapi.IndexRequest{
+		Index: tempIndex,
+		Body:  bytes.NewReader(doc),
+	})
+	if err != nil {
+		return report, err
+	}
+	if resp.StatusCode == http.StatusBadRequest {
+		defer resp.Body.Close()
+
+		var r struct {
+			Error struct {
+				Type   string `json:"type"`
+				Reason string `json:"reason"`
+			} `json:"error"`
+		}
+		b, _ := io.ReadAll(resp.Body)
+		if err := json.Unmarshal(b, &r); err != nil || r.Error.Type == "" {
+			report.Reason = string(b)
+		} else {
+			report.Reason = r.Error.Type + ": " + r.Error.Reason
+		}
+		report.Rejected = true
+		return report, nil
+	}
+	var discard json.RawMessage
+	if err := os.decodeResponse(resp, &discard); err != nil {
+		return report, err
+	}
+
+	mapped, err := os.actualFieldTypes(ctx, os.primary().client, tempIndex)
+	if err != nil {
+		return report, err
+	}
+
+	paths := make(map[string]struct{})
+	documentPaths(document, "", paths)
+
+	for path := range paths {
+		typ, ok := mapped[path]
+		if !ok {
+			continue
+		}
+		_, wasMapped := existing[path]
+		report.Fields = append(report.Fields, FieldMapping{Field: path, Type: typ, Existing: wasMapped})
+	}
+	sort.Slice(report.Fields, func(i, j int) bool { return report.Fields[i].Field < report.Fields[j].Field })
+
+	return report, nil
+}
+
+// documentPaths collects the dotted paths of every field of a document, descending into objects and arrays.
+func documentPaths(value interface{}, prefix string, paths map[string]struct{}) {
+	switch v := value.(type) {
+	case search.Document:
+		documentPaths(map[string]interface{}(v), prefix, paths)
+	case map[string]interface{}:
+		for k, child := range v {
+			paths[prefix+k] = struct{}{}
+			documentPaths(child, prefix+k+".", paths)
+		}
+	case []interface{}:
+		for _, item := range v {
+			documentPaths(item, prefix, paths)
+		}
+	}
+}
@@ -0,0 +1,180 @@
+// Package sqsqueue provides an opensearch.WriteQueue backed by Amazon SQS. It is a separate package so that only
+// the users of the queue depend on the AWS SDK.
+package sqsqueue
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/sqs"
+	"github.com/aws/aws-sdk-go/service/sqs/sqsiface"
+	"github.com/joshilesanmi/open-search-dev/search/opensearch"
+)
+
+// batchSize is the maximum number of messages SQS receives or deletes per request.
+const batchSize = 10
+
+// messageGroup is the message group of the writes sent to FIFO queues, so they are received in order.
+const messageGroup = "write-behind"
+
+// Queue is an opensearch.WriteQueue backed by an Amazon SQS queue, so the queued writes survive the process and
+// can be shared by the processes writing to the same clusters. Writes received by Pending are hidden from other
+// receivers for the visibility timeout of the queue, and reappear if they are not removed in time, e.g. because
+// the replay failed; replays are idempotent, so a write replayed twice is harmless.
+//
+// Standard queues do not keep the order of their messages: Pending orders each batch by the time the writes were
+// queued, but older writes may arrive in later batches. FIFO queues, whose URL ends in ".fifo", keep the order.
+// Writes that cannot be decoded fail Pending until they are moved away, e.g. by a redrive policy.
+type Queue struct {
+	client   sqsiface.SQSAPI
+	queueURL string
+
+	mu       sync.Mutex
+	receipts map[string]string // Receipt handles of the received writes by their IDs, required by Remove.
+}
+
+// Ensures the Queue struct correctly implements the opensearch.WriteQueue interface.
+var _ opensearch.WriteQueue = &Queue{}
+
+// New returns a queue storing the writes in the SQS queue at queueURL, e.g. with a client created by
+// sqs.New(session.Must(session.NewSession())).
+func New(client sqsiface.SQSAPI, queueURL string) *Queue {
+	return &Queue{client: client, queueURL: queueURL, receipts: make(map[string]string)}
+}
+
+// Enqueue sends a write to the queue.
+func (q *Queue) Enqueue(ctx context.Context, write opensearch.QueuedWrite) error {
+	b, err := json.Marshal(write)
+	if err != nil {
+		return err
+	}
+
+	input := &sqs.SendMessageInput{
+		QueueUrl:    aws.String(q.queueURL),
+		MessageBody: aws.String(string(b)),
+	}
+	if q.fifo() {
+		input.MessageGroupId = aws.String(messageGroup)
+		input.MessageDeduplicationId = aws.String(write.ID)
+	}
+
+	if _, err := q.client.SendMessageWithContext(ctx, input); err != nil {
+		return fmt.Errorf("failed to send queued write: %w", err)
+	}
+	return nil
+}
+
+// Pending receives up to limit writes, oldest first, and hides them from other receivers until they are removed
+// or the visibility timeout of the queue expires.
+func (q *Queue) Pending(ctx context.Context, limit int) ([]opensearch.QueuedWrite, error) {
+	var writes []opensearch.QueuedWrite
+	for len(writes) < limit {
+		n := limit - len(writes)
+		if n > batchSize {
+			n = batchSize
+		}
+
+		out, err := q.client.ReceiveMessageWithContext(ctx, &sqs.ReceiveMessageInput{
+			QueueUrl:            aws.String(q.queueURL),
+			MaxNumberOfMessages: aws.Int64(int64(n)),
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to receive queued writes: %w", err)
+		}
+		if len(out.Messages) == 0 {
+			break
+		}
+
+		q.mu.Lock()
+		for _, m := range out.Messages {
+			var w opensearch.QueuedWrite
+			if err := json.Unmarshal([]byte(aws.StringValue(m.Body)), &w); err != nil {
+				q.mu.Unlock()
+				return nil, fmt.Errorf("failed to decode queued write %s: %w", aws.StringValue(m.MessageId), err)
+			}
+			q.receipts[w.ID] = aws.StringValue(m.ReceiptHandle)
+			writes = append(writes, w)
+		}
+		q.mu.Unlock()
+	}
+
+	if !q.fifo() {
+		sort.SliceStable(writes, func(i, j int) bool { return writes[i].Queued.Before(writes[j].Queued) })
+	}
+	return writes, nil
+}
+
+// Remove deletes replayed writes. Only writes received by Pending of this queue can be removed; other IDs are
+// ignored.
+func (q *Queue) Remove(ctx context.Context, ids []string) error {
+	q.mu.Lock()
+	var entries []*sqs.DeleteMessageBatchRequestEntry
+	for _, id := range ids {
+		if receipt, ok := q.receipts[id]; ok {
+			// Batch entry IDs are restricted to alphanumerics, hyphens and underscores, so they are numbered.
+			entries = append(entries, &sqs.DeleteMessageBatchRequestEntry{
+				Id:            aws.String(strconv.Itoa(len(entries))),
+				ReceiptHandle: aws.String(receipt),
+			})
+			delete(q.receipts, id)
+		}
+	}
+	q.mu.Unlock()
+
+	for start := 0; start < len(entries); start += batchSize {
+		end := start + batchSize
+		if end > len(entries) {
+			end = len(entries)
+		}
+
+		out, err := q.client.DeleteMessageBatchWithContext(ctx, &sqs.DeleteMessageBatchInput{
+			QueueUrl: aws.String(q.queueURL),
+			Entries:  entries[start:end],
+		})
+		if err != nil {
+			return fmt.Errorf("failed to remove queued writes: %w", err)
+		}
+		if len(out.Failed) > 0 {
+			return fmt.Errorf("failed to remove %d queued writes: %s", len(out.Failed), aws.StringValue(out.Failed[0].Message))
+		}
+	}
+	return nil
+}
+
+// Len returns the approximate number of queued writes, including the ones received but not yet removed.
+func (q *Queue) Len(ctx context.Context) (int, error) {
+	out, err := q.client.GetQueueAttributesWithContext(ctx, &sqs.GetQueueAttributesInput{
+		QueueUrl: aws.String(q.queueURL),
+		AttributeNames: aws.StringSlice([]string{
+			sqs.QueueAttributeNameApproximateNumberOfMessages,
+			sqs.QueueAttributeNameApproximateNumberOfMessagesNotVisible,
+		}),
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to read queue attributes: %w", err)
+	}
+
+	total := 0
+	for _, name := range []string{
+		sqs.QueueAttributeNameApproximateNumberOfMessages,
+		sqs.QueueAttributeNameApproximateNumberOfMessagesNotVisible,
+	} {
+		n, err := strconv.Atoi(aws.StringValue(out.Attributes[name]))
+		if err != nil {
+			return 0, fmt.Errorf("invalid queue attribute %s: %w", name, err)
+		}
+		total += n
+	}
+	return total, nil
+}
+
+// fifo reports whether the queue is a FIFO queue.
+func (q *Queue) fifo() bool {
+	return strings.HasSuffix(q.queueURL, ".fifo")
+}
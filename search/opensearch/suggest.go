@@ -0,0 +1,267 @@
+package opensearch
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/joshilesanmi/open-search-dev/search"
+	opensearchapi "github.com/opensearch-project/opensearch-go/v2/opensearchapi"
+)
+
+// Thresholds of the mapping suggestion heuristics.
+const (
+	// keywordMaxLength is the average length up to which strings without whitespace are mapped as keywords.
+	keywordMaxLength = 64
+
+	// keywordMaxDistinctRatio is the ratio of distinct to total values up to which strings with whitespace are
+	// still considered enumerations, and mapped as keywords.
+	keywordMaxDistinctRatio = 0.1
+
+	// shortTextLength is the average length up to which text fields are suggested without norms, as length
+	// normalization adds little to the scoring of short values.
+	shortTextLength = 32
+)
+
+// MappingSuggestion is an explicit mapping proposed for sampled documents.
+type MappingSuggestion struct {
+	Mappings map[string]interface{} // The proposed "mappings" section of an index configuration.
+	Notes    []string               // Observations that may require a manual decision, e.g. conflicting types.
+}
+
+// fieldStats accumulates the values observed for a field path.
+type fieldStats struct {
+	strings  int
+	numbers  int
+	integers int
+	booleans int
+	objects  int
+	dates    int
+
+	maxAbs      float64
+	totalLength int
+	whitespace  int
+	distinct    map[string]struct{}
+}
+
+// SuggestMapping proposes an explicit mapping for sampled documents, to help move an index off dynamic mappings.
+// Strings are mapped as keyword when they look like identifiers or enumerations and as text otherwise, with
+// norms disabled for short text. Numbers get the narrowest type holding every sampled value and RFC 3339
+// strings are mapped as dates. The engine metadata fields are always keywords. Unmapped fields are kept in the
+// source but not indexed ("dynamic": false).
+func SuggestMapping(documents []search.Document) MappingSuggestion {
+	stats := make(map[string]*fieldStats)
+	for _, d := range documents {
+		collectFieldStats(map[string]interface{}(d), "", stats)
+	}
+
+	paths := make([]string, 0, len(stats))
+	for p := range stats {
+		paths = append(paths, p)
+	}
+	sort.Strings(paths)
+
+	suggestion := MappingSuggestion{}
+	properties := make(map[string]interface{})
+	for _, p := range paths {
+		mapping, note := stats[p].mapping()
+		if note != "" {
+			suggestion.Notes = append(suggestion.Notes, fmt.Sprintf("%s: %s", p, note))
+		}
+		if mapping == nil {
+			continue
+		}
+		setProperty(properties, p, mapping)
+	}
+
+	for _, f := range []string{"id", "instance_id", "entity_name"} {
+		properties[f] = map[string]interface{}{"type": "keyword"}
+	}
+
+	suggestion.Mappings = map[string]interface{}{
+		"dynamic":    false,
+		"properties": properties,
+	}
+
+	return suggestion
+}
+
+// collectFieldStats records the leaf values of a document under their dotted paths. Arrays contribute each of
+// their elements.
+func collectFieldStats(value interface{}, path string, stats map[string]*fieldStats) {
+	if m, ok := value.(map[string]interface{}); ok {
+		if path != "" {
+			fieldStatsFor(stats, path).objects++
+		}
+		for k, v := range m {
+			p := k
+			if path != "" {
+				p = path + "." + k
+			}
+			collectFieldStats(v, p, stats)
+		}
+		return
+	}
+
+	if items, ok := value.([]interface{}); ok {
+		for _, item := range items {
+			collectFieldStats(item, path, stats)
+		}
+		return
+	}
+
+	s := fieldStatsFor(stats, path)
+	switch v := value.(type) {
+	case bool:
+		s.booleans++
+	case float64:
+		s.numbers++
+		if v == math.Trunc(v) {
+			s.integers++
+		}
+		s.maxAbs = math.Max(s.maxAbs, math.Abs(v))
+	case string:
+		s.strings++
+		s.totalLength += len(v)
+		if strings.ContainsAny(v, " \t\n") {
+			s.whitespace++
+		}
+		if _, err := time.Parse(time.RFC3339Nano, v); err == nil {
+			s.dates++
+		}
+		s.distinct[v] = struct{}{}
+	}
+}
+
+func fieldStatsFor(stats map[string]*fieldStats, path string) *fieldStats {
+	s, ok := stats[path]
+	if !ok {
+		s = &fieldStats{distinct: make(map[string]struct{})}
+		stats[path] = s
+	}
+	return s
+}
+
+// mapping returns the suggested mapping of a field, or nil for objects, whose fields are mapped individually,
+// and fields without values. The note explains choices that may need a manual decision.
+func (s *fieldStats) mapping() (map[string]interface{}, string) {
+	kinds := 0
+	for _, n := range []int{s.strings, s.numbers, s.booleans, s.objects} {
+		if n > 0 {
+			kinds++
+		}
+	}
+
+	switch {
+	case kinds == 0:
+		return nil, "no values sampled, left unmapped"
+	case kinds > 1:
+		return map[string]interface{}{"type": "keyword"}, "values of several types, mapped as keyword"
+	case s.objects > 0:
+		return nil, ""
+	case s.booleans > 0:
+		return map[string]interface{}{"type": "boolean"}, ""
+	case s.numbers > 0:
+		if s.integers < s.numbers {
+			return map[string]interface{}{"type": "double"}, ""
+		}
+		if s.maxAbs <= math.MaxInt32 {
+			return map[string]interface{}{"type": "integer"}, ""
+		}
+		return map[string]interface{}{"type": "long"}, ""
+	}
+
+	if s.dates == s.strings {
+		return map[string]interface{}{"type": "date"}, ""
+	}
+	if s.dates > 0 {
+		return map[string]interface{}{"type": "keyword"}, "some values are dates, mapped as keyword"
+	}
+
+	avgLength := s.totalLength / s.strings
+	distinctRatio := float64(len(s.distinct)) / float64(s.strings)
+	if s.whitespace == 0 && avgLength <= keywordMaxLength {
+		return map[string]interface{}{"type": "keyword", "ignore_above": 256}, ""
+	}
+	if s.strings >= 10 && distinctRatio <= keywordMaxDistinctRatio {
+		return map[string]interface{}{"type": "keyword", "ignore_above": 256}, "few distinct values, mapped as keyword"
+	}
+
+	mapping := map[string]interface{}{"type": "text"}
+	if avgLength <= shortTextLength {
+		mapping["norms"] = false
+	}
+	return mapping, ""
+}
+
+// setProperty adds the mapping of a dotted field path to a properties object, creating the enclosing objects.
+func setProperty(properties map[string]interface{}, path string, mapping map[string]interface{}) {
+	parts := strings.Split(path, ".")
+	for _, part := range parts[:len(parts)-1] {
+		parent, ok := properties[part].(map[string]interface{})
+		if !ok {
+			parent = map[string]interface{}{"properties": map[string]interface{}{}}
+			properties[part] = parent
+		}
+		children, ok := parent["properties"].(map[string]interface{})
+		if !ok {
+			// The parent is mapped as a scalar because its values have several types.
+			return
+		}
+		properties = children
+	}
+	properties[parts[len(parts)-1]] = mapping
+}
+
+// SampleDocuments returns up to size randomly chosen documents of an index, for use with SuggestMapping.
+func (os *OpenSearch) SampleDocuments(ctx context.Context, indexName string, size int) ([]search.Document, error) {
+	body, err := json.Marshal(map[string]interface{}{
+		"size": size,
+		"query": map[string]interface{}{
+			"function_score": map[string]interface{}{
+				"query":        map[string]interface{}{"match_all": map[string]interface{}{}},
+				"random_score": map[string]interface{}{},
+			},
+		},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal sample query %v", err)
+	}
+
+	read, preference, err := os.readCluster(ctx, ReadReport)
+	if err != nil {
+		return nil, err
+	}
+
+	req := opensearchapi.SearchRequest{
+		Index:      []string{indexName},
+		Body:       bytes.NewReader(body),
+		Preference: preference,
+	}
+
+	resp, err := os.executeReadRequest(ctx, read.client, req)
+	if err != nil {
+		return nil, &ClusterError{Cluster: read.role, Name: read.name, Err: err}
+	}
+
+	var r struct {
+		Hits struct {
+			Hits []searchHit `json:"hits"`
+		} `json:"hits"`
+	}
+	if err := os.decodeResponse(resp, &r); err != nil {
+		return nil, &ClusterError{Cluster: read.role, Name: read.name, Err: err}
+	}
+
+	documents := make([]search.Document, 0, len(r.Hits.Hits))
+	for _, hit := range r.Hits.Hits {
+		documents = append(documents, hit.Source)
+	}
+
+	return documents, nil
+}
@@ -0,0 +1,93 @@
+package opensearch
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+
+	opensearch "github.com/opensearch-project/opensearch-go/v2"
+	opensearchapi "github.com/opensearch-project/opensearch-go/v2/opensearchapi"
+)
+
+// SynonymGraphFilter returns a synonym_graph token filter with inline rules such as
+// "sales rep, account manager". Inline synonyms are updated with UpdateSynonyms.
+func SynonymGraphFilter(synonyms ...string) Component {
+	return Component{Type: "synonym_graph", Params: map[string]interface{}{"synonyms": synonyms}}
+}
+
+// SynonymFileFilter returns an updateable synonym_graph token filter reading its rules from a file on every node,
+// relative to the OpenSearch config directory. Updateable filters may only be used in search analyzers; after the
+// file has been changed on all nodes, ReloadSearchAnalyzers applies the new rules without reindexing.
+func SynonymFileFilter(path string) Component {
+	return Component{Type: "synonym_graph", Params: map[string]interface{}{
+		"synonyms_path": path,
+		"updateable":    true,
+	}}
+}
+
+// UpdateSynonyms replaces the inline rules of a synonym filter on both the primary and, if configured, the
+// secondary OpenSearch clients. Analysis settings can only be changed on a closed index, so the index is briefly
+// closed and reopened; searches against it fail in the meantime. Documents do not need to be reindexed as long
+// as the filter is only used in search analyzers.
+func (os *OpenSearch) UpdateSynonyms(ctx context.Context, indexName, filterName string, synonyms []string) error {
+	if err := os.checkWritable(indexName); err != nil {
+		return err
+	}
+
+	settings, err := json.Marshal(map[string]interface{}{
+		"analysis": map[string]interface{}{
+			"filter": map[string]interface{}{
+				filterName: SynonymGraphFilter(synonyms...),
+			},
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to marshal synonym settings: %v", err)
+	}
+
+	return os.write(ctx, func(client *opensearch.Client) error {
+		return os.updateClosedIndexSettings(ctx, client, indexName, settings)
+	})
+}
+
+// ReloadSearchAnalyzers reloads the search analyzers of an index on both the primary and, if configured, the
+// secondary OpenSearch clients, picking up changed synonym files used by updateable filters. It requires the
+// index management plugin.
+func (os *OpenSearch) ReloadSearchAnalyzers(ctx context.Context, indexName string) error {
+	return os.eachCluster(ctx, func(client *opensearch.Client) error {
+		req := rawRequest{
+			Method: http.MethodPost,
+			Path:   "/_plugins/_refresh_search_analyzers/" + url.PathEscape(indexName),
+		}
+		return os.executeRequest(ctx, client, req)
+	})
+}
+
+// updateClosedIndexSettings closes an index, applies settings that cannot be changed on an open index and
+// reopens it. The index is reopened even if the settings update fails.
+func (os *OpenSearch) updateClosedIndexSettings(ctx context.Context, client *opensearch.Client, indexName string, settings []byte) (err error) {
+	closeReq := opensearchapi.IndicesCloseRequest{Index: []string{indexName}}
+	if err := os.executeRequest(ctx, client, &closeReq); err != nil {
+		return fmt.Errorf("failed to close index: %v", err)
+	}
+
+	defer func() {
+		openReq := opensearchapi.IndicesOpenRequest{Index: []string{indexName}}
+		if openErr := os.executeRequest(ctx, client, &openReq); openErr != nil && err == nil {
+			err = fmt.Errorf("failed to reopen index: %v", openErr)
+		}
+	}()
+
+	putReq := opensearchapi.IndicesPutSettingsRequest{
+		Index: []string{indexName},
+		Body:  bytes.NewReader(settings),
+	}
+	if err := os.executeRequest(ctx, client, &putReq); err != nil {
+		return fmt.Errorf("failed to update settings: %v", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,103 @@
+package opensearch
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/joshilesanmi/open-search-dev/search"
+	opensearch "github.com/opensearch-project/opensearch-go/v2"
+	opensearchapi "github.com/opensearch-project/opensearch-go/v2/opensearchapi"
+)
+
+// InstanceAlias returns the name of the filtered alias exposing only the documents of an instance in an index.
+func InstanceAlias(indexName, instanceID string) string {
+	return indexName + "-instance-" + strings.ToLower(instanceID)
+}
+
+// WithInstanceAliases makes Search query the per-instance alias of the given index, created by ProvisionInstance,
+// instead of all indices. The alias filter complements the instance filter of the query, so a mistake in query
+// construction cannot expose the documents of another instance. Indices given with search.WithSearchIndices are
+// also searched through the aliases of the instance on them, so instances must be provisioned on every index
+// before they are searched.
+func WithInstanceAliases(indexName string) OpenSearchOption {
+	return func(os *OpenSearch) error {
+		if indexName == "" {
+			return errors.New("instance alias index name must not be empty")
+		}
+		os.instanceAliasIndex = indexName
+		return nil
+	}
+}
+
+// ProvisionInstance creates the filtered alias of an instance on an index on every configured cluster. The alias
+// only exposes documents of the instance, so it can also be used to grant per-instance access to dashboards.
+// Provisioning an instance again is a no-op. If configured WithDashboards, the Dashboards objects of the
+// instance are provisioned as well.
+func (os *OpenSearch) ProvisionInstance(ctx context.Context, indexName, instanceID string) error {
+	if err := os.checkWritable(indexName); err != nil {
+		return err
+	}
+
+	if instanceID == "" {
+		return errors.New("instance ID must not be empty")
+	}
+
+	body, err := json.Marshal(map[string]interface{}{
+		"filter": map[string]interface{}{
+			"term": map[string]string{
+				"instance_id": instanceID,
+			},
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to marshal alias filter %v", err)
+	}
+
+	err = os.write(ctx, func(client *opensearch.Client) error {
+		return os.putAlias(ctx, client, indexName, InstanceAlias(indexName, instanceID), body)
+	})
+	if err != nil {
+		return err
+	}
+
+	if os.dashboards != nil {
+		if err := os.dashboards.Provision(ctx, indexName, instanceID, os.visualizations...); err != nil {
+			return fmt.Errorf("dashboards: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// putAlias creates or updates an alias of an index.
+func (os *OpenSearch) putAlias(ctx context.Context, client *opensearch.Client, indexName, alias string, body []byte) error {
+	req := opensearchapi.IndicesPutAliasRequest{
+		Index: []string{indexName},
+		Name:  alias,
+		Body:  bytes.NewReader(body),
+	}
+
+	return os.executeRequest(ctx, client, req)
+}
+
+// searchIndices returns the indices a search of the instance is sent to: the indices of the context, if set with
+// search.WithSearchIndices, or else every index. With instance aliases, the alias of the instance on each of
+// them is searched instead.
+func (os *OpenSearch) searchIndices(ctx context.Context, instanceID string) []string {
+	indices := search.SearchIndices(ctx)
+	if os.instanceAliasIndex == "" {
+		return indices
+	}
+	if len(indices) == 0 {
+		return []string{InstanceAlias(os.instanceAliasIndex, instanceID)}
+	}
+	aliases := make([]string, 0, len(indices))
+	for _, indexName := range indices {
+		aliases = append(aliases, InstanceAlias(indexName, instanceID))
+	}
+	return aliases
+}
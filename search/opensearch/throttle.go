@@ -0,0 +1,77 @@
+package opensearch
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/joshilesanmi/open-search-dev/search"
+)
+
+// ThrottleConfig configures how requests rejected by a throttling cluster are retried.
+type ThrottleConfig struct {
+	MaxRetries int           // Maximum number of retries of a throttled request. Zero disables retries.
+	MaxWait    time.Duration // Longest Retry-After delay that is waited for; longer delays fail immediately.
+}
+
+// defaultThrottle is used unless WithThrottleRetry is given.
+var defaultThrottle = ThrottleConfig{
+	MaxRetries: 2,
+	MaxWait:    10 * time.Second,
+}
+
+// WithThrottleRetry configures the retries of requests the cluster rejects with a 429 and a Retry-After header.
+// Such requests are sent again once the delay has passed, as long as it does not exceed MaxWait and the context
+// allows it. Throttled requests without a Retry-After header fail immediately, as an *APIError matching
+// ErrTooManyRequests. By default requests are retried twice, waiting up to 10s.
+func WithThrottleRetry(config ThrottleConfig) OpenSearchOption {
+	return func(os *OpenSearch) error {
+		if config.MaxRetries < 0 || config.MaxWait < 0 {
+			return errors.New("throttle retries and wait must not be negative")
+		}
+		os.throttle = &config
+		return nil
+	}
+}
+
+// throttleTransport retries requests rejected with a 429 after the delay given by their Retry-After header.
+type throttleTransport struct {
+	config ThrottleConfig
+	clock  search.Clock
+	next   http.RoundTripper
+}
+
+func (t *throttleTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	for attempt := 0; ; attempt++ {
+		resp, err := t.next.RoundTrip(req)
+		if err != nil || resp.StatusCode != http.StatusTooManyRequests || attempt >= t.config.MaxRetries {
+			return resp, err
+		}
+
+		wait := parseRetryAfter(resp.Header.Get("Retry-After"), t.clock.Now())
+		if wait <= 0 || wait > t.config.MaxWait {
+			return resp, nil
+		}
+		// The request can only be sent again if its body can be recreated.
+		if req.Body != nil && req.Body != http.NoBody && req.GetBody == nil {
+			return resp, nil
+		}
+
+		resp.Body.Close()
+
+		select {
+		case <-req.Context().Done():
+			return nil, req.Context().Err()
+		case <-search.After(t.clock, wait):
+		}
+
+		if req.GetBody != nil {
+			body, err := req.GetBody()
+			if err != nil {
+				return nil, err
+			}
+			req = req.Clone(req.Context())
+			req.Body = body
+		}
+	}
+}
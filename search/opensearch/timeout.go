@@ -0,0 +1,368 @@
+package opensearch
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/joshilesanmi/open-search-dev/search"
+)
+
+// defaultConnectTimeout bounds the establishment of connections unless WithConnectTimeout is given.
+const defaultConnectTimeout = 30 * time.Second
+
+// WithConnectTimeout bounds the time to establish a connection to a cluster, including the TLS handshake. It
+// only applies to the default transport. Defaults to 30s.
+func WithConnectTimeout(timeout time.Duration) OpenSearchOption {
+	return func(os *OpenSearch) error {
+		if timeout <= 0 {
+			return errors.New("connect timeout must be positive")
+		}
+		os.connectTimeout = timeout
+		return nil
+	}
+}
+
+// WithRequestTimeout applies a deadline to every operation of the engine that takes a context, so a slow cluster
+// cannot hang callers indefinitely. Deadlines of the caller's context that are earlier still apply.
+func WithRequestTimeout(timeout time.Duration) OpenSearchOption {
+	return func(os *OpenSearch) error {
+		if timeout <= 0 {
+			return errors.New("request timeout must be positive")
+		}
+		os.requestTimeout = timeout
+		return nil
+	}
+}
+
+// WithMethodTimeout applies a deadline to the operation of the engine with the given method name, e.g. a short
+// one for "FindDocument" and a long one for "MigrateDocuments". It takes precedence over WithRequestTimeout.
+func WithMethodTimeout(method string, timeout time.Duration) OpenSearchOption {
+	return func(os *OpenSearch) error {
+		if !timeoutMethods[method] {
+			return fmt.Errorf("unknown method %q", method)
+		}
+		if timeout <= 0 {
+			return errors.New("method timeout must be positive")
+		}
+		if os.methodTimeouts == nil {
+			os.methodTimeouts = make(map[string]time.Duration)
+		}
+		os.methodTimeouts[method] = timeout
+		return nil
+	}
+}
+
+// opensearchTimeoutMiddleware applies the configured deadlines to the operations of the embedded Engine.
+type opensearchTimeoutMiddleware struct {
+	Engine
+	timeout        time.Duration
+	methodTimeouts map[string]time.Duration
+}
+
+// newTimeoutMiddleware returns an OpenSearchMiddleware applying the timeouts configured on the engine.
+func newTimeoutMiddleware(timeout time.Duration, methodTimeouts map[string]time.Duration) OpenSearchMiddleware {
+	return func(next Engine) Engine {
+		return opensearchTimeoutMiddleware{
+			Engine:         next,
+			timeout:        timeout,
+			methodTimeouts: methodTimeouts,
+		}
+	}
+}
+
+// context applies the deadline of a method to the context.
+func (mw opensearchTimeoutMiddleware) context(ctx context.Context, method string) (context.Context, context.CancelFunc) {
+	timeout, ok := mw.methodTimeouts[method]
+	if !ok {
+		timeout = mw.timeout
+	}
+	if timeout <= 0 {
+		return ctx, func() {}
+	}
+	return context.WithTimeout(ctx, timeout)
+}
+
+// timeoutMethods are the methods a timeout can be configured for.
+var timeoutMethods = map[string]bool{
+	"CreateIndex":           true,
+	"DeleteIndex":           true,
+	"RefreshIndex":          true,
+	"PutDocument":           true,
+	"DeleteDocument":        true,
+	"FindDocument":          true,
+	"Search":                true,
+	"Ping":                  true,
+	"ClusterHealth":         true,
+	"NodesInfo":             true,
+	"DiskUsage":             true,
+	"ForceMerge":            true,
+	"AnalyzeText":           true,
+	"UsageReport":           true,
+	"UpdateSynonyms":        true,
+	"ReloadSearchAnalyzers": true,
+	"SelfCheck":             true,
+	"HybridSearch":          true,
+	"SimilarDocuments":      true,
+	"ProvisionInstance":     true,
+	"RegisterPercolator":    true,
+	"UnregisterPercolator":  true,
+	"Percolate":             true,
+	"BulkPutDocuments":      true,
+	"BulkDeleteDocuments":   true,
+	"Warmup":                true,
+	"SimulateIndex":         true,
+	"ConsistencyToken":      true,
+	"WaitForSearchable":     true,
+	"MigrateDocuments":      true,
+	"MSearch":               true,
+	"SubmitAsyncSearch":     true,
+	"GetAsyncSearch":        true,
+	"CancelAsyncSearch":     true,
+	"SampleDocuments":       true,
+	"Reconcile":             true,
+	"ReplayWrites":          true,
+	"CopyIndex":             true,
+	"ListIndices":           true,
+	"DescribeIndex":         true}
+
+func (mw opensearchTimeoutMiddleware) CreateIndex(ctx context.Context, indexName string, config map[string]interface{}) error {
+	ctx, cancel := mw.context(ctx, "CreateIndex")
+	defer cancel()
+	return mw.Engine.CreateIndex(ctx, indexName, config)
+}
+
+func (mw opensearchTimeoutMiddleware) DeleteIndex(ctx context.Context, indexName string, opts ...search.DeleteIndexOption) error {
+	ctx, cancel := mw.context(ctx, "DeleteIndex")
+	defer cancel()
+	return mw.Engine.DeleteIndex(ctx, indexName, opts...)
+}
+
+func (mw opensearchTimeoutMiddleware) RefreshIndex(ctx context.Context, indexName string) error {
+	ctx, cancel := mw.context(ctx, "RefreshIndex")
+	defer cancel()
+	return mw.Engine.RefreshIndex(ctx, indexName)
+}
+
+func (mw opensearchTimeoutMiddleware) PutDocument(ctx context.Context, instanceID, indexName, entityName, entityID string, document search.Document, opts ...search.IndexOption) error {
+	ctx, cancel := mw.context(ctx, "PutDocument")
+	defer cancel()
+	return mw.Engine.PutDocument(ctx, instanceID, indexName, entityName, entityID, document, opts...)
+}
+
+func (mw opensearchTimeoutMiddleware) DeleteDocument(ctx context.Context, instanceID, indexName, entityName, entityID string) error {
+	ctx, cancel := mw.context(ctx, "DeleteDocument")
+	defer cancel()
+	return mw.Engine.DeleteDocument(ctx, instanceID, indexName, entityName, entityID)
+}
+
+func (mw opensearchTimeoutMiddleware) FindDocument(ctx context.Context, instanceID, indexName, entityName, entityID string, opts ...search.FindOption) (search.Document, error) {
+	ctx, cancel := mw.context(ctx, "FindDocument")
+	defer cancel()
+	return mw.Engine.FindDocument(ctx, instanceID, indexName, entityName, entityID, opts...)
+}
+
+func (mw opensearchTimeoutMiddleware) Search(ctx context.Context, instanceID string, query search.Query) (search.SearchResult, error) {
+	ctx, cancel := mw.context(ctx, "Search")
+	defer cancel()
+	return mw.Engine.Search(ctx, instanceID, query)
+}
+
+func (mw opensearchTimeoutMiddleware) Ping(ctx context.Context) error {
+	ctx, cancel := mw.context(ctx, "Ping")
+	defer cancel()
+	return mw.Engine.Ping(ctx)
+}
+
+func (mw opensearchTimeoutMiddleware) ClusterHealth(ctx context.Context) ([]ClusterHealth, error) {
+	ctx, cancel := mw.context(ctx, "ClusterHealth")
+	defer cancel()
+	return mw.Engine.ClusterHealth(ctx)
+}
+
+func (mw opensearchTimeoutMiddleware) NodesInfo(ctx context.Context) ([]NodesInfo, error) {
+	ctx, cancel := mw.context(ctx, "NodesInfo")
+	defer cancel()
+	return mw.Engine.NodesInfo(ctx)
+}
+
+func (mw opensearchTimeoutMiddleware) DiskUsage(ctx context.Context) ([]DiskUsage, error) {
+	ctx, cancel := mw.context(ctx, "DiskUsage")
+	defer cancel()
+	return mw.Engine.DiskUsage(ctx)
+}
+
+func (mw opensearchTimeoutMiddleware) ForceMerge(ctx context.Context, indexName string, opts ...ForceMergeOption) error {
+	ctx, cancel := mw.context(ctx, "ForceMerge")
+	defer cancel()
+	return mw.Engine.ForceMerge(ctx, indexName, opts...)
+}
+
+func (mw opensearchTimeoutMiddleware) AnalyzeText(ctx context.Context, indexName, analyzer, text string) ([]Token, error) {
+	ctx, cancel := mw.context(ctx, "AnalyzeText")
+	defer cancel()
+	return mw.Engine.AnalyzeText(ctx, indexName, analyzer, text)
+}
+
+func (mw opensearchTimeoutMiddleware) UsageReport(ctx context.Context, indexName string) ([]TenantUsage, error) {
+	ctx, cancel := mw.context(ctx, "UsageReport")
+	defer cancel()
+	return mw.Engine.UsageReport(ctx, indexName)
+}
+
+func (mw opensearchTimeoutMiddleware) UpdateSynonyms(ctx context.Context, indexName, filterName string, synonyms []string) error {
+	ctx, cancel := mw.context(ctx, "UpdateSynonyms")
+	defer cancel()
+	return mw.Engine.UpdateSynonyms(ctx, indexName, filterName, synonyms)
+}
+
+func (mw opensearchTimeoutMiddleware) ReloadSearchAnalyzers(ctx context.Context, indexName string) error {
+	ctx, cancel := mw.context(ctx, "ReloadSearchAnalyzers")
+	defer cancel()
+	return mw.Engine.ReloadSearchAnalyzers(ctx, indexName)
+}
+
+func (mw opensearchTimeoutMiddleware) SelfCheck(ctx context.Context, opts ...SelfCheckOption) ([]Finding, error) {
+	ctx, cancel := mw.context(ctx, "SelfCheck")
+	defer cancel()
+	return mw.Engine.SelfCheck(ctx, opts...)
+}
+
+func (mw opensearchTimeoutMiddleware) HybridSearch(ctx context.Context, instanceID string, query search.Query, opts ...HybridOption) ([]search.Document, error) {
+	ctx, cancel := mw.context(ctx, "HybridSearch")
+	defer cancel()
+	return mw.Engine.HybridSearch(ctx, instanceID, query, opts...)
+}
+
+func (mw opensearchTimeoutMiddleware) SimilarDocuments(ctx context.Context, instanceID, indexName, entityName, entityID string, opts ...SimilarOption) ([]search.Document, error) {
+	ctx, cancel := mw.context(ctx, "SimilarDocuments")
+	defer cancel()
+	return mw.Engine.SimilarDocuments(ctx, instanceID, indexName, entityName, entityID, opts...)
+}
+
+func (mw opensearchTimeoutMiddleware) ProvisionInstance(ctx context.Context, indexName, instanceID string) error {
+	ctx, cancel := mw.context(ctx, "ProvisionInstance")
+	defer cancel()
+	return mw.Engine.ProvisionInstance(ctx, indexName, instanceID)
+}
+
+func (mw opensearchTimeoutMiddleware) RegisterPercolator(ctx context.Context, instanceID, indexName, queryID string, query search.Query) error {
+	ctx, cancel := mw.context(ctx, "RegisterPercolator")
+	defer cancel()
+	return mw.Engine.RegisterPercolator(ctx, instanceID, indexName, queryID, query)
+}
+
+func (mw opensearchTimeoutMiddleware) UnregisterPercolator(ctx context.Context, instanceID, indexName, queryID string) error {
+	ctx, cancel := mw.context(ctx, "UnregisterPercolator")
+	defer cancel()
+	return mw.Engine.UnregisterPercolator(ctx, instanceID, indexName, queryID)
+}
+
+func (mw opensearchTimeoutMiddleware) Percolate(ctx context.Context, instanceID, indexName, entityName string, document search.Document) ([]string, error) {
+	ctx, cancel := mw.context(ctx, "Percolate")
+	defer cancel()
+	return mw.Engine.Percolate(ctx, instanceID, indexName, entityName, document)
+}
+
+func (mw opensearchTimeoutMiddleware) BulkPutDocuments(ctx context.Context, indexName string, documents []search.SourceDocument, opts ...search.IndexOption) (search.BulkReport, error) {
+	ctx, cancel := mw.context(ctx, "BulkPutDocuments")
+	defer cancel()
+	return mw.Engine.BulkPutDocuments(ctx, indexName, documents, opts...)
+}
+
+func (mw opensearchTimeoutMiddleware) BulkDeleteDocuments(ctx context.Context, indexName string, keys []search.DocumentKey) (search.BulkReport, error) {
+	ctx, cancel := mw.context(ctx, "BulkDeleteDocuments")
+	defer cancel()
+	return mw.Engine.BulkDeleteDocuments(ctx, indexName, keys)
+}
+
+func (mw opensearchTimeoutMiddleware) Warmup(ctx context.Context, indexName string) error {
+	ctx, cancel := mw.context(ctx, "Warmup")
+	defer cancel()
+	return mw.Engine.Warmup(ctx, indexName)
+}
+
+func (mw opensearchTimeoutMiddleware) SimulateIndex(ctx context.Context, indexName string, document search.Document) (SimulationReport, error) {
+	ctx, cancel := mw.context(ctx, "SimulateIndex")
+	defer cancel()
+	return mw.Engine.SimulateIndex(ctx, indexName, document)
+}
+
+func (mw opensearchTimeoutMiddleware) ConsistencyToken(ctx context.Context, indexName string, keys []search.DocumentKey) (ConsistencyToken, error) {
+	ctx, cancel := mw.context(ctx, "ConsistencyToken")
+	defer cancel()
+	return mw.Engine.ConsistencyToken(ctx, indexName, keys)
+}
+
+func (mw opensearchTimeoutMiddleware) WaitForSearchable(ctx context.Context, token ConsistencyToken) error {
+	ctx, cancel := mw.context(ctx, "WaitForSearchable")
+	defer cancel()
+	return mw.Engine.WaitForSearchable(ctx, token)
+}
+
+func (mw opensearchTimeoutMiddleware) MigrateDocuments(ctx context.Context, indexName string, rules ...search.TransformRule) (int64, error) {
+	ctx, cancel := mw.context(ctx, "MigrateDocuments")
+	defer cancel()
+	return mw.Engine.MigrateDocuments(ctx, indexName, rules...)
+}
+
+func (mw opensearchTimeoutMiddleware) MSearch(ctx context.Context, requests []MSearchRequest) ([]MSearchResponse, error) {
+	ctx, cancel := mw.context(ctx, "MSearch")
+	defer cancel()
+	return mw.Engine.MSearch(ctx, requests)
+}
+
+func (mw opensearchTimeoutMiddleware) SubmitAsyncSearch(ctx context.Context, instanceID string, query search.Query, opts ...AsyncSearchOption) (AsyncSearch, error) {
+	ctx, cancel := mw.context(ctx, "SubmitAsyncSearch")
+	defer cancel()
+	return mw.Engine.SubmitAsyncSearch(ctx, instanceID, query, opts...)
+}
+
+func (mw opensearchTimeoutMiddleware) GetAsyncSearch(ctx context.Context, id string) (AsyncSearch, error) {
+	ctx, cancel := mw.context(ctx, "GetAsyncSearch")
+	defer cancel()
+	return mw.Engine.GetAsyncSearch(ctx, id)
+}
+
+func (mw opensearchTimeoutMiddleware) CancelAsyncSearch(ctx context.Context, id string) error {
+	ctx, cancel := mw.context(ctx, "CancelAsyncSearch")
+	defer cancel()
+	return mw.Engine.CancelAsyncSearch(ctx, id)
+}
+
+func (mw opensearchTimeoutMiddleware) SampleDocuments(ctx context.Context, indexName string, size int) ([]search.Document, error) {
+	ctx, cancel := mw.context(ctx, "SampleDocuments")
+	defer cancel()
+	return mw.Engine.SampleDocuments(ctx, indexName, size)
+}
+
+func (mw opensearchTimeoutMiddleware) Reconcile(ctx context.Context, indexName string, opts ...ReconcileOption) (ReconcileReport, error) {
+	ctx, cancel := mw.context(ctx, "Reconcile")
+	defer cancel()
+	return mw.Engine.Reconcile(ctx, indexName, opts...)
+}
+
+func (mw opensearchTimeoutMiddleware) ReplayWrites(ctx context.Context) (int, error) {
+	ctx, cancel := mw.context(ctx, "ReplayWrites")
+	defer cancel()
+	return mw.Engine.ReplayWrites(ctx)
+}
+
+func (mw opensearchTimeoutMiddleware) CopyIndex(ctx context.Context, indexName string, from, to search.ClusterTarget, opts ...CopyOption) (CopyReport, error) {
+	ctx, cancel := mw.context(ctx, "CopyIndex")
+	defer cancel()
+	return mw.Engine.CopyIndex(ctx, indexName, from, to, opts...)
+}
+
+func (mw opensearchTimeoutMiddleware) ListIndices(ctx context.Context, pattern string) ([]IndexInfo, error) {
+	ctx, cancel := mw.context(ctx, "ListIndices")
+	defer cancel()
+	return mw.Engine.ListIndices(ctx, pattern)
+}
+
+func (mw opensearchTimeoutMiddleware) DescribeIndex(ctx context.Context, indexName string) (IndexDescription, error) {
+	ctx, cancel := mw.context(ctx, "DescribeIndex")
+	defer cancel()
+	return mw.Engine.DescribeIndex(ctx, indexName)
+}
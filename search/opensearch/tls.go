@@ -0,0 +1,101 @@
+package opensearch
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"os"
+)
+
+// WithClientCertificate presents the certificate to the clusters, for clusters requiring mutual TLS.
+func WithClientCertificate(cert tls.Certificate) OpenSearchOption {
+	return func(os *OpenSearch) error {
+		cfg := os.tlsClientConfig()
+		cfg.Certificates = append(cfg.Certificates, cert)
+		return nil
+	}
+}
+
+// WithClientCertificateFiles presents the certificate loaded from a pair of PEM encoded files to the clusters,
+// for clusters requiring mutual TLS.
+func WithClientCertificateFiles(certFile, keyFile string) OpenSearchOption {
+	return func(os *OpenSearch) error {
+		cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+		if err != nil {
+			return fmt.Errorf("failed to load client certificate: %w", err)
+		}
+		return WithClientCertificate(cert)(os)
+	}
+}
+
+// WithRootCAs verifies the certificates of the clusters against the pool instead of the system roots.
+func WithRootCAs(pool *x509.CertPool) OpenSearchOption {
+	return func(os *OpenSearch) error {
+		if pool == nil {
+			return errors.New("root CA pool must not be nil")
+		}
+		os.tlsClientConfig().RootCAs = pool
+		return nil
+	}
+}
+
+// WithCACertificates verifies the certificates of the clusters against the PEM encoded CA certificates, e.g.
+// the CA of a self-hosted cluster, instead of the system roots.
+func WithCACertificates(pem []byte) OpenSearchOption {
+	return func(os *OpenSearch) error {
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return errors.New("no CA certificates found in PEM data")
+		}
+		return WithRootCAs(pool)(os)
+	}
+}
+
+// WithCACertificateFile verifies the certificates of the clusters against the CA certificates of a PEM file.
+func WithCACertificateFile(path string) OpenSearchOption {
+	pem, err := os.ReadFile(path)
+	if err != nil {
+		return func(*OpenSearch) error {
+			return fmt.Errorf("failed to read CA certificates: %w", err)
+		}
+	}
+	return WithCACertificates(pem)
+}
+
+// WithTLSConfig replaces the TLS configuration of the default transport, e.g. to restrict the cipher suites or
+// the minimum version. The configuration is cloned; options such as WithClientCertificate given after it add to
+// the clone. A configuration without a minimum version is raised to TLS 1.2. Disabling certificate verification
+// is rejected here and requires WithInsecureSkipVerify, so it is a deliberate choice.
+func WithTLSConfig(cfg *tls.Config) OpenSearchOption {
+	return func(os *OpenSearch) error {
+		if cfg == nil {
+			return errors.New("TLS config must not be nil")
+		}
+		if cfg.InsecureSkipVerify {
+			return errors.New("TLS config must not skip verification, use WithInsecureSkipVerify")
+		}
+		os.tlsConfig = cfg.Clone()
+		if os.tlsConfig.MinVersion == 0 {
+			os.tlsConfig.MinVersion = tls.VersionTLS12
+		}
+		return nil
+	}
+}
+
+// WithInsecureSkipVerify disables the verification of the certificates of the clusters, for development
+// clusters with self-signed certificates. It must not be used in production; prefer WithCACertificates.
+func WithInsecureSkipVerify() OpenSearchOption {
+	return func(os *OpenSearch) error {
+		os.tlsClientConfig().InsecureSkipVerify = true
+		return nil
+	}
+}
+
+// tlsClientConfig returns the TLS configuration of the default transport, creating it on first use.
+func (os *OpenSearch) tlsClientConfig() *tls.Config {
+	if os.tlsConfig == nil {
+		os.tlsConfig = &tls.Config{}
+	}
+	return os.tlsConfig
+}
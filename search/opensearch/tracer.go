@@ -0,0 +1,38 @@
+package opensearch
+
+import (
+	"errors"
+	"net/http"
+)
+
+// Tracer instruments the HTTP requests sent to a cluster.
+type Tracer interface {
+	// WrapTransport returns a transport tracing the requests sent through next to the named cluster.
+	WrapTransport(cluster string, next http.RoundTripper) http.RoundTripper
+}
+
+// NoopTracer is a Tracer that does not trace requests.
+var NoopTracer Tracer = noopTracer{}
+
+// defaultTracer is used unless WithTracer is given.
+var defaultTracer = NoopTracer
+
+type noopTracer struct{}
+
+func (noopTracer) WrapTransport(_ string, next http.RoundTripper) http.RoundTripper {
+	return next
+}
+
+// WithTracer enables tracing of the requests to the clusters, e.g. WithTracer(XRayTracer) for AWS X-Ray. By
+// default requests are sent through a plain transport and not traced, so the engine also runs outside of AWS.
+// Building with the noxray build tag removes XRayTracer and, with it, every dependency of the package on the AWS
+// SDKs; SQS and SigV4 support live in the sqsqueue and awssigv4 packages.
+func WithTracer(tracer Tracer) OpenSearchOption {
+	return func(os *OpenSearch) error {
+		if tracer == nil {
+			return errors.New("tracer must not be nil")
+		}
+		os.tracer = tracer
+		return nil
+	}
+}
@@ -0,0 +1,32 @@
+//go:build !noxray
+
+package opensearch
+
+import (
+	"net/http"
+
+	"github.com/aws/aws-xray-sdk-go/xray"
+)
+
+// XRayTracer is a Tracer that records requests as AWS X-Ray subsegments, annotated with the cluster name. Requests
+// must be made with a context carrying an X-Ray segment, as in Lambda functions or handlers wrapped by the SDK.
+// It is not available in builds with the noxray build tag.
+var XRayTracer Tracer = xrayTracer{}
+
+type xrayTracer struct{}
+
+func (xrayTracer) WrapTransport(cluster string, next http.RoundTripper) http.RoundTripper {
+	return xray.RoundTripper(&clusterLabelTransport{name: cluster, next: next})
+}
+
+// clusterLabelTransport annotates the X-Ray subsegment of each request with the name of the cluster it targets.
+type clusterLabelTransport struct {
+	name string
+	next http.RoundTripper
+}
+
+func (t *clusterLabelTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	// Requests made without an active segment are not traced, so a missing segment is not an error here.
+	_ = xray.AddAnnotation(req.Context(), "cluster", t.name)
+	return t.next.RoundTrip(req)
+}
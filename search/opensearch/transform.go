@@ -0,0 +1,151 @@
+package opensearch
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/joshilesanmi/open-search-dev/search"
+	opensearch "github.com/opensearch-project/opensearch-go/v2"
+	opensearchapi "github.com/opensearch-project/opensearch-go/v2/opensearchapi"
+)
+
+// WithReadTransform applies transformation rules to every document returned by FindDocument, Search, including
+// collapsed hits, HybridSearch and SimilarDocuments, so consumers see migrated field names and types while the
+// stored documents still use the legacy scheme. A rule that cannot be applied to a document, e.g. a failed type
+// conversion, leaves the field unchanged. FindDocument returns the stored document with search.WithRawDocument,
+// which search.Resync uses to compare documents before any transformation.
+func WithReadTransform(rules ...search.TransformRule) OpenSearchOption {
+	return func(os *OpenSearch) error {
+		if _, _, err := transformScript(rules); err != nil {
+			return err
+		}
+		os.readTransforms = append(os.readTransforms, rules...)
+		return nil
+	}
+}
+
+// transformRead applies the read transformation rules to a document.
+func (os *OpenSearch) transformRead(d search.Document) {
+	for _, rule := range os.readTransforms {
+		_ = rule.Apply(d)
+	}
+}
+
+// presentDocument prepares a document read from a cluster for the caller: it applies the read transformation
+// rules, then the metadata mode.
+func (os *OpenSearch) presentDocument(d search.Document) {
+	os.transformRead(d)
+	d.ApplyMetadataMode(os.readMetadata)
+}
+
+// MigrateDocuments applies transformation rules to the stored documents of an index with an update-by-query on
+// every configured cluster, completing a migration started with WithReadTransform. Documents updated
+// concurrently are skipped rather than failing the migration. It returns the number of documents updated on the
+// primary cluster.
+func (os *OpenSearch) MigrateDocuments(ctx context.Context, indexName string, rules ...search.TransformRule) (int64, error) {
+	if err := os.checkWritable(indexName); err != nil {
+		return 0, err
+	}
+
+	source, params, err := transformScript(rules)
+	if err != nil {
+		return 0, err
+	}
+
+	body, err := json.Marshal(map[string]interface{}{
+		"script": map[string]interface{}{
+			"lang":   "painless",
+			"source": source,
+			"params": params,
+		},
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to marshal migration script %v", err)
+	}
+
+	var updated int64
+	first := true
+	err = os.write(ctx, func(client *opensearch.Client) error {
+		n, err := os.updateByQuery(ctx, client, indexName, body)
+		if first {
+			updated, first = n, false
+		}
+		return err
+	})
+
+	return updated, err
+}
+
+// updateByQuery runs an update-by-query on an index and returns the number of updated documents.
+func (os *OpenSearch) updateByQuery(ctx context.Context, client *opensearch.Client, indexName string, body []byte) (int64, error) {
+	resp, err := os.executeReadRequest(ctx, client, opensearchapi.UpdateByQueryRequest{
+		Index:     []string{indexName},
+		Body:      bytes.NewReader(body),
+		Conflicts: "proceed",
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	var r struct {
+		Updated  int64             `json:"updated"`
+		Failures []json.RawMessage `json:"failures"`
+	}
+	if err := os.decodeResponse(resp, &r); err != nil {
+		return 0, err
+	}
+	if len(r.Failures) > 0 {
+		return r.Updated, fmt.Errorf("%d documents failed to migrate, first: %s", len(r.Failures), r.Failures[0])
+	}
+
+	return r.Updated, nil
+}
+
+// transformScript translates transformation rules into a painless script and its parameters.
+func transformScript(rules []search.TransformRule) (string, map[string]interface{}, error) {
+	var source strings.Builder
+	params := make(map[string]interface{})
+
+	for i, rule := range rules {
+		p := func(name string, value interface{}) string {
+			key := fmt.Sprintf("%s_%d", name, i)
+			params[key] = value
+			return "params." + key
+		}
+
+		switch r := rule.(type) {
+		case search.RenameField:
+			from, to := p("from", r.From), p("to", r.To)
+			fmt.Fprintf(&source, "if (ctx._source.containsKey(%s)) { ctx._source[%s] = ctx._source.remove(%s); } ", from, to, from)
+		case search.SplitField:
+			field, sep := p("field", r.Field), p("separator", r.Separator)
+			fmt.Fprintf(&source, "if (ctx._source[%s] instanceof String) { def parts = new ArrayList(); "+
+				"for (String part : ctx._source[%s].splitOnToken(%s)) { parts.add(part.trim()); } ctx._source[%s] = parts; } ",
+				field, field, sep, field)
+		case search.ConvertField:
+			var conversion string
+			switch r.Type {
+			case search.FieldTypeString:
+				conversion = "String.valueOf(v)"
+			case search.FieldTypeInteger:
+				conversion = "v instanceof Number ? ((Number) v).longValue() : Long.parseLong(v.toString().trim())"
+			case search.FieldTypeFloat:
+				conversion = "v instanceof Number ? ((Number) v).doubleValue() : Double.parseDouble(v.toString().trim())"
+			case search.FieldTypeBoolean:
+				conversion = "v instanceof Boolean ? v : Boolean.parseBoolean(v.toString().trim())"
+			default:
+				return "", nil, fmt.Errorf("unknown field type %q", r.Type)
+			}
+			field := p("field", r.Field)
+			fmt.Fprintf(&source, "if (ctx._source[%s] != null) { def v = ctx._source[%s]; ctx._source[%s] = %s; } ",
+				field, field, field, conversion)
+		default:
+			return "", nil, fmt.Errorf("transformation rule %T cannot be translated to a script", rule)
+		}
+	}
+
+	return strings.TrimSpace(source.String()), params, nil
+}
@@ -0,0 +1,202 @@
+package opensearch
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/joshilesanmi/open-search-dev/search"
+	opensearch "github.com/opensearch-project/opensearch-go/v2"
+)
+
+// WithTransport replaces the HTTP transport used to reach the clusters, e.g. with a recording transport in tests
+// or a transport routing through a corporate proxy. The transport is still wrapped by the tracer, if configured.
+// TLS options such as WithClientCertificate only apply to the default transport.
+func WithTransport(rt http.RoundTripper) OpenSearchOption {
+	return func(os *OpenSearch) error {
+		if rt == nil {
+			return errors.New("transport must not be nil")
+		}
+		os.transport = rt
+		return nil
+	}
+}
+
+// ConnectionPoolConfig tunes the connection pool of the default transport.
+type ConnectionPoolConfig struct {
+	MaxIdleConns        int           // Maximum number of idle connections across all hosts. Zero means unlimited.
+	MaxIdleConnsPerHost int           // Maximum number of idle connections kept per host.
+	MaxConnsPerHost     int           // Maximum number of connections per host, including active ones. Zero means unlimited.
+	IdleConnTimeout     time.Duration // How long an idle connection is kept open. Zero means no limit.
+}
+
+// defaultConnectionPool is used unless WithConnectionPool is given. Unlike the net/http defaults, which keep only
+// 2 idle connections per host, it keeps enough connections to a single-endpoint cluster to avoid reconnecting
+// under concurrent load.
+var defaultConnectionPool = ConnectionPoolConfig{
+	MaxIdleConns:        100,
+	MaxIdleConnsPerHost: 32,
+	IdleConnTimeout:     90 * time.Second,
+}
+
+// WithConnectionPool tunes the connection pool of the default transport, e.g. raising MaxIdleConnsPerHost to the
+// number of concurrent bulk writers so connections are reused rather than churned.
+func WithConnectionPool(config ConnectionPoolConfig) OpenSearchOption {
+	return func(os *OpenSearch) error {
+		if config.MaxIdleConns < 0 || config.MaxIdleConnsPerHost < 0 || config.MaxConnsPerHost < 0 || config.IdleConnTimeout < 0 {
+			return errors.New("connection pool limits must not be negative")
+		}
+		os.connectionPool = &config
+		return nil
+	}
+}
+
+// WithProxy sends the requests to the clusters through the HTTP proxy at proxyURL, e.g.
+// "http://proxy.internal:3128". It only applies to the default transport.
+func WithProxy(proxyURL string) OpenSearchOption {
+	return func(os *OpenSearch) error {
+		u, err := url.Parse(proxyURL)
+		if err != nil {
+			return fmt.Errorf("invalid proxy URL: %w", err)
+		}
+		if u.Scheme == "" || u.Host == "" {
+			return fmt.Errorf("invalid proxy URL %q", proxyURL)
+		}
+		os.proxy = http.ProxyURL(u)
+		return nil
+	}
+}
+
+// WithProxyFromEnvironment sends the requests to the clusters through the proxy configured by the HTTP_PROXY,
+// HTTPS_PROXY and NO_PROXY environment variables. It only applies to the default transport.
+func WithProxyFromEnvironment() OpenSearchOption {
+	return func(os *OpenSearch) error {
+		os.proxy = http.ProxyFromEnvironment
+		return nil
+	}
+}
+
+// WithCompression gzip-compresses the bodies of requests to the clusters, which mostly benefits bulk requests of
+// large documents. Compressed responses are requested and decoded by the default transport regardless.
+func WithCompression() OpenSearchOption {
+	return func(os *OpenSearch) error {
+		os.compress = true
+		return nil
+	}
+}
+
+// WithAddresses adds node addresses to a cluster, identified by its role: PrimaryCluster, SecondaryCluster or
+// SearchCluster. Requests are distributed round-robin over the endpoint and the added addresses; a node failing
+// with a connection error or a 502, 503 or 504 is skipped until it is resurrected after a backoff, so losing one
+// coordinating node does not take the application down.
+func WithAddresses(cluster string, addresses ...string) OpenSearchOption {
+	return func(os *OpenSearch) error {
+		switch cluster {
+		case PrimaryCluster, SecondaryCluster, SearchCluster:
+		default:
+			return fmt.Errorf("unknown cluster %q", cluster)
+		}
+		for _, a := range addresses {
+			if _, err := url.Parse(a); err != nil || a == "" {
+				return fmt.Errorf("invalid address %q", a)
+			}
+		}
+		if os.addresses == nil {
+			os.addresses = make(map[string][]string)
+		}
+		os.addresses[cluster] = append(os.addresses[cluster], addresses...)
+		return nil
+	}
+}
+
+// WithNodeDiscovery discovers the nodes of every cluster when the engine is created and then at the interval,
+// and distributes requests over them. The nodes must be reachable at their published HTTP addresses, which is
+// usually not the case behind a load balancer or in a managed service.
+func WithNodeDiscovery(interval time.Duration) OpenSearchOption {
+	return func(os *OpenSearch) error {
+		if interval <= 0 {
+			return errors.New("node discovery interval must be positive")
+		}
+		os.discoveryInterval = interval
+		return nil
+	}
+}
+
+// newClient creates an OpenSearch client for the cluster with the given role, reaching it at the endpoint and
+// any addresses added WithAddresses. The HTTP transport is wrapped by the tracer, which receives the name of the
+// cluster to label the traced requests with. The same signer and transport are used for every cluster.
+func (os *OpenSearch) newClient(cluster, endpoint, name string) (*opensearch.Client, error) {
+	transport := os.transport
+	if transport == nil {
+		connectTimeout := os.connectTimeout
+		if connectTimeout == 0 {
+			connectTimeout = defaultConnectTimeout
+		}
+		pool := defaultConnectionPool
+		if os.connectionPool != nil {
+			pool = *os.connectionPool
+		}
+		transport = &http.Transport{
+			DialContext: (&net.Dialer{
+				Timeout:   connectTimeout,
+				KeepAlive: 30 * time.Second,
+			}).DialContext,
+			TLSHandshakeTimeout: connectTimeout,
+			TLSClientConfig:     os.tlsClientConfig().Clone(),
+			Proxy:               os.proxy,
+			MaxIdleConns:        pool.MaxIdleConns,
+			MaxIdleConnsPerHost: pool.MaxIdleConnsPerHost,
+			MaxConnsPerHost:     pool.MaxConnsPerHost,
+			IdleConnTimeout:     pool.IdleConnTimeout,
+		}
+	}
+	throttle := defaultThrottle
+	if os.throttle != nil {
+		throttle = *os.throttle
+	}
+	if throttle.MaxRetries > 0 {
+		transport = &throttleTransport{config: throttle, clock: os.clock, next: transport}
+	}
+	if os.authorize != nil {
+		transport = &authTransport{authorize: os.authorize, next: transport}
+	}
+	transport = &requestIDTransport{next: transport}
+
+	return opensearch.NewClient(opensearch.Config{
+		Transport: os.tracer.WrapTransport(name, transport),
+		Addresses: append([]string{endpoint}, os.addresses[cluster]...),
+		Signer:    os.signer,
+
+		CompressRequestBody: os.compress,
+
+		DiscoverNodesOnStart:  os.discoveryInterval > 0,
+		DiscoverNodesInterval: os.discoveryInterval,
+	})
+}
+
+// requestIDHeader carries the request ID of the context to the cluster.
+const requestIDHeader = "X-Request-ID"
+
+// requestIDTransport attaches the request ID of the request context, see search.WithRequestID, to the request.
+// The ID is also sent as the opaque ID, which the cluster records in its slow logs and tasks, unless the request
+// already carries one.
+type requestIDTransport struct {
+	next http.RoundTripper
+}
+
+func (t *requestIDTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	id := search.RequestID(req.Context())
+	if id == "" {
+		return t.next.RoundTrip(req)
+	}
+
+	req = req.Clone(req.Context())
+	req.Header.Set(requestIDHeader, id)
+	if req.Header.Get(opaqueIDHeader) == "" {
+		req.Header.Set(opaqueIDHeader, id)
+	}
+	return t.next.RoundTrip(req)
+}
@@ -0,0 +1,178 @@
+package opensearch
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	opensearchapi "github.com/opensearch-project/opensearch-go/v2/opensearchapi"
+)
+
+// TenantUsage describes the resources used by a single instance (tenant) in an index.
+type TenantUsage struct {
+	InstanceID   string
+	Documents    int64 // Number of documents belonging to the instance.
+	StorageBytes int64 // Estimated primary storage, attributed in proportion to the document count.
+	Queries      int64 // Number of searches issued for the instance by this process since it started.
+}
+
+// UsageReport aggregates per-instance usage of an index, for billing and capacity
+// planning. Document counts are exact and are read from the cluster ReadReport is routed to. OpenSearch does not track storage per document, so the primary store
+// size of the index is attributed to instances in proportion to their document counts. Query volume is counted
+// in-process and therefore only covers searches made through this engine instance.
+func (os *OpenSearch) UsageReport(ctx context.Context, indexName string) ([]TenantUsage, error) {
+	counts, err := os.documentCountsByInstance(ctx, indexName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count documents: %w", err)
+	}
+
+	storeBytes, totalDocs, err := os.indexStoreStats(ctx, indexName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read index stats: %w", err)
+	}
+
+	os.usageMu.Lock()
+	queries := make(map[string]int64, len(os.queryVolume))
+	for id, n := range os.queryVolume {
+		queries[id] = n
+	}
+	os.usageMu.Unlock()
+
+	usage := make([]TenantUsage, 0, len(counts))
+	for id, docs := range counts {
+		u := TenantUsage{
+			InstanceID: id,
+			Documents:  docs,
+			Queries:    queries[id],
+		}
+		if totalDocs > 0 {
+			u.StorageBytes = storeBytes * docs / totalDocs
+		}
+		usage = append(usage, u)
+	}
+
+	sort.Slice(usage, func(i, j int) bool {
+		return usage[i].InstanceID < usage[j].InstanceID
+	})
+
+	return usage, nil
+}
+
+// recordQuery counts a search issued for an instance.
+func (os *OpenSearch) recordQuery(instanceID string) {
+	os.usageMu.Lock()
+	defer os.usageMu.Unlock()
+
+	if os.queryVolume == nil {
+		os.queryVolume = make(map[string]int64)
+	}
+	os.queryVolume[instanceID]++
+}
+
+// documentCountsByInstance pages through a composite aggregation on instance_id, so the counts are exact
+// regardless of how many instances share the index.
+func (os *OpenSearch) documentCountsByInstance(ctx context.Context, indexName string) (map[string]int64, error) {
+	counts := make(map[string]int64)
+
+	read, preference, err := os.readCluster(ctx, ReadReport)
+	if err != nil {
+		return nil, err
+	}
+
+	var after map[string]interface{}
+	for {
+		composite := map[string]interface{}{
+			"size": 1000,
+			"sources": []interface{}{
+				map[string]interface{}{
+					"instance_id": map[string]interface{}{
+						"terms": map[string]interface{}{"field": "instance_id"},
+					},
+				},
+			},
+		}
+		if after != nil {
+			composite["after"] = after
+		}
+
+		body, err := json.Marshal(map[string]interface{}{
+			"size": 0,
+			"aggs": map[string]interface{}{
+				"instances": map[string]interface{}{"composite": composite},
+			},
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal aggregation: %v", err)
+		}
+
+		req := opensearchapi.SearchRequest{
+			Index:      []string{indexName},
+			Body:       bytes.NewReader(body),
+			Preference: preference,
+		}
+
+		resp, err := os.executeReadRequest(ctx, read.client, req)
+		if err != nil {
+			return nil, err
+		}
+
+		var r struct {
+			Aggregations struct {
+				Instances struct {
+					AfterKey map[string]interface{} `json:"after_key"`
+					Buckets  []struct {
+						Key struct {
+							InstanceID string `json:"instance_id"`
+						} `json:"key"`
+						DocCount int64 `json:"doc_count"`
+					} `json:"buckets"`
+				} `json:"instances"`
+			} `json:"aggregations"`
+		}
+		if err := os.decodeResponse(resp, &r); err != nil {
+			return nil, err
+		}
+
+		for _, b := range r.Aggregations.Instances.Buckets {
+			counts[b.Key.InstanceID] = b.DocCount
+		}
+
+		if len(r.Aggregations.Instances.Buckets) == 0 || r.Aggregations.Instances.AfterKey == nil {
+			return counts, nil
+		}
+		after = r.Aggregations.Instances.AfterKey
+	}
+}
+
+// indexStoreStats returns the primary store size in bytes and the primary document count of an index.
+func (os *OpenSearch) indexStoreStats(ctx context.Context, indexName string) (int64, int64, error) {
+	req := opensearchapi.IndicesStatsRequest{
+		Index:  []string{indexName},
+		Metric: []string{"store", "docs"},
+	}
+
+	resp, err := os.executeReadRequest(ctx, os.primary().client, req)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	var r struct {
+		All struct {
+			Primaries struct {
+				Docs struct {
+					Count int64 `json:"count"`
+				} `json:"docs"`
+				Store struct {
+					SizeInBytes int64 `json:"size_in_bytes"`
+				} `json:"store"`
+			} `json:"primaries"`
+		} `json:"_all"`
+	}
+	if err := os.decodeResponse(resp, &r); err != nil {
+		return 0, 0, err
+	}
+
+	return r.All.Primaries.Store.SizeInBytes, r.All.Primaries.Docs.Count, nil
+}
@@ -0,0 +1,68 @@
+package opensearch
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/joshilesanmi/open-search-dev/search"
+	opensearchapi "github.com/opensearch-project/opensearch-go/v2/opensearchapi"
+)
+
+// WarmupQuery is a representative query executed against a new index before it serves user searches.
+type WarmupQuery struct {
+	InstanceID string
+	Query      search.Query
+}
+
+// WithWarmup configures the queries executed by Warmup. Once configured, CreateIndex warms up every index it
+// creates; after an alias swap, Warmup should be called on the promoted index before the swap.
+func WithWarmup(queries ...WarmupQuery) OpenSearchOption {
+	return func(os *OpenSearch) error {
+		os.warmupQueries = append(os.warmupQueries, queries...)
+		return nil
+	}
+}
+
+// Warmup executes the configured warm-up queries against an index on every configured cluster, including the
+// search-only cluster, so that the first user searches after a creation or blue/green promotion do not pay for
+// cold caches. It stops at the first failing query.
+func (os *OpenSearch) Warmup(ctx context.Context, indexName string) error {
+	if len(os.warmupQueries) == 0 {
+		return nil
+	}
+
+	clusters := os.clusters()
+	if os.searchClient != nil {
+		clusters = append(clusters, cluster{role: SearchCluster, name: os.searchName, client: os.searchClient})
+	}
+
+	for _, c := range clusters {
+		for _, wq := range os.warmupQueries {
+			searchQuery, err := os.constructSearchQuery(wq.InstanceID, wq.Query)
+			if err != nil {
+				return fmt.Errorf("warm-up query %q: %w", wq.Query.Value, err)
+			}
+			body, err := json.Marshal(searchQuery)
+			if err != nil {
+				return fmt.Errorf("failed to marshal warm-up query: %v", err)
+			}
+
+			resp, err := os.executeReadRequest(ctx, c.client, opensearchapi.SearchRequest{
+				Index: []string{indexName},
+				Body:  bytes.NewReader(body),
+			})
+			if err != nil {
+				return &ClusterError{Cluster: c.role, Name: c.name, Err: err}
+			}
+
+			var discard json.RawMessage
+			if err := os.decodeResponse(resp, &discard); err != nil {
+				return &ClusterError{Cluster: c.role, Name: c.name, Err: fmt.Errorf("warm-up query %q: %w", wq.Query.Value, err)}
+			}
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,358 @@
+package opensearch
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// QueuedWrite is a document write that could not be applied to a secondary or replica cluster and is replayed
+// once the cluster recovers. Only the identity of the document is queued: the replay copies the version the
+// primary holds at that time, or deletes the document if the primary no longer has it, so replays never
+// overwrite newer writes.
+type QueuedWrite struct {
+	ID         string    `json:"id"`          // Unique ID of the entry.
+	Cluster    string    `json:"cluster"`     // The name of the cluster the write is replayed to.
+	Index      string    `json:"index"`       // The index of the document.
+	DocumentID string    `json:"document_id"` // The engine ID of the document.
+	Queued     time.Time `json:"queued"`      // When the write was queued.
+}
+
+// WriteQueue durably stores queued writes until they are replayed. FileQueue stores them on disk and the Queue of
+// the sqsqueue package in Amazon SQS; queues backed by Redis or similar services implement the same interface. Implementations must be
+// safe for concurrent use.
+type WriteQueue interface {
+	// Enqueue stores a write.
+	Enqueue(ctx context.Context, write QueuedWrite) error
+
+	// Pending returns up to limit stored writes, oldest first.
+	Pending(ctx context.Context, limit int) ([]QueuedWrite, error)
+
+	// Remove deletes replayed writes by their IDs.
+	Remove(ctx context.Context, ids []string) error
+
+	// Len returns the number of stored writes.
+	Len(ctx context.Context) (int, error)
+}
+
+// WriteBehindConfig configures the write-behind queue.
+type WriteBehindConfig struct {
+	Queue          WriteQueue    // Stores the failed writes.
+	ReplayInterval time.Duration // Minimum time between automatic replays. Defaults to 30 seconds.
+	BatchSize      int           // Number of writes replayed per round trip to the queue. Defaults to 100.
+}
+
+// WithWriteBehind queues the document writes that fail on a secondary or replica cluster, or are skipped by
+// WritePrimaryOnlyOnSecondaryFailure, instead of failing the operation, so migration targets do not silently
+// miss documents. The queue is replayed in the background once a write to the cluster succeeds again, at most
+// every ReplayInterval, and on demand with ReplayWrites. Operations other than document writes, e.g. index
+// creation, are not queued. If a write cannot be queued, the WritePolicy of the cluster applies.
+func WithWriteBehind(config WriteBehindConfig) OpenSearchOption {
+	return func(os *OpenSearch) error {
+		if config.Queue == nil {
+			return errors.New("write-behind queue must not be nil")
+		}
+		if config.ReplayInterval <= 0 {
+			config.ReplayInterval = 30 * time.Second
+		}
+		if config.BatchSize <= 0 {
+			config.BatchSize = 100
+		}
+		os.writeBehind = &writeBehind{config: config}
+		return nil
+	}
+}
+
+// writeBehind tracks the replays of the write-behind queue.
+type writeBehind struct {
+	config WriteBehindConfig
+
+	replaying  atomic.Bool
+	lastReplay atomic.Int64 // Unix nanoseconds of the start of the last automatic replay.
+}
+
+// documentRef identifies the document written by an operation, so a failed write can be queued.
+type documentRef struct {
+	index string
+	id    string
+}
+
+// enqueue queues the write of a document to a cluster. It reports whether the write was queued.
+func (os *OpenSearch) enqueue(ctx context.Context, c cluster, doc documentRef) bool {
+	if os.writeBehind == nil {
+		return false
+	}
+	err := os.writeBehind.config.Queue.Enqueue(ctx, QueuedWrite{
+		ID:         os.ids.NewID(),
+		Cluster:    c.name,
+		Index:      doc.index,
+		DocumentID: doc.id,
+		Queued:     os.clock.Now(),
+	})
+	if err != nil {
+		return false
+	}
+	os.replication.queued.Add(1)
+	return true
+}
+
+// enqueueItems queues the writes of bulk items to a cluster. It reports whether every write was queued.
+func (os *OpenSearch) enqueueItems(ctx context.Context, c cluster, indexName string, items []bulkItem) bool {
+	for _, item := range items {
+		if !os.enqueue(ctx, c, documentRef{index: indexName, id: item.id}) {
+			return false
+		}
+	}
+	return true
+}
+
+// replayInBackground starts a replay of the queue after a write to a cluster succeeded, unless one is running or
+// the last one started less than ReplayInterval ago. Writes queued for clusters that are still unavailable stop
+// the replay until the next attempt.
+func (os *OpenSearch) replayInBackground() {
+	wb := os.writeBehind
+	if wb == nil || os.replication.queued.Load() <= 0 {
+		return
+	}
+	now := os.clock.Now()
+	if now.Sub(unixTime(wb.lastReplay.Load())) < wb.config.ReplayInterval || !wb.replaying.CompareAndSwap(false, true) {
+		return
+	}
+	wb.lastReplay.Store(now.UnixNano())
+
+	go func() {
+		defer wb.replaying.Store(false)
+		_, _ = os.replayWrites(context.Background())
+	}()
+}
+
+// ReplayWrites replays the writes of the write-behind queue, oldest first, and returns the number replayed. It
+// stops at the first write that fails, typically because its cluster is still unavailable. Writes queued for a
+// cluster that is no longer configured are discarded.
+func (os *OpenSearch) ReplayWrites(ctx context.Context) (int, error) {
+	if os.writeBehind == nil {
+		return 0, errors.New("no write-behind queue configured")
+	}
+	return os.replayWrites(ctx)
+}
+
+func (os *OpenSearch) replayWrites(ctx context.Context) (int, error) {
+	queue := os.writeBehind.config.Queue
+	replayed := 0
+
+	for {
+		writes, err := queue.Pending(ctx, os.writeBehind.config.BatchSize)
+		if err != nil || len(writes) == 0 {
+			return replayed, err
+		}
+
+		clusters := make(map[string]cluster)
+		for _, c := range os.clusters() {
+			clusters[c.name] = c
+		}
+		primary := os.primary()
+
+		done := make([]string, 0, len(writes))
+		var replayErr error
+		for _, w := range writes {
+			if c, ok := clusters[w.Cluster]; ok && c.role != PrimaryCluster {
+				if replayErr = os.replayWrite(ctx, primary, c, w); replayErr != nil {
+					break
+				}
+				replayed++
+			}
+			done = append(done, w.ID)
+		}
+
+		if len(done) > 0 {
+			if err := queue.Remove(ctx, done); err != nil {
+				return replayed, err
+			}
+			os.replication.queued.Add(-int64(len(done)))
+		}
+		if replayErr != nil {
+			return replayed, replayErr
+		}
+	}
+}
+
+// replayWrite copies the current version of a document from the primary to a cluster, or deletes it from the
+// cluster if the primary no longer has it.
+func (os *OpenSearch) replayWrite(ctx context.Context, primary, c cluster, w QueuedWrite) error {
+	doc, err := os.findDocument(ctx, primary.client, w.Index, w.DocumentID, "")
+	if errors.Is(err, ErrDocumentNotFound) {
+		err = os.deleteDocument(ctx, c.client, w.Index, w.DocumentID)
+		if errors.Is(err, ErrDocumentNotFound) {
+			return nil
+		}
+		return wrapReplayError(c, err)
+	}
+	if err != nil {
+		return &ClusterError{Cluster: primary.role, Name: primary.name, Err: err}
+	}
+
+	body, err := json.Marshal(doc)
+	if err != nil {
+		return fmt.Errorf("failed to marshal document %v", err)
+	}
+	return wrapReplayError(c, os.putDocument(ctx, c.client, w.Index, w.DocumentID, body, ""))
+}
+
+func wrapReplayError(c cluster, err error) error {
+	if err == nil {
+		return nil
+	}
+	return &ClusterError{Cluster: c.role, Name: c.name, Err: err}
+}
+
+// FileQueue is a WriteQueue stored in a file of newline-delimited JSON, synced on every change. It keeps the
+// queued writes in memory and suits a single process; concurrent processes need separate files.
+type FileQueue struct {
+	path string
+
+	mu     sync.Mutex
+	writes []QueuedWrite
+}
+
+// NewFileQueue opens the queue stored at path, creating it if it does not exist.
+func NewFileQueue(path string) (*FileQueue, error) {
+	q := &FileQueue{path: path}
+
+	f, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return q, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		var w QueuedWrite
+		if err := json.Unmarshal(scanner.Bytes(), &w); err != nil {
+			return nil, fmt.Errorf("failed to decode queued write: %w", err)
+		}
+		q.writes = append(q.writes, w)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return q, nil
+}
+
+// Enqueue appends a write to the file.
+func (q *FileQueue) Enqueue(_ context.Context, write QueuedWrite) error {
+	b, err := json.Marshal(write)
+	if err != nil {
+		return err
+	}
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	f, err := os.OpenFile(q.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(append(b, '\n')); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+
+	q.writes = append(q.writes, write)
+	return nil
+}
+
+// Pending returns up to limit writes, oldest first.
+func (q *FileQueue) Pending(_ context.Context, limit int) ([]QueuedWrite, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if limit > len(q.writes) {
+		limit = len(q.writes)
+	}
+	return append([]QueuedWrite(nil), q.writes[:limit]...), nil
+}
+
+// Remove deletes writes and rewrites the file.
+func (q *FileQueue) Remove(_ context.Context, ids []string) error {
+	remove := make(map[string]bool, len(ids))
+	for _, id := range ids {
+		remove[id] = true
+	}
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	kept := make([]QueuedWrite, 0, len(q.writes))
+	for _, w := range q.writes {
+		if !remove[w.ID] {
+			kept = append(kept, w)
+		}
+	}
+
+	if err := q.rewrite(kept); err != nil {
+		return err
+	}
+	q.writes = kept
+	return nil
+}
+
+// Len returns the number of queued writes.
+func (q *FileQueue) Len(_ context.Context) (int, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return len(q.writes), nil
+}
+
+// rewrite atomically replaces the file with the given writes.
+func (q *FileQueue) rewrite(writes []QueuedWrite) error {
+	tmp, err := os.CreateTemp(filepath.Dir(q.path), filepath.Base(q.path)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if err := writeQueued(tmp, writes); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), q.path)
+}
+
+func writeQueued(w io.Writer, writes []QueuedWrite) error {
+	enc := json.NewEncoder(w)
+	for _, write := range writes {
+		if err := enc.Encode(write); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,132 @@
+package search
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// DSL returns the query DSL body sent to the search engine for the query, including the filter restricting
+// the results to the given instance.
+func (q Query) DSL(instanceID string) map[string]interface{} {
+	var filter interface{} = InstanceFilter(instanceID)
+
+	if len(q.Filters) > 0 {
+		filters := []interface{}{filter}
+		for _, f := range q.Filters {
+			filters = append(filters, f.FilterDSL())
+		}
+		filter = filters
+	}
+
+	boolQuery := map[string]interface{}{
+		"must":   q.match(),
+		"filter": filter,
+	}
+
+	var query interface{} = map[string]interface{}{
+		"bool": boolQuery,
+	}
+
+	if r := q.Relevance; r != nil {
+		if len(r.ExactMatchFields) > 0 {
+			boolQuery["should"] = r.exactMatches(q.Value)
+		}
+		if r.Recency != nil {
+			query = r.Recency.decay(query.(map[string]interface{}))
+		}
+	}
+
+	body := map[string]interface{}{
+		"query": query,
+	}
+	if q.Collapse != nil {
+		body["collapse"] = q.Collapse.dsl()
+	}
+	if q.Size > 0 {
+		body["size"] = q.Size
+	}
+	if len(q.Sort) > 0 {
+		body["sort"] = sortDSL(q.Sort)
+	}
+
+	return body
+}
+
+// InstanceFilter returns the filter clause restricting results to the given instance, which the engine injects
+// into every query.
+func InstanceFilter(instanceID string) map[string]interface{} {
+	return map[string]interface{}{
+		"term": map[string]string{
+			"instance_id": instanceID,
+		},
+	}
+}
+
+// match returns the clause matching the query value, according to the query mode.
+func (q Query) match() map[string]interface{} {
+	clause := map[string]interface{}{
+		"query": q.Value,
+	}
+	if fields := q.fields(); len(fields) > 0 {
+		clause["fields"] = fields
+	}
+
+	switch q.Mode {
+	case QueryModeSimple:
+		// simple_query_string ignores invalid syntax instead of failing and has no field:value syntax.
+		clause["lenient"] = true
+		q.syntaxOptions(clause)
+		return map[string]interface{}{"simple_query_string": clause}
+	case QueryModeMultiMatch:
+		clause["lenient"] = true
+		if q.Operator != OperatorDefault {
+			clause["operator"] = q.Operator.String()
+		}
+		return map[string]interface{}{"multi_match": clause}
+	default:
+		q.syntaxOptions(clause)
+		return map[string]interface{}{"query_string": clause}
+	}
+}
+
+// syntaxOptions sets the options shared by the query_string and simple_query_string clauses.
+func (q Query) syntaxOptions(clause map[string]interface{}) {
+	if q.Operator != OperatorDefault {
+		clause["default_operator"] = q.Operator.String()
+	}
+	if q.AnalyzeWildcard {
+		clause["analyze_wildcard"] = true
+	}
+}
+
+// fields returns the fields searched by the query with their boosts. If the query restricts the searchable
+// fields, boosts of other fields are ignored.
+func (q Query) fields() []string {
+	var boosts map[string]float64
+	if q.Relevance != nil {
+		boosts = q.Relevance.FieldBoosts
+	}
+
+	if len(q.Fields) == 0 {
+		if len(boosts) == 0 {
+			return nil
+		}
+		return q.Relevance.fields()
+	}
+
+	fields := make([]string, 0, len(q.Fields))
+	for _, field := range q.Fields {
+		if boost, ok := boosts[field]; ok {
+			field = fmt.Sprintf("%s^%g", field, boost)
+		}
+		fields = append(fields, field)
+	}
+	return fields
+}
+
+// DebugJSON renders the DSL body of the query, including the injected instance filter, as indented JSON that can
+// be pasted into the Dev Tools console. Defaults an engine applies to queries, such as its relevance or query
+// mode, are not included; engines render the body they send themselves, e.g. opensearch's DebugQuery.
+func (q Query) DebugJSON(instanceID string) ([]byte, error) {
+	return json.MarshalIndent(q.DSL(instanceID), "", "  ")
+}
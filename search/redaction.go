@@ -0,0 +1,187 @@
+package search
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// RedactionAction is what a RedactionRule does to a field.
+type RedactionAction int
+
+const (
+	RedactDrop RedactionAction = iota // Remove the field.
+	RedactHash                        // Replace the field with a keyed hash of its value, keeping it joinable.
+)
+
+// RedactionRule redacts a single field of exported documents. Dotted names refer to fields nested in objects,
+// e.g. "address.street".
+type RedactionRule struct {
+	Field  string
+	Action RedactionAction
+}
+
+// Redactor applies redaction rules to documents and queries before they leave the system, e.g. in exports,
+// command output or logs, so that data shared with third parties excludes PII by construction.
+type Redactor struct {
+	key   []byte
+	rules []RedactionRule
+}
+
+// NewRedactor returns a Redactor applying the given rules. The key is used for hashed fields, so that hashes
+// cannot be reversed by hashing guessed values without it.
+func NewRedactor(key []byte, rules ...RedactionRule) *Redactor {
+	return &Redactor{key: key, rules: rules}
+}
+
+// Redact returns a redacted copy of the document; the document itself is not modified.
+func (r *Redactor) Redact(d Document) (Document, error) {
+	b, err := json.Marshal(d)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal document %v", err)
+	}
+
+	var redacted Document
+	if err := json.Unmarshal(b, &redacted); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal document %v", err)
+	}
+
+	for _, rule := range r.rules {
+		if err := r.apply(redacted, strings.Split(rule.Field, "."), rule.Action); err != nil {
+			return nil, fmt.Errorf("field %q: %w", rule.Field, err)
+		}
+	}
+
+	return redacted, nil
+}
+
+// freeTextClauses are the query clauses whose "query" text is not keyed by a field, and may target any field,
+// e.g. "email:alice@example.com" in a query_string query.
+var freeTextClauses = map[string]bool{
+	"query_string":        true,
+	"simple_query_string": true,
+	"multi_match":         true,
+	"combined_fields":     true,
+}
+
+// RedactQuery returns a redacted copy of a query body, e.g. the DSL of a search: the value of every key naming a
+// redacted field, or one of its subfields such as "email.raw", is dropped or hashed wherever it appears. The text
+// of free-text clauses such as query_string is hashed, as it may name any field.
+func (r *Redactor) RedactQuery(query interface{}) (interface{}, error) {
+	b, err := json.Marshal(query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal query %v", err)
+	}
+
+	var redacted interface{}
+	if err := json.Unmarshal(b, &redacted); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal query %v", err)
+	}
+
+	if err := r.redactClauses(redacted); err != nil {
+		return nil, err
+	}
+
+	return redacted, nil
+}
+
+// redactClauses redacts the values keyed by redacted fields in a decoded query body, descending into every
+// object and array.
+func (r *Redactor) redactClauses(v interface{}) error {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		for key, value := range v {
+			if action, ok := r.action(key); ok {
+				if err := r.apply(v, []string{key}, action); err != nil {
+					return fmt.Errorf("field %q: %w", key, err)
+				}
+				continue
+			}
+			if clause, ok := value.(map[string]interface{}); ok && freeTextClauses[key] && len(r.rules) > 0 {
+				if err := r.apply(clause, []string{"query"}, RedactHash); err != nil {
+					return fmt.Errorf("%s: %w", key, err)
+				}
+			}
+			if err := r.redactClauses(value); err != nil {
+				return err
+			}
+		}
+	case []interface{}:
+		for _, item := range v {
+			if err := r.redactClauses(item); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// action returns the action of the rule redacting a field or the field it is a subfield of, if any.
+func (r *Redactor) action(field string) (RedactionAction, bool) {
+	for _, rule := range r.rules {
+		if field == rule.Field || strings.HasPrefix(field, rule.Field+".") {
+			return rule.Action, true
+		}
+	}
+	return 0, false
+}
+
+// apply redacts a field given by its path, descending into objects and arrays of objects.
+func (r *Redactor) apply(object map[string]interface{}, path []string, action RedactionAction) error {
+	value, ok := object[path[0]]
+	if !ok {
+		return nil
+	}
+
+	if len(path) > 1 {
+		switch v := value.(type) {
+		case map[string]interface{}:
+			return r.apply(v, path[1:], action)
+		case []interface{}:
+			for _, item := range v {
+				if nested, ok := item.(map[string]interface{}); ok {
+					if err := r.apply(nested, path[1:], action); err != nil {
+						return err
+					}
+				}
+			}
+		}
+		return nil
+	}
+
+	switch action {
+	case RedactDrop:
+		delete(object, path[0])
+	case RedactHash:
+		hashed, err := r.hash(value)
+		if err != nil {
+			return err
+		}
+		object[path[0]] = hashed
+	default:
+		return fmt.Errorf("unknown redaction action %d", action)
+	}
+
+	return nil
+}
+
+// hash returns the keyed hash of a value. Strings are hashed as is, so equal values in other systems hash the
+// same; other values are hashed in their JSON encoding.
+func (r *Redactor) hash(value interface{}) (string, error) {
+	s, ok := value.(string)
+	if !ok {
+		b, err := json.Marshal(value)
+		if err != nil {
+			return "", err
+		}
+		s = string(b)
+	}
+
+	mac := hmac.New(sha256.New, r.key)
+	mac.Write([]byte(s))
+
+	return hex.EncodeToString(mac.Sum(nil)), nil
+}
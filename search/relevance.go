@@ -0,0 +1,94 @@
+package search
+
+import (
+	"fmt"
+	"sort"
+)
+
+// RelevanceConfig tunes how search results are scored. The zero value scores all fields equally.
+type RelevanceConfig struct {
+	FieldBoosts      map[string]float64 // Per-field score multipliers, e.g. {"name": 3}. Unlisted fields are not searched.
+	ExactMatchFields []string           // Keyword fields whose exact match with the query value is boosted.
+	ExactMatchBoost  float64            // Boost of an exact keyword match. Defaults to 10.
+	Recency          *RecencyDecay      // Favors recently updated documents, if set.
+}
+
+// RecencyDecay decays the score of documents with the age of a date field.
+type RecencyDecay struct {
+	Field  string  // The date field. Defaults to "updated_at".
+	Scale  string  // The age at which the score is multiplied by Decay, e.g. "30d".
+	Offset string  // The age below which scores are not decayed, e.g. "1d".
+	Decay  float64 // The multiplier at Scale. Defaults to 0.5.
+}
+
+// WithRelevance returns a copy of the query scored according to the given configuration.
+func (q Query) WithRelevance(config RelevanceConfig) Query {
+	q.Relevance = &config
+	return q
+}
+
+// fields returns the boosted field list of a query_string query, in a stable order.
+func (c *RelevanceConfig) fields() []string {
+	fields := make([]string, 0, len(c.FieldBoosts))
+	for field, boost := range c.FieldBoosts {
+		fields = append(fields, fmt.Sprintf("%s^%g", field, boost))
+	}
+	sort.Strings(fields)
+	return fields
+}
+
+// exactMatches returns the should clauses boosting exact keyword matches of the query value.
+func (c *RelevanceConfig) exactMatches(value string) []interface{} {
+	boost := c.ExactMatchBoost
+	if boost == 0 {
+		boost = 10
+	}
+
+	clauses := make([]interface{}, 0, len(c.ExactMatchFields))
+	for _, field := range c.ExactMatchFields {
+		clauses = append(clauses, map[string]interface{}{
+			"term": map[string]interface{}{
+				field: map[string]interface{}{
+					"value": value,
+					"boost": boost,
+				},
+			},
+		})
+	}
+	return clauses
+}
+
+// decay wraps a query in a function_score query decaying scores with the age of the document.
+func (d *RecencyDecay) decay(query map[string]interface{}) map[string]interface{} {
+	field := d.Field
+	if field == "" {
+		field = "updated_at"
+	}
+	decay := d.Decay
+	if decay == 0 {
+		decay = 0.5
+	}
+
+	params := map[string]interface{}{
+		"origin": "now",
+		"scale":  d.Scale,
+		"decay":  decay,
+	}
+	if d.Offset != "" {
+		params["offset"] = d.Offset
+	}
+
+	return map[string]interface{}{
+		"function_score": map[string]interface{}{
+			"query": query,
+			"functions": []interface{}{
+				map[string]interface{}{
+					"gauss": map[string]interface{}{
+						field: params,
+					},
+				},
+			},
+			"boost_mode": "multiply",
+		},
+	}
+}
@@ -0,0 +1,81 @@
+package search
+
+import (
+	"context"
+	"time"
+)
+
+// Hit is a single document matched by a search, with its engine metadata.
+type Hit struct {
+	ID       string   // The engine ID of the document.
+	Index    string   // The index the document was found in.
+	Score    float64  // The relevance score of the document.
+	Document Document // The stored document.
+}
+
+// SearchResult is the outcome of a search.
+type SearchResult struct {
+	Hits          []Hit         // The returned page of matches, best first.
+	Total         int64         // The number of documents matching the query overall.
+	TotalRelation string        // "eq" if Total is exact, "gte" if it is a lower bound.
+	Took          time.Duration // The time the engine spent executing the search.
+}
+
+// Documents returns the documents of the hits, in order.
+func (r SearchResult) Documents() []Document {
+	documents := make([]Document, 0, len(r.Hits))
+	for _, hit := range r.Hits {
+		documents = append(documents, hit.Document)
+	}
+	return documents
+}
+
+// SearchDocuments performs a search and returns only the matched documents, for callers written against the
+// earlier Search signature.
+func SearchDocuments(ctx context.Context, engine SearchEngine, instanceID string, query Query) ([]Document, error) {
+	result, err := engine.Search(ctx, instanceID, query)
+	if err != nil {
+		return nil, err
+	}
+	return result.Documents(), nil
+}
+
+// DiffHits compares the hits of the same search on two engines or clusters. It returns the IDs only found in the
+// first or the second list of hits, and whether the IDs found in both are in a different order.
+func DiffHits(hits, other []Hit) (missing, extra []string, orderChanged bool) {
+	in := func(hits []Hit) map[string]bool {
+		ids := make(map[string]bool, len(hits))
+		for _, hit := range hits {
+			ids[hit.ID] = true
+		}
+		return ids
+	}
+	ids, otherIDs := in(hits), in(other)
+
+	var common, otherCommon []string
+	for _, hit := range hits {
+		if otherIDs[hit.ID] {
+			common = append(common, hit.ID)
+		} else {
+			missing = append(missing, hit.ID)
+		}
+	}
+	for _, hit := range other {
+		if ids[hit.ID] {
+			otherCommon = append(otherCommon, hit.ID)
+		} else {
+			extra = append(extra, hit.ID)
+		}
+	}
+
+	if len(common) != len(otherCommon) {
+		return missing, extra, true
+	}
+	for i := range common {
+		if common[i] != otherCommon[i] {
+			orderChanged = true
+			break
+		}
+	}
+	return missing, extra, orderChanged
+}
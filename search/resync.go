@@ -0,0 +1,212 @@
+package search
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+)
+
+// SourceDocument is a single document read from the system of record, together with the identifiers
+// used to address it in the search engine.
+type SourceDocument struct {
+	InstanceID string
+	EntityName string
+	EntityID   string
+	Document   Document
+}
+
+// DocumentSource is an iterator over the documents held by an external system of record.
+// Next returns io.EOF once every document has been read.
+type DocumentSource interface {
+	Next(ctx context.Context) (SourceDocument, error)
+}
+
+// ResyncOption is a function type that applies configuration options to a ResyncOptions instance.
+type ResyncOption func(*ResyncOptions)
+
+// ResyncOptions defines configuration options for a resync run.
+type ResyncOptions struct {
+	WritesPerSecond float64       // Maximum number of documents written per second. Zero disables rate limiting.
+	IndexOptions    []IndexOption // Options passed to every PutDocument issued by the resync.
+	Clock           Clock         // Source of the time writes are rate limited by. Defaults to SystemClock.
+}
+
+// WithResyncRate returns a ResyncOption that limits how many documents are written per second, so a scheduled
+// resync does not compete with live traffic for cluster resources.
+func WithResyncRate(writesPerSecond float64) ResyncOption {
+	return func(opts *ResyncOptions) {
+		opts.WritesPerSecond = writesPerSecond
+	}
+}
+
+// WithResyncIndexOptions returns a ResyncOption that sets the IndexOptions applied to every repaired document.
+func WithResyncIndexOptions(opts ...IndexOption) ResyncOption {
+	return func(o *ResyncOptions) {
+		o.IndexOptions = opts
+	}
+}
+
+// WithResyncClock returns a ResyncOption that replaces the system clock writes are rate limited by, typically
+// with the clock given to the engine, so throttled resyncs can be tested deterministically.
+func WithResyncClock(clock Clock) ResyncOption {
+	return func(o *ResyncOptions) {
+		if clock != nil {
+			o.Clock = clock
+		}
+	}
+}
+
+// ResyncReport summarises the outcome of a resync run.
+type ResyncReport struct {
+	Scanned   int // Documents read from the source.
+	Unchanged int // Documents already present in the index with identical contents.
+	Missing   int // Documents absent from the index that were written.
+	Changed   int // Documents present in the index with different contents that were overwritten.
+	Failed    int // Documents that could not be compared or written.
+
+	FailedItems []ItemFailure // The documents that could not be compared or written, with their reasons.
+}
+
+// Resync walks every document in source, compares its hash against the copy stored in indexName and writes
+// only the documents that are missing or have changed. Writes are rate limited according to the supplied options.
+// Failures for individual documents are counted in the report and do not stop the run; the first of them is
+// returned alongside the report once the source is exhausted.
+func Resync(ctx context.Context, engine SearchEngine, source DocumentSource, indexName string, opts ...ResyncOption) (ResyncReport, error) {
+	options := &ResyncOptions{Clock: SystemClock}
+	for _, opt := range opts {
+		opt(options)
+	}
+
+	begin := options.Clock.Now()
+	var written int
+
+	var report ResyncReport
+	var firstErr error
+
+	fail := func(src SourceDocument, err error) {
+		report.Failed++
+		report.FailedItems = append(report.FailedItems, ItemFailure{
+			DocumentKey: DocumentKey{InstanceID: src.InstanceID, EntityName: src.EntityName, EntityID: src.EntityID},
+			Reason:      err.Error(),
+		})
+		err = fmt.Errorf("document %q: %w", src.EntityID, err)
+		if firstErr == nil {
+			firstErr = err
+		}
+	}
+
+	for {
+		src, err := source.Next(ctx)
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return report, fmt.Errorf("failed to read from document source: %w", err)
+		}
+		report.Scanned++
+
+		state, err := documentState(ctx, engine, indexName, src)
+		if err != nil {
+			fail(src, err)
+			continue
+		}
+		if state == resyncUnchanged {
+			report.Unchanged++
+			continue
+		}
+
+		if err := throttleResync(ctx, options, begin, written); err != nil {
+			return report, err
+		}
+		written++
+
+		err = engine.PutDocument(ctx, src.InstanceID, indexName, src.EntityName, src.EntityID, copyDocument(src.Document), options.IndexOptions...)
+		if err != nil {
+			fail(src, err)
+			continue
+		}
+
+		if state == resyncMissing {
+			report.Missing++
+		} else {
+			report.Changed++
+		}
+	}
+
+	return report, firstErr
+}
+
+// throttleResync waits on the clock of the options until one more write after the n written since begin stays
+// within the rate of the options.
+func throttleResync(ctx context.Context, options *ResyncOptions, begin time.Time, n int) error {
+	if options.WritesPerSecond <= 0 {
+		return nil
+	}
+	wait := time.Duration(float64(n+1)/options.WritesPerSecond*float64(time.Second)) - options.Clock.Now().Sub(begin)
+	if wait <= 0 {
+		return nil
+	}
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-After(options.Clock, wait):
+		return nil
+	}
+}
+
+type resyncState int
+
+const (
+	resyncUnchanged resyncState = iota
+	resyncMissing
+	resyncChanged
+)
+
+// documentState compares the source document with the copy stored in the index.
+func documentState(ctx context.Context, engine SearchEngine, indexName string, src SourceDocument) (resyncState, error) {
+	// The stored copy is compared, as the engine may present documents differently from how it stores them.
+	indexed, err := engine.FindDocument(ctx, src.InstanceID, indexName, src.EntityName, src.EntityID, WithConsistencyCheck(), WithRawDocument())
+	if errors.Is(err, ErrDocumentNotFound) {
+		return resyncMissing, nil
+	}
+	// A mismatch between clusters means at least one copy is stale, so rewrite the document.
+	if errors.Is(err, ErrDocumentMismatch) {
+		return resyncChanged, nil
+	}
+	if err != nil {
+		return resyncUnchanged, err
+	}
+
+	// The index copy carries the engine metadata, so add it to the source copy before hashing.
+	expected, err := copyDocument(src.Document).AddDocumentMetaData(src.InstanceID, src.EntityName, src.EntityID)
+	if err != nil {
+		return resyncUnchanged, err
+	}
+
+	expectedHash, err := expected.Hash()
+	if err != nil {
+		return resyncUnchanged, err
+	}
+
+	indexedHash, err := indexed.Hash()
+	if err != nil {
+		return resyncUnchanged, err
+	}
+
+	if expectedHash != indexedHash {
+		return resyncChanged, nil
+	}
+	return resyncUnchanged, nil
+}
+
+// copyDocument returns a shallow copy of the document, so metadata added during indexing does not leak back
+// into the caller's source document.
+func copyDocument(d Document) Document {
+	c := make(Document, len(d))
+	for k, v := range d {
+		c[k] = v
+	}
+	return c
+}
@@ -2,11 +2,88 @@ package search
 
 import (
 	"context"
+	"errors"
 )
 
+// ErrDocumentNotFound is an error that indicates a requested document could not be found in the search index.
+var ErrDocumentNotFound = errors.New("document not found")
+
+// ErrDocumentMismatch is an error indicating that there is a mismatch between the expected and actual document.
+var ErrDocumentMismatch = errors.New("document mismatch")
+
+// ErrIndexProtected is an error indicating that an index was not deleted because it is protected, for example
+// because it is the write target of an alias. Deleting it requires the WithForceDelete option.
+var ErrIndexProtected = errors.New("index is protected")
+
+// ErrReadOnly is an error indicating that a mutating operation was rejected because writes are fenced,
+// for example during maintenance or a migration.
+var ErrReadOnly = errors.New("search engine is read-only")
+
 // Query represents a search query with a string value used to perform search operations within the search engine.
 type Query struct {
-	Value string
+	Value     string
+	Mode      QueryMode        // How Value is interpreted. Defaults to the mode configured on the engine.
+	Fields    []string         // Allow-list of the fields Value is matched against. Defaults to all fields.
+	Operator  Operator         // How the terms of Value are combined. Defaults to the operator configured on the engine.
+	Priority  Priority         // The priority class of the query. Defaults to PriorityInteractive.
+	Filters   []Filter         // Additional clauses restricting the results, e.g. GeoDistance or Nested.
+	Relevance *RelevanceConfig // Tunes the scoring of results, if set.
+	Collapse  *Collapse        // Deduplicates results by the value of a field, if set.
+	Size      int              // Maximum number of hits returned. Defaults to the engine default of 10.
+	Sort      []SortField      // Orders the hits. Defaults to the relevance score, best first.
+
+	// AnalyzeWildcard analyzes wildcard terms such as "Müll*", so they match like the analyzed text. It applies to
+	// QueryModeQueryString and QueryModeSimple.
+	AnalyzeWildcard bool
+}
+
+// QueryMode selects how the value of a Query is interpreted.
+type QueryMode int
+
+const (
+	QueryModeDefault     QueryMode = iota // The mode configured on the engine, QueryModeQueryString unless set.
+	QueryModeQueryString                  // Full Lucene syntax, including field targeting; fails on invalid syntax.
+	QueryModeSimple                       // Simple syntax (quotes, +, -, |, *) that never fails and cannot target fields.
+	QueryModeMultiMatch                   // Plain text without any syntax, safe for raw user input.
+)
+
+// Operator selects whether all or any of the terms of a query must match.
+type Operator int
+
+const (
+	OperatorDefault Operator = iota // The operator configured on the engine, OperatorOr unless set.
+	OperatorOr                      // Documents matching any term match.
+	OperatorAnd                     // Only documents matching every term match.
+)
+
+// String returns the name of the operator in the query DSL.
+func (o Operator) String() string {
+	switch o {
+	case OperatorAnd:
+		return "and"
+	default:
+		return "or"
+	}
+}
+
+// Priority classifies queries so that background work cannot starve user-facing searches.
+type Priority int
+
+const (
+	PriorityInteractive Priority = iota // User-facing searches that must stay responsive.
+	PriorityBatch                       // Background work such as exports, which can wait.
+)
+
+// String returns the name of the priority class.
+func (p Priority) String() string {
+	switch p {
+	case PriorityInteractive:
+		return "interactive"
+	case PriorityBatch:
+		return "batch"
+	default:
+		return "unknown"
+	}
 }
 
 // IndexOption is a function type that applies configuration options to an IndexOptions instance.
@@ -26,13 +103,67 @@ func WithIndexRefresh(refresh bool) IndexOption {
 	}
 }
 
+// FindOption is a function type that applies configuration options to a FindOptions instance.
+type FindOption func(*FindOptions)
+
+// FindOptions defines configuration options for document lookups.
+type FindOptions struct {
+	ConsistencyCheck bool // If true, engines writing to several clusters verify the document is identical on each.
+	Raw              bool // If true, the document is returned as stored, see WithRawDocument.
+}
+
+// WithConsistencyCheck returns a FindOption that verifies the document is identical on every cluster the engine
+// writes to, failing with ErrDocumentMismatch otherwise. It multiplies the cost of the lookup, so it is meant for
+// verification jobs rather than regular reads.
+func WithConsistencyCheck() FindOption {
+	return func(opts *FindOptions) {
+		opts.ConsistencyCheck = true
+	}
+}
+
+// WithRawDocument returns a FindOption that returns the document as stored, without the presentation configured
+// on the engine for reads, such as the metadata mode or read transforms, e.g. to compare it with the document it
+// was written from.
+func WithRawDocument() FindOption {
+	return func(opts *FindOptions) {
+		opts.Raw = true
+	}
+}
+
+// DeleteIndexOption is a function type that applies configuration options to a DeleteIndexOptions instance.
+type DeleteIndexOption func(*DeleteIndexOptions)
+
+// DeleteIndexOptions defines configuration options for index deletion.
+type DeleteIndexOptions struct {
+	Force     bool // If true, safety checks protecting the index are skipped.
+	Confirmed bool // If true, the caller confirms the deletion to policies requiring elevated confirmation.
+}
+
+// WithForceDelete returns a DeleteIndexOption that skips the safety checks protecting an index from deletion.
+func WithForceDelete() DeleteIndexOption {
+	return func(opts *DeleteIndexOptions) {
+		opts.Force = true
+	}
+}
+
+// WithConfirmation returns a DeleteIndexOption confirming the deletion to policies that require elevated
+// confirmation. Unlike WithForceDelete, it does not override policies that veto the deletion.
+func WithConfirmation() DeleteIndexOption {
+	return func(opts *DeleteIndexOptions) {
+		opts.Confirmed = true
+	}
+}
+
 // SearchEngine defines an interface for interacting with a search engine.
 type SearchEngine interface {
 	// CreateIndex initializes a new index with a given name and configuration.
 	CreateIndex(ctx context.Context, indexName string, config map[string]interface{}) error
 
-	// DeleteIndex removes an index by its name.
-	DeleteIndex(ctx context.Context, indexName string) error
+	// DeleteIndex removes an index by its name. Protected indices are only removed with WithForceDelete.
+	DeleteIndex(ctx context.Context, indexName string, opts ...DeleteIndexOption) error
+
+	// RefreshIndex makes all operations performed on an index since the last refresh visible to search.
+	RefreshIndex(ctx context.Context, indexName string) error
 
 	// PutDocument adds or updates a document within a specific instance and index.
 	PutDocument(ctx context.Context, instanceID, indexName, entityName, entityID string, document Document, opts ...IndexOption) error
@@ -41,8 +172,11 @@ type SearchEngine interface {
 	DeleteDocument(ctx context.Context, instanceID, indexName, entityName, entityID string) error
 
 	// FindDocument retrieves a single document from a specific instance and index.
-	FindDocument(ctx context.Context, instanceID, indexName, entityName, entityID string) (Document, error)
+	FindDocument(ctx context.Context, instanceID, indexName, entityName, entityID string, opts ...FindOption) (Document, error)
 
 	// Search performs a search operation within a specific instance based on the provided query.
-	Search(ctx context.Context, instanceID string, query Query) ([]Document, error)
+	Search(ctx context.Context, instanceID string, query Query) (SearchResult, error)
+
+	// Ping checks that the search engine is reachable, for use in liveness checks.
+	Ping(ctx context.Context) error
 }
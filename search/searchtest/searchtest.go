@@ -0,0 +1,268 @@
+// Package searchtest provides a fake search.SearchEngine for tests of code using a search engine, e.g. to check how
+// a service handles a failing secondary cluster or search errors. The fake records every call, returns programmed
+// responses and injects errors per method; calls that are not programmed are served by an in-memory engine.
+package searchtest
+
+import (
+	"context"
+	"sync"
+
+	"github.com/joshilesanmi/open-search-dev/search"
+	"github.com/joshilesanmi/open-search-dev/search/memory"
+)
+
+// The names of the methods of search.SearchEngine, used to record calls and inject errors.
+const (
+	CreateIndex    = "CreateIndex"
+	DeleteIndex    = "DeleteIndex"
+	RefreshIndex   = "RefreshIndex"
+	PutDocument    = "PutDocument"
+	DeleteDocument = "DeleteDocument"
+	FindDocument   = "FindDocument"
+	Search         = "Search"
+	Ping           = "Ping"
+)
+
+// Call is a recorded call of the fake. Fields that do not apply to the method are empty.
+type Call struct {
+	Method     string
+	InstanceID string
+	IndexName  string
+	EntityName string
+	EntityID   string
+	Document   search.Document // The document passed to PutDocument.
+	Config     map[string]interface{}
+	Query      search.Query
+}
+
+// Fake is a configurable search.SearchEngine. A call is recorded first, then fails with an injected error if one
+// is set for its method, else returns the response of the programmed function of its method, else is passed to
+// Backing. The functions and Backing must be set before the fake is used; the other methods are safe for
+// concurrent use.
+type Fake struct {
+	// Backing serves the calls without a programmed response. Defaults to an empty in-memory engine.
+	Backing search.SearchEngine
+
+	CreateIndexFunc    func(ctx context.Context, indexName string, config map[string]interface{}) error
+	DeleteIndexFunc    func(ctx context.Context, indexName string, opts ...search.DeleteIndexOption) error
+	RefreshIndexFunc   func(ctx context.Context, indexName string) error
+	PutDocumentFunc    func(ctx context.Context, instanceID, indexName, entityName, entityID string, document search.Document, opts ...search.IndexOption) error
+	DeleteDocumentFunc func(ctx context.Context, instanceID, indexName, entityName, entityID string) error
+	FindDocumentFunc   func(ctx context.Context, instanceID, indexName, entityName, entityID string, opts ...search.FindOption) (search.Document, error)
+	SearchFunc         func(ctx context.Context, instanceID string, query search.Query) (search.SearchResult, error)
+	PingFunc           func(ctx context.Context) error
+
+	mu     sync.Mutex
+	calls  []Call
+	errs   map[string]error   // Returned by every call of a method.
+	queued map[string][]error // Returned by the next calls of a method, before errs.
+}
+
+// Ensures the Fake struct correctly implements the search.SearchEngine interface.
+var _ search.SearchEngine = &Fake{}
+
+// NewFake returns a Fake backed by an empty in-memory engine.
+func NewFake() *Fake {
+	return &Fake{Backing: memory.New()}
+}
+
+// FailWith makes every call of a method return err until it is cleared with a nil err or Reset.
+func (f *Fake) FailWith(method string, err error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.errs == nil {
+		f.errs = make(map[string]error)
+	}
+	if err == nil {
+		delete(f.errs, method)
+		return
+	}
+	f.errs[method] = err
+}
+
+// FailNext makes the next calls of a method return the given errors, one per call. A nil error lets its call
+// proceed, so failures can be interleaved with successes.
+func (f *Fake) FailNext(method string, errs ...error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.queued == nil {
+		f.queued = make(map[string][]error)
+	}
+	f.queued[method] = append(f.queued[method], errs...)
+}
+
+// Calls returns the recorded calls of the given methods, or of all methods if none is given, in call order.
+func (f *Fake) Calls(methods ...string) []Call {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if len(methods) == 0 {
+		return append([]Call(nil), f.calls...)
+	}
+
+	var calls []Call
+	for _, c := range f.calls {
+		for _, m := range methods {
+			if c.Method == m {
+				calls = append(calls, c)
+				break
+			}
+		}
+	}
+	return calls
+}
+
+// CallCount returns the number of recorded calls of a method.
+func (f *Fake) CallCount(method string) int {
+	return len(f.Calls(method))
+}
+
+// Reset clears the recorded calls and the injected errors. Programmed functions and the documents of Backing
+// are kept.
+func (f *Fake) Reset() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.calls = nil
+	f.errs = nil
+	f.queued = nil
+}
+
+// record records a call and returns the error injected for it, if any.
+func (f *Fake) record(c Call) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.calls = append(f.calls, c)
+
+	if queued := f.queued[c.Method]; len(queued) > 0 {
+		f.queued[c.Method] = queued[1:]
+		if queued[0] != nil {
+			return queued[0]
+		}
+	}
+	return f.errs[c.Method]
+}
+
+// backing returns the engine serving calls without a programmed response.
+func (f *Fake) backing() search.SearchEngine {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.Backing == nil {
+		f.Backing = memory.New()
+	}
+	return f.Backing
+}
+
+// CreateIndex records the call and creates the index.
+func (f *Fake) CreateIndex(ctx context.Context, indexName string, config map[string]interface{}) error {
+	if err := f.record(Call{Method: CreateIndex, IndexName: indexName, Config: config}); err != nil {
+		return err
+	}
+	if f.CreateIndexFunc != nil {
+		return f.CreateIndexFunc(ctx, indexName, config)
+	}
+	return f.backing().CreateIndex(ctx, indexName, config)
+}
+
+// DeleteIndex records the call and deletes the index.
+func (f *Fake) DeleteIndex(ctx context.Context, indexName string, opts ...search.DeleteIndexOption) error {
+	if err := f.record(Call{Method: DeleteIndex, IndexName: indexName}); err != nil {
+		return err
+	}
+	if f.DeleteIndexFunc != nil {
+		return f.DeleteIndexFunc(ctx, indexName, opts...)
+	}
+	return f.backing().DeleteIndex(ctx, indexName, opts...)
+}
+
+// RefreshIndex records the call and refreshes the index.
+func (f *Fake) RefreshIndex(ctx context.Context, indexName string) error {
+	if err := f.record(Call{Method: RefreshIndex, IndexName: indexName}); err != nil {
+		return err
+	}
+	if f.RefreshIndexFunc != nil {
+		return f.RefreshIndexFunc(ctx, indexName)
+	}
+	return f.backing().RefreshIndex(ctx, indexName)
+}
+
+// PutDocument records the call and stores the document.
+func (f *Fake) PutDocument(ctx context.Context, instanceID, indexName, entityName, entityID string, document search.Document, opts ...search.IndexOption) error {
+	err := f.record(Call{
+		Method:     PutDocument,
+		InstanceID: instanceID,
+		IndexName:  indexName,
+		EntityName: entityName,
+		EntityID:   entityID,
+		Document:   document,
+	})
+	if err != nil {
+		return err
+	}
+	if f.PutDocumentFunc != nil {
+		return f.PutDocumentFunc(ctx, instanceID, indexName, entityName, entityID, document, opts...)
+	}
+	return f.backing().PutDocument(ctx, instanceID, indexName, entityName, entityID, document, opts...)
+}
+
+// DeleteDocument records the call and deletes the document.
+func (f *Fake) DeleteDocument(ctx context.Context, instanceID, indexName, entityName, entityID string) error {
+	err := f.record(Call{
+		Method:     DeleteDocument,
+		InstanceID: instanceID,
+		IndexName:  indexName,
+		EntityName: entityName,
+		EntityID:   entityID,
+	})
+	if err != nil {
+		return err
+	}
+	if f.DeleteDocumentFunc != nil {
+		return f.DeleteDocumentFunc(ctx, instanceID, indexName, entityName, entityID)
+	}
+	return f.backing().DeleteDocument(ctx, instanceID, indexName, entityName, entityID)
+}
+
+// FindDocument records the call and returns the document.
+func (f *Fake) FindDocument(ctx context.Context, instanceID, indexName, entityName, entityID string, opts ...search.FindOption) (search.Document, error) {
+	err := f.record(Call{
+		Method:     FindDocument,
+		InstanceID: instanceID,
+		IndexName:  indexName,
+		EntityName: entityName,
+		EntityID:   entityID,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if f.FindDocumentFunc != nil {
+		return f.FindDocumentFunc(ctx, instanceID, indexName, entityName, entityID, opts...)
+	}
+	return f.backing().FindDocument(ctx, instanceID, indexName, entityName, entityID, opts...)
+}
+
+// Search records the call and returns the matching documents.
+func (f *Fake) Search(ctx context.Context, instanceID string, query search.Query) (search.SearchResult, error) {
+	if err := f.record(Call{Method: Search, InstanceID: instanceID, Query: query}); err != nil {
+		return search.SearchResult{}, err
+	}
+	if f.SearchFunc != nil {
+		return f.SearchFunc(ctx, instanceID, query)
+	}
+	return f.backing().Search(ctx, instanceID, query)
+}
+
+// Ping records the call and pings the backing engine.
+func (f *Fake) Ping(ctx context.Context) error {
+	if err := f.record(Call{Method: Ping}); err != nil {
+		return err
+	}
+	if f.PingFunc != nil {
+		return f.PingFunc(ctx)
+	}
+	return f.backing().Ping(ctx)
+}
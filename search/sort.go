@@ -0,0 +1,55 @@
+package search
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ScoreField sorts hits by their relevance score when used as the field of a SortField.
+const ScoreField = "_score"
+
+// SortField orders search results by a field. Text fields must be sorted by a keyword sub-field, e.g. "name.raw".
+type SortField struct {
+	Field      string
+	Descending bool
+}
+
+// ParseSort parses a comma-separated sort specification such as "created_at:desc,name.raw", as accepted on the
+// command line. Fields are sorted ascending unless followed by ":desc".
+func ParseSort(spec string) ([]SortField, error) {
+	var fields []SortField
+	for _, part := range strings.Split(spec, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+
+		field, order, _ := strings.Cut(part, ":")
+		f := SortField{Field: field}
+		switch strings.ToLower(order) {
+		case "", "asc":
+		case "desc":
+			f.Descending = true
+		default:
+			return nil, fmt.Errorf("invalid sort order %q of field %q, must be asc or desc", order, field)
+		}
+		if f.Field == "" {
+			return nil, fmt.Errorf("invalid sort %q: field is empty", part)
+		}
+		fields = append(fields, f)
+	}
+	return fields, nil
+}
+
+// sortDSL returns the sort clause of a search body.
+func sortDSL(fields []SortField) []interface{} {
+	clauses := make([]interface{}, 0, len(fields))
+	for _, f := range fields {
+		order := "asc"
+		if f.Descending {
+			order = "desc"
+		}
+		clauses = append(clauses, map[string]interface{}{f.Field: map[string]interface{}{"order": order}})
+	}
+	return clauses
+}
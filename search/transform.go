@@ -0,0 +1,119 @@
+package search
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// TransformRule rewrites a field of a document, e.g. to migrate a legacy custom-field naming scheme. Rules are
+// applied to top-level fields; documents without the field are left unchanged.
+type TransformRule interface {
+	// Apply rewrites the document in place.
+	Apply(d Document) error
+}
+
+// Transform applies rules to a document in order.
+func Transform(d Document, rules ...TransformRule) error {
+	for _, rule := range rules {
+		if err := rule.Apply(d); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// RenameField moves the value of a field to a new name, replacing any value stored under the new name.
+type RenameField struct {
+	From string
+	To   string
+}
+
+// Apply renames the field.
+func (r RenameField) Apply(d Document) error {
+	if v, ok := d[r.From]; ok {
+		delete(d, r.From)
+		d[r.To] = v
+	}
+	return nil
+}
+
+// SplitField splits a string field on a separator into a list of trimmed values, e.g. "a, b" into ["a", "b"].
+type SplitField struct {
+	Field     string
+	Separator string
+}
+
+// Apply splits the field. Values that are not strings are left unchanged.
+func (r SplitField) Apply(d Document) error {
+	s, ok := d[r.Field].(string)
+	if !ok {
+		return nil
+	}
+
+	parts := strings.Split(s, r.Separator)
+	values := make([]interface{}, 0, len(parts))
+	for _, p := range parts {
+		values = append(values, strings.TrimSpace(p))
+	}
+	d[r.Field] = values
+
+	return nil
+}
+
+// FieldType is a type a field value can be converted to by ConvertField.
+type FieldType string
+
+const (
+	FieldTypeString  FieldType = "string"
+	FieldTypeInteger FieldType = "integer"
+	FieldTypeFloat   FieldType = "float"
+	FieldTypeBoolean FieldType = "boolean"
+)
+
+// ConvertField converts the value of a field to another type, e.g. a number stored as text to an integer.
+type ConvertField struct {
+	Field string
+	Type  FieldType
+}
+
+// Apply converts the field. Null values are left unchanged.
+func (r ConvertField) Apply(d Document) error {
+	v, ok := d[r.Field]
+	if !ok || v == nil {
+		return nil
+	}
+
+	s := fmt.Sprint(v)
+
+	var err error
+	switch r.Type {
+	case FieldTypeString:
+		d[r.Field] = s
+	case FieldTypeInteger:
+		var n int64
+		// Values decoded from JSON are float64, so accept integral floats.
+		if f, ok := v.(float64); ok && f == float64(int64(f)) {
+			n = int64(f)
+		} else {
+			n, err = strconv.ParseInt(strings.TrimSpace(s), 10, 64)
+		}
+		d[r.Field] = n
+	case FieldTypeFloat:
+		var f float64
+		f, err = strconv.ParseFloat(strings.TrimSpace(s), 64)
+		d[r.Field] = f
+	case FieldTypeBoolean:
+		var b bool
+		b, err = strconv.ParseBool(strings.TrimSpace(s))
+		d[r.Field] = b
+	default:
+		return fmt.Errorf("unknown field type %q", r.Type)
+	}
+	if err != nil {
+		d[r.Field] = v
+		return fmt.Errorf("field %q: cannot convert %q to %s", r.Field, s, r.Type)
+	}
+
+	return nil
+}